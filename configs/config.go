@@ -17,8 +17,14 @@ type Config struct {
 			AllowedMethods   []string `mapstructure:"ALLOWED_METHODS"`
 			AllowedOrigins   []string `mapstructure:"ALLOWED_ORIGINS"`
 			Enable           bool     `mapstructure:"ENABLE"`
+			ExposedHeaders   []string `mapstructure:"EXPOSED_HEADERS"`
 			MaxAgeSeconds    int      `mapstructure:"MAX_AGE_SECONDS"`
 		}
+		Idempotency struct {
+			Store        string `mapstructure:"STORE"`
+			TTLSeconds   int64  `mapstructure:"TTL_SECONDS"`
+			LeaseSeconds int64  `mapstructure:"LEASE_SECONDS"`
+		}
 		Name     string `mapstructure:"NAME"`
 		Revision string `mapstructure:"REVISION"`
 		URL      string `mapstructure:"URL"`
@@ -57,25 +63,92 @@ type Config struct {
 
 	Event struct {
 		Consumer struct {
+			Deduplication struct {
+				LeaseSeconds         int64  `mapstructure:"LEASE_SECONDS"`
+				PurgeIntervalSeconds int64  `mapstructure:"PURGE_INTERVAL_SECONDS"`
+				RetentionSeconds     int64  `mapstructure:"RETENTION_SECONDS"`
+				Store                string `mapstructure:"STORE"`
+			}
+			SNS struct {
+				CertHosts []string `mapstructure:"CERT_HOSTS"`
+				Enabled   bool     `mapstructure:"ENABLED"`
+
+				Topics struct {
+					FooBarBaz struct {
+						ARN string `mapstructure:"ARN"`
+					} `mapstructure:"FOOBARBAZ"`
+					Order struct {
+						ARN string `mapstructure:"ARN"`
+					} `mapstructure:"ORDER"`
+				}
+			}
 			SQS struct {
-				AccessKeyID       string `mapstructure:"ACCESS_KEY_ID"`
-				BackoffSeconds    int    `mapstructure:"BACKOFF_SECONDS"`
-				MaxMessage        int64  `mapstructure:"MAX_MESSAGE"`
-				MaxRetries        int    `mapstructure:"MAX_RETRIES"`
-				MaxRetriesConsume int    `mapstructure:"MAX_RETRIES_CONSUME"`
-				Region            string `mapstructure:"REGION"`
-				SecretAccessKey   string `mapstructure:"SECRET_ACCESS_KEY"`
-				WaitTimeSeconds   int64  `mapstructure:"WAIT_TIME_SECONDS"`
+				AccessKeyID              string `mapstructure:"ACCESS_KEY_ID"`
+				BackoffSeconds           int    `mapstructure:"BACKOFF_SECONDS"`
+				DeadLetterQueueURL       string `mapstructure:"DEAD_LETTER_QUEUE_URL"`
+				MaxMessage               int64  `mapstructure:"MAX_MESSAGE"`
+				MaxReceiveCount          int    `mapstructure:"MAX_RECEIVE_COUNT"`
+				MaxRetries               int    `mapstructure:"MAX_RETRIES"`
+				MaxRetriesConsume        int    `mapstructure:"MAX_RETRIES_CONSUME"`
+				MaxVisibilitySeconds     int64  `mapstructure:"MAX_VISIBILITY_SECONDS"`
+				Region                   string `mapstructure:"REGION"`
+				SecretAccessKey          string `mapstructure:"SECRET_ACCESS_KEY"`
+				VisibilityBackoffSeconds int64  `mapstructure:"VISIBILITY_BACKOFF_SECONDS"`
+				WaitTimeSeconds          int64  `mapstructure:"WAIT_TIME_SECONDS"`
+				Workers                  int    `mapstructure:"WORKERS"`
 
 				Topics struct {
 					FooBarBaz struct {
 						Enabled bool   `mapstructure:"ENABLED"`
 						URL     string `mapstructure:"URL"`
 					} `mapstructure:"FOOBARBAZ"`
+					Order struct {
+						Enabled bool   `mapstructure:"ENABLED"`
+						URL     string `mapstructure:"URL"`
+					} `mapstructure:"ORDER"`
 				}
 			}
 		}
 
+		Kafka struct {
+			Acks               string   `mapstructure:"ACKS"`
+			BackoffMillis      int64    `mapstructure:"BACKOFF_MILLIS"`
+			BatchTimeoutMillis int64    `mapstructure:"BATCH_TIMEOUT_MILLIS"`
+			Brokers            []string `mapstructure:"BROKERS"`
+			DeadLetterTopic    string   `mapstructure:"DEAD_LETTER_TOPIC"`
+			GroupID            string   `mapstructure:"GROUP_ID"`
+			MaxAttempts        int      `mapstructure:"MAX_ATTEMPTS"`
+			MaxWaitMillis      int64    `mapstructure:"MAX_WAIT_MILLIS"`
+			StartOffset        string   `mapstructure:"START_OFFSET"`
+
+			Topics struct {
+				BrandCreated        string `mapstructure:"BRAND_CREATED"`
+				BrandUpdated        string `mapstructure:"BRAND_UPDATED"`
+				FooBarBaz           string `mapstructure:"FOOBARBAZ"`
+				FooCreated          string `mapstructure:"FOO_CREATED"`
+				Order               string `mapstructure:"ORDER"`
+				ProductCreated      string `mapstructure:"PRODUCT_CREATED"`
+				ProductDeleted      string `mapstructure:"PRODUCT_DELETED"`
+				ProductUpdated      string `mapstructure:"PRODUCT_UPDATED"`
+				StockChanged        string `mapstructure:"STOCK_CHANGED"`
+				VariantPriceChanged string `mapstructure:"VARIANT_PRICE_CHANGED"`
+			}
+		}
+
+		Memory struct {
+			Subscriptions            []string `mapstructure:"SUBSCRIPTIONS"`
+			VisibilityTimeoutSeconds int64    `mapstructure:"VISIBILITY_TIMEOUT_SECONDS"`
+		}
+
+		Outbox struct {
+			BackoffSeconds     int   `mapstructure:"BACKOFF_SECONDS"`
+			BatchSize          int   `mapstructure:"BATCH_SIZE"`
+			Enabled            bool  `mapstructure:"ENABLED"`
+			MaxAttempts        int   `mapstructure:"MAX_ATTEMPTS"`
+			MaxBackoffSeconds  int   `mapstructure:"MAX_BACKOFF_SECONDS"`
+			PollIntervalMillis int64 `mapstructure:"POLL_INTERVAL_MILLIS"`
+		}
+
 		Producer struct {
 			SNS struct {
 				AccessKeyID     string `mapstructure:"ACCESS_KEY_ID"`
@@ -83,19 +156,88 @@ type Config struct {
 				Region          string `mapstructure:"REGION"`
 				SecretAccessKey string `mapstructure:"SECRET_ACCESS_KEY"`
 				Topics          struct {
+					BrandCreated struct {
+						ARN     string `mapstructure:"ARN"`
+						Enabled bool   `mapstructure:"ENABLED"`
+					} `mapstructure:"BRAND_CREATED"`
+					BrandUpdated struct {
+						ARN     string `mapstructure:"ARN"`
+						Enabled bool   `mapstructure:"ENABLED"`
+					} `mapstructure:"BRAND_UPDATED"`
 					FooCreated struct {
 						ARN     string `mapstructure:"ARN"`
 						Enabled bool   `mapstructure:"ENABLED"`
 					} `mapstructure:"FOO_CREATED"`
+					ProductCreated struct {
+						ARN     string `mapstructure:"ARN"`
+						Enabled bool   `mapstructure:"ENABLED"`
+					} `mapstructure:"PRODUCT_CREATED"`
+					ProductDeleted struct {
+						ARN     string `mapstructure:"ARN"`
+						Enabled bool   `mapstructure:"ENABLED"`
+					} `mapstructure:"PRODUCT_DELETED"`
+					ProductUpdated struct {
+						ARN     string `mapstructure:"ARN"`
+						Enabled bool   `mapstructure:"ENABLED"`
+					} `mapstructure:"PRODUCT_UPDATED"`
+					StockChanged struct {
+						ARN     string `mapstructure:"ARN"`
+						Enabled bool   `mapstructure:"ENABLED"`
+					} `mapstructure:"STOCK_CHANGED"`
+					VariantPriceChanged struct {
+						ARN     string `mapstructure:"ARN"`
+						Enabled bool   `mapstructure:"ENABLED"`
+					} `mapstructure:"VARIANT_PRICE_CHANGED"`
 				}
 			}
 		}
+
+		Transport string `mapstructure:"TRANSPORT"`
+	}
+
+	OAuth struct {
+		AccessTokenExpirySeconds       int64    `mapstructure:"ACCESS_TOKEN_EXPIRY_SECONDS"`
+		AuthorizationCodeExpirySeconds int64    `mapstructure:"AUTHORIZATION_CODE_EXPIRY_SECONDS"`
+		ClientScope                    []string `mapstructure:"CLIENT_SCOPE"`
+		RefreshTokenExpirySeconds      int64    `mapstructure:"REFRESH_TOKEN_EXPIRY_SECONDS"`
+		TokenFormat                    string   `mapstructure:"TOKEN_FORMAT"`
+
+		JWT struct {
+			ExpirySeconds int64    `mapstructure:"EXPIRY_SECONDS"`
+			Issuer        string   `mapstructure:"ISSUER"`
+			KeyFiles      []string `mapstructure:"KEY_FILES"`
+		}
+
+		Lockout struct {
+			Enabled           bool  `mapstructure:"ENABLED"`
+			MaxUserFailures   int64 `mapstructure:"MAX_USER_FAILURES"`
+			MaxIPFailures     int64 `mapstructure:"MAX_IP_FAILURES"`
+			WindowSeconds     int64 `mapstructure:"WINDOW_SECONDS"`
+			DurationSeconds   int64 `mapstructure:"DURATION_SECONDS"`
+			DelayMilliseconds int64 `mapstructure:"DELAY_MILLISECONDS"`
+			MaxDelaySeconds   int64 `mapstructure:"MAX_DELAY_SECONDS"`
+		}
+	}
+
+	Scheduler struct {
+		Enabled        bool   `mapstructure:"ENABLED"`
+		Lock           string `mapstructure:"LOCK"`
+		LockTTLSeconds int64  `mapstructure:"LOCK_TTL_SECONDS"`
+
+		Jobs struct {
+			OAuthTokenCleanup struct {
+				Schedule string `mapstructure:"SCHEDULE"`
+			} `mapstructure:"OAUTH_TOKEN_CLEANUP"`
+		}
 	}
 
 	Server struct {
-		Env      string `mapstructure:"ENV"`
-		LogLevel string `mapstructure:"LOG_LEVEL"`
-		Port     string `mapstructure:"PORT"`
+		Env                string   `mapstructure:"ENV"`
+		LogLevel           string   `mapstructure:"LOG_LEVEL"`
+		Port               string   `mapstructure:"PORT"`
+		PublicCatalogReads bool     `mapstructure:"PUBLIC_CATALOG_READS"`
+		TrustedProxies     []string `mapstructure:"TRUSTED_PROXIES"`
+
 		Shutdown struct {
 			CleanupPeriodSeconds int64 `mapstructure:"CLEANUP_PERIOD_SECONDS"`
 			GracePeriodSeconds   int64 `mapstructure:"GRACE_PERIOD_SECONDS"`
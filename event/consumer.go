@@ -1,22 +1,59 @@
 package event
 
 import (
+	"context"
+	"sync"
+
 	"github.com/evermos/boilerplate-go/event/domain/foobarbaz"
+	"github.com/evermos/boilerplate-go/event/domain/orders"
 )
 
 // Consumers is the wrapper to contain all event consumers.
 type Consumers struct {
 	FooBarBaz foobarbaz.ConsumerImpl
+	Orders    orders.ConsumerImpl
+	drained   chan struct{}
 }
 
 // ProvideConsumers is the provider function for Consumers.
-func ProvideConsumers(fooBarBaz foobarbaz.ConsumerImpl) Consumers {
+func ProvideConsumers(fooBarBaz foobarbaz.ConsumerImpl, orders orders.ConsumerImpl) Consumers {
 	return Consumers{
 		FooBarBaz: fooBarBaz,
+		Orders:    orders,
 	}
 }
 
-// Start starts all domains event consumer
-func (c *Consumers) Start() {
-	c.FooBarBaz.Start()
+// Start starts all domains event consumer. They stop polling once ctx is done
+// and finish processing the messages they already received.
+func (c *Consumers) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	run := func(consume func(ctx context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			consume(ctx)
+		}()
+	}
+
+	run(c.FooBarBaz.Run)
+	run(c.Orders.Run)
+
+	c.drained = make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(c.drained)
+	}()
+}
+
+// Drain waits until all consumers have stopped and processed their in-flight
+// messages, or until ctx is done.
+func (c *Consumers) Drain(ctx context.Context) {
+	if c.drained == nil {
+		return
+	}
+
+	select {
+	case <-c.drained:
+	case <-ctx.Done():
+	}
 }
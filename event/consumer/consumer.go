@@ -1,5 +1,10 @@
 package consumer
 
+import "context"
+
+// Consumer represents an event consumer interface.
 type Consumer interface {
-	Listen(url string)
+	// Listen consumes messages from url until ctx is done, then returns once
+	// the in-flight messages are processed.
+	Listen(ctx context.Context, url string)
 }
@@ -0,0 +1,67 @@
+package consumer
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DeadLetter is a consumed message that will never be processed successfully.
+type DeadLetter struct {
+	Body           string
+	MessageGroupID *string
+	MessageID      string
+	Reason         error
+	ReceiveCount   int
+	Source         string
+}
+
+// DeadLetterHandler receives messages that can never be processed, so they
+// can be kept aside for inspection instead of being redelivered forever.
+type DeadLetterHandler interface {
+	HandleDeadLetter(letter DeadLetter) error
+}
+
+// LogDeadLetterHandler logs and drops dead-lettered messages.
+type LogDeadLetterHandler struct{}
+
+// HandleDeadLetter logs the dead-lettered message.
+func (LogDeadLetterHandler) HandleDeadLetter(letter DeadLetter) error {
+	log.Error().
+		Err(letter.Reason).
+		Str("source", letter.Source).
+		Str("messageId", letter.MessageID).
+		Int("receiveCount", letter.ReceiveCount).
+		Str("body", letter.Body).
+		Msg("dead-lettered message")
+	return nil
+}
+
+// PermanentError marks a processing error that redelivery cannot fix, such as
+// a malformed payload. Messages failing with it are dead-lettered right away.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps an error as a PermanentError.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent checks whether an error is, or wraps, a PermanentError.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
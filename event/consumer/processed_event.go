@@ -0,0 +1,224 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ProcessedEventStoreTypeRedis selects the Redis-backed processed event
+	// store.
+	ProcessedEventStoreTypeRedis = "redis"
+
+	defaultProcessedEventLease     = 5 * time.Minute
+	defaultProcessedEventRetention = 7 * 24 * time.Hour
+)
+
+// ProcessedEventStatus is the outcome of processing a message.
+type ProcessedEventStatus string
+
+const (
+	// ProcessedEventStatusProcessing means a handler is processing the
+	// message.
+	ProcessedEventStatusProcessing ProcessedEventStatus = "processing"
+	// ProcessedEventStatusSucceeded means a handler processed the message.
+	ProcessedEventStatusSucceeded ProcessedEventStatus = "succeeded"
+	// ProcessedEventStatusFailed means a handler failed processing the
+	// message, which may be claimed again.
+	ProcessedEventStatusFailed ProcessedEventStatus = "failed"
+)
+
+// ErrEventInProgress is returned for a message that another delivery is still
+// processing. The message is retried once the other delivery finished.
+var ErrEventInProgress = errors.New("message is being processed by another delivery")
+
+// ProcessedEvent records a handler processing a message.
+type ProcessedEvent struct {
+	Handler   string               `db:"handler" json:"handler"`
+	MessageID string               `db:"message_id" json:"messageId"`
+	Status    ProcessedEventStatus `db:"status" json:"status"`
+	Attempts  int                  `db:"attempts" json:"attempts"`
+	Expires   time.Time            `db:"expires" json:"expires"`
+}
+
+// ProcessedEventStore remembers which messages each handler processed.
+type ProcessedEventStore interface {
+	// Claim atomically claims a message for a handler for the duration of
+	// the lease. When the message is processed or being processed already,
+	// the existing record is returned and claimed is false.
+	Claim(handler string, messageID string, lease time.Duration) (existing ProcessedEvent, claimed bool, err error)
+	// Complete records a claimed message as processed, so that it is skipped
+	// during the retention window.
+	Complete(handler string, messageID string, retention time.Duration) error
+	// Fail records that processing a claimed message failed, so that the
+	// next delivery claims it again.
+	Fail(handler string, messageID string, reason error) error
+	// Purge deletes the records expired before the given time.
+	Purge(before time.Time) (purged int64, err error)
+}
+
+// ProvideProcessedEventStore is the provider for ProcessedEventStore. The
+// backing store is selected through configuration.
+func ProvideProcessedEventStore(config *configs.Config, db *infras.MySQLConn) ProcessedEventStore {
+	switch config.Event.Consumer.Deduplication.Store {
+	case ProcessedEventStoreTypeRedis:
+		return NewProcessedEventStoreRedis(infras.RedisNewClient(*config))
+	default:
+		return NewProcessedEventStoreMySQL(db)
+	}
+}
+
+// Deduplicator skips the messages a handler processed already, so that
+// redelivered messages are processed once.
+type Deduplicator struct {
+	Store     ProcessedEventStore
+	lease     time.Duration
+	retention time.Duration
+}
+
+// ProvideDeduplicator is the provider for Deduplicator.
+func ProvideDeduplicator(config *configs.Config, store ProcessedEventStore) *Deduplicator {
+	deduplicationConfig := config.Event.Consumer.Deduplication
+	return NewDeduplicator(
+		store,
+		time.Duration(deduplicationConfig.LeaseSeconds)*time.Second,
+		time.Duration(deduplicationConfig.RetentionSeconds)*time.Second,
+	)
+}
+
+// NewDeduplicator creates a Deduplicator. A message is claimed by a delivery
+// for the duration of lease, and skipped for the duration of retention once
+// processed.
+func NewDeduplicator(store ProcessedEventStore, lease time.Duration, retention time.Duration) *Deduplicator {
+	if lease <= 0 {
+		lease = defaultProcessedEventLease
+	}
+	if retention <= 0 {
+		retention = defaultProcessedEventRetention
+	}
+
+	return &Deduplicator{
+		Store:     store,
+		lease:     lease,
+		retention: retention,
+	}
+}
+
+// Wrap wraps a Process of SNS messages so that each message is processed
+// once by the named handler, keyed by its MessageId. Messages without one are
+// processed as they are.
+func (d *Deduplicator) Wrap(handler string, process Process) Process {
+	return func(message []byte) error {
+		snsMessage := model.SNSMessage{}
+		err := json.Unmarshal(message, &snsMessage)
+		if err != nil || snsMessage.MessageID == uuid.Nil {
+			return process(message)
+		}
+		messageID := snsMessage.MessageID.String()
+
+		existing, claimed, err := d.Store.Claim(handler, messageID, d.lease)
+		if err != nil {
+			return fmt.Errorf("failed claiming message: %w", err)
+		}
+		if !claimed {
+			if existing.Status == ProcessedEventStatusSucceeded {
+				log.Info().Str("handler", handler).Str("messageId", messageID).Msg("skipping message processed already")
+				return nil
+			}
+			return ErrEventInProgress
+		}
+
+		err = process(message)
+		if err != nil {
+			if storeErr := d.Store.Fail(handler, messageID, err); storeErr != nil {
+				// the claim expires with its lease instead
+				log.Error().Err(storeErr).Str("handler", handler).Str("messageId", messageID).Msg("failed releasing message claim")
+			}
+			return err
+		}
+
+		if storeErr := d.Store.Complete(handler, messageID, d.retention); storeErr != nil {
+			// the message is processed, failing it would only process it again
+			log.Error().Err(storeErr).Str("handler", handler).Str("messageId", messageID).Msg("failed recording processed message")
+		}
+		return nil
+	}
+}
+
+// ProcessedEventPurger periodically deletes expired processed event records.
+type ProcessedEventPurger struct {
+	Store    ProcessedEventStore
+	interval time.Duration
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// ProvideProcessedEventPurger is the provider for ProcessedEventPurger.
+func ProvideProcessedEventPurger(config *configs.Config, store ProcessedEventStore) *ProcessedEventPurger {
+	return &ProcessedEventPurger{
+		Store:    store,
+		interval: time.Duration(config.Event.Consumer.Deduplication.PurgeIntervalSeconds) * time.Second,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start starts purging in the background.
+func (p *ProcessedEventPurger) Start() {
+	if p.interval <= 0 {
+		log.Info().Msg("Processed event purging is disabled.")
+		close(p.done)
+		return
+	}
+
+	log.Info().Dur("interval", p.interval).Msg("Processed events will be purged periodically.")
+	go p.run()
+}
+
+// Stop stops purging and waits for a running purge to finish.
+func (p *ProcessedEventPurger) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
+
+func (p *ProcessedEventPurger) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.Purge()
+		}
+	}
+}
+
+// Purge deletes the records that expired by now.
+func (p *ProcessedEventPurger) Purge() {
+	purged, err := p.Store.Purge(time.Now())
+	if err != nil {
+		log.Error().Err(err).Msg("failed purging processed events")
+		return
+	}
+
+	if purged > 0 {
+		log.Info().Int64("purged", purged).Msg("Purged processed events.")
+	}
+}
@@ -0,0 +1,127 @@
+package consumer
+
+import (
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlErrorDuplicateEntry = 1062
+
+	queryInsertProcessedEvent = `
+		INSERT INTO processed_events (
+			handler,
+			message_id,
+			status,
+			expires
+		) VALUES (?, ?, ?, ?)`
+
+	// a failed message, or one whose lease ran out, is claimed again
+	queryReclaimProcessedEvent = `
+		UPDATE processed_events
+		SET
+			status = ?,
+			reason = NULL,
+			attempts = attempts + 1,
+			expires = ?
+		WHERE handler = ? AND message_id = ? AND (status = ? OR (status = ? AND expires < ?))`
+
+	querySelectProcessedEvent = `
+		SELECT
+			handler,
+			message_id,
+			status,
+			attempts,
+			expires
+		FROM processed_events
+		WHERE handler = ? AND message_id = ?`
+
+	queryCompleteProcessedEvent = `
+		UPDATE processed_events
+		SET
+			status = ?,
+			expires = ?
+		WHERE handler = ? AND message_id = ?`
+
+	queryFailProcessedEvent = `
+		UPDATE processed_events
+		SET
+			status = ?,
+			reason = ?,
+			expires = ?
+		WHERE handler = ? AND message_id = ?`
+
+	queryPurgeProcessedEvents = "DELETE FROM processed_events WHERE expires < ?"
+)
+
+// ProcessedEventStoreMySQL is the MySQL-backed implementation of
+// ProcessedEventStore.
+type ProcessedEventStoreMySQL struct {
+	db *infras.MySQLConn
+}
+
+// NewProcessedEventStoreMySQL creates a new ProcessedEventStoreMySQL.
+func NewProcessedEventStoreMySQL(db *infras.MySQLConn) *ProcessedEventStoreMySQL {
+	return &ProcessedEventStoreMySQL{db: db}
+}
+
+// Claim claims a message by inserting it, relying on the primary key to reject
+// concurrent claims.
+func (s *ProcessedEventStoreMySQL) Claim(handler string, messageID string, lease time.Duration) (existing ProcessedEvent, claimed bool, err error) {
+	now := time.Now()
+	_, err = s.db.Write.Exec(queryInsertProcessedEvent, handler, messageID, ProcessedEventStatusProcessing, now.Add(lease))
+	if err == nil {
+		return existing, true, nil
+	}
+
+	if mysqlErr, ok := err.(*mysql.MySQLError); !ok || mysqlErr.Number != mysqlErrorDuplicateEntry {
+		return
+	}
+
+	result, err := s.db.Write.Exec(
+		queryReclaimProcessedEvent,
+		ProcessedEventStatusProcessing,
+		now.Add(lease),
+		handler,
+		messageID,
+		ProcessedEventStatusFailed,
+		ProcessedEventStatusProcessing,
+		now,
+	)
+	if err != nil {
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected > 0 {
+		return existing, affected > 0, err
+	}
+
+	// read from the primary so that a fresh claim is visible
+	err = s.db.Write.Get(&existing, querySelectProcessedEvent, handler, messageID)
+	return
+}
+
+// Complete records a claimed message as processed.
+func (s *ProcessedEventStoreMySQL) Complete(handler string, messageID string, retention time.Duration) error {
+	_, err := s.db.Write.Exec(queryCompleteProcessedEvent, ProcessedEventStatusSucceeded, time.Now().Add(retention), handler, messageID)
+	return err
+}
+
+// Fail records that processing a claimed message failed. The record is kept
+// until the next purge to tell how often the message was attempted.
+func (s *ProcessedEventStoreMySQL) Fail(handler string, messageID string, reason error) error {
+	_, err := s.db.Write.Exec(queryFailProcessedEvent, ProcessedEventStatusFailed, reason.Error(), time.Now(), handler, messageID)
+	return err
+}
+
+// Purge deletes the records expired before the given time.
+func (s *ProcessedEventStoreMySQL) Purge(before time.Time) (purged int64, err error) {
+	result, err := s.db.Write.Exec(queryPurgeProcessedEvents, before)
+	if err != nil {
+		return
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,83 @@
+package consumer
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const processedEventRedisKeyPrefix = "processed_event:"
+
+// ProcessedEventStoreRedis is the Redis-backed implementation of
+// ProcessedEventStore. Records expire on their own, so there is nothing to
+// purge.
+type ProcessedEventStoreRedis struct {
+	client *redis.Client
+}
+
+// NewProcessedEventStoreRedis creates a new ProcessedEventStoreRedis.
+func NewProcessedEventStoreRedis(client *redis.Client) *ProcessedEventStoreRedis {
+	return &ProcessedEventStoreRedis{client: client}
+}
+
+func processedEventRedisKey(handler string, messageID string) string {
+	return processedEventRedisKeyPrefix + handler + ":" + messageID
+}
+
+// Claim claims a message using SETNX so that only one delivery wins the race.
+func (s *ProcessedEventStoreRedis) Claim(handler string, messageID string, lease time.Duration) (existing ProcessedEvent, claimed bool, err error) {
+	key := processedEventRedisKey(handler, messageID)
+	value, err := json.Marshal(ProcessedEvent{
+		Handler:   handler,
+		MessageID: messageID,
+		Status:    ProcessedEventStatusProcessing,
+		Attempts:  1,
+		Expires:   time.Now().Add(lease),
+	})
+	if err != nil {
+		return
+	}
+
+	claimed, err = s.client.SetNX(key, value, lease).Result()
+	if err != nil || claimed {
+		return
+	}
+
+	stored, err := s.client.Get(key).Bytes()
+	if err == redis.Nil {
+		// the claim expired in between, try again
+		return s.Claim(handler, messageID, lease)
+	}
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(stored, &existing)
+	return
+}
+
+// Complete records a claimed message as processed.
+func (s *ProcessedEventStoreRedis) Complete(handler string, messageID string, retention time.Duration) error {
+	value, err := json.Marshal(ProcessedEvent{
+		Handler:   handler,
+		MessageID: messageID,
+		Status:    ProcessedEventStatusSucceeded,
+		Expires:   time.Now().Add(retention),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(processedEventRedisKey(handler, messageID), value, retention).Err()
+}
+
+// Fail frees a claimed message.
+func (s *ProcessedEventStoreRedis) Fail(handler string, messageID string, reason error) error {
+	return s.client.Del(processedEventRedisKey(handler, messageID)).Err()
+}
+
+// Purge is a no-op, Redis expires the records itself.
+func (s *ProcessedEventStoreRedis) Purge(before time.Time) (int64, error) {
+	return 0, nil
+}
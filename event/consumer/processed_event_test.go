@@ -0,0 +1,170 @@
+package consumer
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProcessedEventStore struct {
+	mu     sync.Mutex
+	events map[string]ProcessedEvent
+}
+
+func newFakeProcessedEventStore() *fakeProcessedEventStore {
+	return &fakeProcessedEventStore{events: make(map[string]ProcessedEvent)}
+}
+
+func (s *fakeProcessedEventStore) Claim(handler string, messageID string, lease time.Duration) (ProcessedEvent, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := handler + ":" + messageID
+	existing, ok := s.events[key]
+	if ok && existing.Status != ProcessedEventStatusFailed && existing.Expires.After(time.Now()) {
+		return existing, false, nil
+	}
+
+	s.events[key] = ProcessedEvent{
+		Handler:   handler,
+		MessageID: messageID,
+		Status:    ProcessedEventStatusProcessing,
+		Attempts:  existing.Attempts + 1,
+		Expires:   time.Now().Add(lease),
+	}
+	return ProcessedEvent{}, true, nil
+}
+
+func (s *fakeProcessedEventStore) Complete(handler string, messageID string, retention time.Duration) error {
+	return s.set(handler, messageID, ProcessedEventStatusSucceeded, time.Now().Add(retention))
+}
+
+func (s *fakeProcessedEventStore) Fail(handler string, messageID string, reason error) error {
+	return s.set(handler, messageID, ProcessedEventStatusFailed, time.Now())
+}
+
+func (s *fakeProcessedEventStore) set(handler string, messageID string, status ProcessedEventStatus, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := handler + ":" + messageID
+	event := s.events[key]
+	event.Status = status
+	event.Expires = expires
+	s.events[key] = event
+	return nil
+}
+
+func (s *fakeProcessedEventStore) Purge(before time.Time) (purged int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, event := range s.events {
+		if event.Expires.Before(before) {
+			delete(s.events, key)
+			purged++
+		}
+	}
+	return
+}
+
+func newTestNotification(t *testing.T) []byte {
+	messageID, _ := uuid.NewV4()
+	body, err := json.Marshal(model.SNSMessage{Type: "Notification", MessageID: messageID, Message: "{}"})
+	require.NoError(t, err)
+	return body
+}
+
+func TestDeduplicator(t *testing.T) {
+	t.Run("processes a message once per handler", func(t *testing.T) {
+		d := NewDeduplicator(newFakeProcessedEventStore(), time.Minute, time.Hour)
+		calls := map[string]int{}
+		process := func(handler string) Process {
+			return d.Wrap(handler, func([]byte) error {
+				calls[handler]++
+				return nil
+			})
+		}
+		foo, bar := process("foo"), process("bar")
+		message := newTestNotification(t)
+
+		assert.NoError(t, foo(message))
+		assert.NoError(t, foo(message))
+		assert.NoError(t, bar(message))
+		assert.Equal(t, map[string]int{"foo": 1, "bar": 1}, calls)
+	})
+
+	t.Run("processes failed messages again", func(t *testing.T) {
+		d := NewDeduplicator(newFakeProcessedEventStore(), time.Minute, time.Hour)
+		calls := 0
+		process := d.Wrap("foo", func([]byte) error {
+			calls++
+			if calls == 1 {
+				return errors.New("unavailable")
+			}
+			return nil
+		})
+		message := newTestNotification(t)
+
+		assert.Error(t, process(message))
+		assert.NoError(t, process(message))
+		assert.NoError(t, process(message))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("retries messages being processed by another delivery", func(t *testing.T) {
+		store := newFakeProcessedEventStore()
+		d := NewDeduplicator(store, time.Minute, time.Hour)
+		message := newTestNotification(t)
+
+		var nested error
+		process := d.Wrap("foo", func([]byte) error { return nil })
+		outer := d.Wrap("foo", func(body []byte) error {
+			nested = process(body)
+			return nil
+		})
+
+		assert.NoError(t, outer(message))
+		assert.Equal(t, ErrEventInProgress, nested)
+	})
+
+	t.Run("processes messages without an id as they are", func(t *testing.T) {
+		d := NewDeduplicator(newFakeProcessedEventStore(), time.Minute, time.Hour)
+		calls := 0
+		process := d.Wrap("foo", func([]byte) error {
+			calls++
+			return nil
+		})
+
+		assert.NoError(t, process([]byte(`{"Message":"{}"}`)))
+		assert.NoError(t, process([]byte(`{"Message":"{}"}`)))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("processes messages again after the retention window", func(t *testing.T) {
+		store := newFakeProcessedEventStore()
+		d := NewDeduplicator(store, time.Minute, time.Millisecond)
+		calls := 0
+		process := d.Wrap("foo", func([]byte) error {
+			calls++
+			return nil
+		})
+		message := newTestNotification(t)
+
+		assert.NoError(t, process(message))
+		time.Sleep(2 * time.Millisecond)
+		purger := &ProcessedEventPurger{Store: store}
+		purger.Purge()
+		assert.Empty(t, store.events)
+
+		assert.NoError(t, process(message))
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -1,16 +1,36 @@
 package consumer
 
 import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/memory"
+	"github.com/gofrs/uuid"
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	// maxVisibilityTimeout is the longest visibility timeout SQS accepts.
+	maxVisibilityTimeout = 12 * time.Hour
+	// maxDeleteBatchSize is the most messages SQS deletes in one batch.
+	maxDeleteBatchSize = 10
+	// ackFlushInterval bounds how long an acknowledged message waits for its
+	// batch to fill up before it is deleted.
+	ackFlushInterval = time.Second
+
+	attributeSourceQueue   = "source_queue"
+	attributeFailureReason = "failure_reason"
+)
+
 // Process represents the processing function of the message consumer.
 type Process func(e []byte) error
 
@@ -41,33 +61,142 @@ func createSQSConfig(config *configs.Config) (*session.Session, error) {
 	})
 }
 
-// SQSConsumer represents an SQS consumer.
+// SQSConsumer represents an SQS consumer. Messages are processed concurrently
+// and deleted in batches once processed. Messages whose processing fails are
+// left on the queue with an exponentially growing visibility timeout, and are
+// dead-lettered once they have been received MaxReceiveCount times.
 type SQSConsumer struct {
-	Process Process
-	config  *configs.Config
-	sqs     *sqs.SQS
+	Process    Process
+	DeadLetter DeadLetterHandler
+	config     *configs.Config
+	sqs        sqsiface.SQSAPI
 }
 
-// NewSQSConsumer create object Consumer
+// NewSQSConsumer create object Consumer. When the in-memory transport is
+// configured, it consumes from the in-memory broker instead of SQS.
 func NewSQSConsumer(config *configs.Config) *SQSConsumer {
+	if config.Event.Transport == memory.Transport {
+		return NewSQSConsumerWithClient(config, memory.ProvideBroker(config))
+	}
+
 	sess, err := createSQSConfig(config)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed creating sqs config")
 	}
-	return &SQSConsumer{config: config, sqs: sqs.New(sess)}
+	return NewSQSConsumerWithClient(config, sqs.New(sess))
+}
+
+// NewSQSConsumerWithClient creates a Consumer using the given SQS client.
+func NewSQSConsumerWithClient(config *configs.Config, client sqsiface.SQSAPI) *SQSConsumer {
+	return &SQSConsumer{
+		DeadLetter: LogDeadLetterHandler{},
+		config:     config,
+		sqs:        client,
+	}
 }
 
-// Listen is a function to listen new message from sqs queue
-func (p *SQSConsumer) Listen(url string) {
-	log.Info().Str("url", url).Msg("SQS Consumer will start polling.")
+// Listen polls the queue and processes its messages on a pool of workers
+// until ctx is done. Messages of a FIFO message group are always processed by
+// the same worker, in the order they are received. It then stops polling,
+// waits for the in-flight messages to be processed and acknowledged, and
+// returns.
+func (p *SQSConsumer) Listen(ctx context.Context, url string) {
+	workers := p.config.Event.Consumer.SQS.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	log.Info().Str("url", url).Int("workers", workers).Msg("SQS Consumer will start polling.")
+
+	messages := make(chan *sqs.Message)
+	grouped := make([]chan *sqs.Message, workers)
+	acks := make(chan *sqs.Message, workers)
 
+	var processing sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		grouped[i] = make(chan *sqs.Message)
+		processing.Add(1)
+		go func(grouped <-chan *sqs.Message) {
+			defer processing.Done()
+			p.work(url, messages, grouped, acks)
+		}(grouped[i])
+	}
+
+	acknowledged := make(chan struct{})
+	go func() {
+		defer close(acknowledged)
+		p.acknowledge(url, acks)
+	}()
+
+	p.poll(ctx, url, func(message *sqs.Message) {
+		groupID := aws.StringValue(message.Attributes[sqs.MessageSystemAttributeNameMessageGroupId])
+		if groupID == "" {
+			messages <- message
+			return
+		}
+		grouped[groupWorker(groupID, workers)] <- message
+	})
+
+	log.Info().Str("url", url).Msg("SQS Consumer stopped polling, draining in-flight messages.")
+	close(messages)
+	for _, group := range grouped {
+		close(group)
+	}
+	processing.Wait()
+	close(acks)
+	<-acknowledged
+	log.Info().Str("url", url).Msg("SQS Consumer drained.")
+}
+
+// work processes the messages of any group and those of the groups of its
+// worker until both are closed.
+func (p *SQSConsumer) work(url string, messages <-chan *sqs.Message, grouped <-chan *sqs.Message, acks chan<- *sqs.Message) {
+	for messages != nil || grouped != nil {
+		var message *sqs.Message
+		var ok bool
+		select {
+		case message, ok = <-messages:
+			if !ok {
+				messages = nil
+				continue
+			}
+		case message, ok = <-grouped:
+			if !ok {
+				grouped = nil
+				continue
+			}
+		}
+
+		if p.handleMessage(message, url) {
+			acks <- message
+		}
+	}
+}
+
+// groupWorker returns the worker processing the messages of a message group.
+func groupWorker(groupID string, workers int) int {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(groupID))
+	return int(hash.Sum32() % uint32(workers))
+}
+
+// poll receives messages and dispatches them to the workers until ctx is
+// done.
+func (p *SQSConsumer) poll(ctx context.Context, url string, dispatch func(message *sqs.Message)) {
 	retries := 0
-	for {
-		receiveResp, err := p.sqs.ReceiveMessage(&sqs.ReceiveMessageInput{
+	for ctx.Err() == nil {
+		receiveResp, err := p.sqs.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
 			QueueUrl:            aws.String(url),
 			MaxNumberOfMessages: aws.Int64(p.config.Event.Consumer.SQS.MaxMessage),
 			WaitTimeSeconds:     aws.Int64(p.config.Event.Consumer.SQS.WaitTimeSeconds),
+			AttributeNames: aws.StringSlice([]string{
+				sqs.MessageSystemAttributeNameApproximateReceiveCount,
+				sqs.MessageSystemAttributeNameMessageGroupId,
+			}),
 		})
+		if ctx.Err() != nil {
+			return
+		}
 		if err != nil {
 			if retries == p.config.Event.Consumer.SQS.MaxRetriesConsume {
 				log.Error().Err(err).Int("retries", retries).Msg("failed receiving message after maximum retries, failing permanently")
@@ -82,34 +211,214 @@ func (p *SQSConsumer) Listen(url string) {
 				Int("backoffSeconds", p.config.Event.Consumer.SQS.BackoffSeconds).
 				Msg("failed receiving message, will retry")
 			retries++
-			time.Sleep(time.Duration(p.config.Event.Consumer.SQS.BackoffSeconds) * time.Second)
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(p.config.Event.Consumer.SQS.BackoffSeconds) * time.Second):
+			}
 			continue
 		} else {
 			retries = 0
 		}
 
+		// messages already received are always handed over, so that none is
+		// left invisible until its visibility timeout expires
 		for _, message := range receiveResp.Messages {
-			err := p.Process([]byte(*message.Body))
-			if err != nil {
-				log.Error().Err(err).Msg("failed processing message")
-			}
+			dispatch(message)
+		}
+	}
+}
 
-			err = p.deleteMessage(message, url)
-			if err != nil {
-				log.Error().Err(err).Msg("failed deleting message")
+// acknowledge deletes acknowledged messages in batches, flushing whenever a
+// batch is full, the flush interval elapses or acks is closed.
+func (p *SQSConsumer) acknowledge(url string, acks <-chan *sqs.Message) {
+	ticker := time.NewTicker(ackFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*sqs.Message, 0, maxDeleteBatchSize)
+	for {
+		select {
+		case message, ok := <-acks:
+			if !ok {
+				p.deleteMessageBatch(batch, url)
+				return
 			}
+			batch = append(batch, message)
+			if len(batch) == maxDeleteBatchSize {
+				p.deleteMessageBatch(batch, url)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			p.deleteMessageBatch(batch, url)
+			batch = batch[:0]
 		}
 	}
 }
 
-func (p *SQSConsumer) deleteMessage(msg *sqs.Message, url string) error {
-	output, err := p.sqs.DeleteMessage(&sqs.DeleteMessageInput{
-		QueueUrl:      &url,
-		ReceiptHandle: msg.ReceiptHandle,
+// handleMessage processes a message and reports whether it may be deleted.
+// Failed messages are kept for redelivery, or dead-lettered when retrying is
+// futile.
+func (p *SQSConsumer) handleMessage(message *sqs.Message, url string) (acknowledge bool) {
+	err := p.Process([]byte(aws.StringValue(message.Body)))
+	if err == nil {
+		return true
+	}
+
+	receiveCount := receiveCountOf(message)
+	maxReceiveCount := p.config.Event.Consumer.SQS.MaxReceiveCount
+
+	log.Error().
+		Err(err).
+		Str("messageId", aws.StringValue(message.MessageId)).
+		Int("receiveCount", receiveCount).
+		Msg("failed processing message")
+
+	if !IsPermanent(err) && (maxReceiveCount <= 0 || receiveCount < maxReceiveCount) {
+		p.backoff(message, url, receiveCount)
+		return false
+	}
+
+	err = p.deadLetter(DeadLetter{
+		Body:           aws.StringValue(message.Body),
+		MessageGroupID: message.Attributes[sqs.MessageSystemAttributeNameMessageGroupId],
+		MessageID:      aws.StringValue(message.MessageId),
+		Reason:         err,
+		ReceiveCount:   receiveCount,
+		Source:         url,
+	})
+	if err != nil {
+		// keep the message, it is redelivered once its visibility times out
+		log.Error().Err(err).Str("messageId", aws.StringValue(message.MessageId)).Msg("failed dead-lettering message")
+		return false
+	}
+
+	return true
+}
+
+// backoff hides a failed message for an exponentially growing period before
+// it is redelivered.
+func (p *SQSConsumer) backoff(message *sqs.Message, url string, receiveCount int) {
+	timeout := VisibilityBackoff(
+		receiveCount,
+		time.Duration(p.config.Event.Consumer.SQS.VisibilityBackoffSeconds)*time.Second,
+		time.Duration(p.config.Event.Consumer.SQS.MaxVisibilitySeconds)*time.Second,
+	)
+	if timeout <= 0 {
+		return
+	}
+
+	_, err := p.sqs.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          &url,
+		ReceiptHandle:     message.ReceiptHandle,
+		VisibilityTimeout: aws.Int64(int64(timeout / time.Second)),
 	})
 	if err != nil {
-		log.Err(err).Interface("output", output).Msg("failed deleting message")
-		return err
+		log.Error().Err(err).Str("messageId", aws.StringValue(message.MessageId)).Msg("failed extending message visibility")
+	}
+}
+
+// deadLetter moves a message to the configured dead-letter queue, or hands it
+// to the DeadLetterHandler when no queue is configured.
+func (p *SQSConsumer) deadLetter(letter DeadLetter) error {
+	queueURL := p.config.Event.Consumer.SQS.DeadLetterQueueURL
+	if queueURL == "" {
+		return p.DeadLetter.HandleDeadLetter(letter)
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:    &queueURL,
+		MessageBody: &letter.Body,
+		MessageAttributes: map[string]*sqs.MessageAttributeValue{
+			attributeSourceQueue: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(letter.Source),
+			},
+			attributeFailureReason: {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(letter.Reason.Error()),
+			},
+		},
+	}
+	if letter.MessageGroupID != nil {
+		input.MessageGroupId = letter.MessageGroupID
+		input.MessageDeduplicationId = &letter.MessageID
+	}
+
+	_, err := p.sqs.SendMessage(input)
+	return err
+}
+
+// Requeue sends a message body back to the queue it was consumed from.
+func (p *SQSConsumer) Requeue(queueURL string, body string, messageGroupID *string) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:    &queueURL,
+		MessageBody: &body,
+	}
+	if messageGroupID != nil {
+		// a fresh deduplication id, the original one may still be remembered
+		deduplicationID, _ := uuid.NewV4()
+		input.MessageGroupId = messageGroupID
+		input.MessageDeduplicationId = aws.String(deduplicationID.String())
+	}
+
+	_, err := p.sqs.SendMessage(input)
+	return err
+}
+
+// VisibilityBackoff computes the visibility timeout of a message that failed
+// on its receiveCount-th delivery, doubling base on each delivery up to max.
+func VisibilityBackoff(receiveCount int, base, max time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	if max <= 0 || max > maxVisibilityTimeout {
+		max = maxVisibilityTimeout
+	}
+
+	timeout := base
+	for i := 1; i < receiveCount && timeout < max; i++ {
+		timeout *= 2
+	}
+	if timeout > max {
+		timeout = max
+	}
+	return timeout
+}
+
+func receiveCountOf(message *sqs.Message) int {
+	count, err := strconv.Atoi(aws.StringValue(message.Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+	if err != nil {
+		return 1
+	}
+	return count
+}
+
+func (p *SQSConsumer) deleteMessageBatch(messages []*sqs.Message, url string) {
+	if len(messages) == 0 {
+		return
+	}
+
+	entries := make([]*sqs.DeleteMessageBatchRequestEntry, len(messages))
+	for i, message := range messages {
+		entries[i] = &sqs.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: message.ReceiptHandle,
+		}
+	}
+
+	output, err := p.sqs.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
+		QueueUrl: &url,
+		Entries:  entries,
+	})
+	if err != nil {
+		log.Err(err).Int("count", len(entries)).Msg("failed deleting messages")
+		return
+	}
+
+	for _, failed := range output.Failed {
+		log.Error().
+			Str("id", aws.StringValue(failed.Id)).
+			Str("code", aws.StringValue(failed.Code)).
+			Str("message", aws.StringValue(failed.Message)).
+			Msg("failed deleting message")
 	}
-	return nil
 }
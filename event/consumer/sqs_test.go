@@ -0,0 +1,252 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/stretchr/testify/assert"
+)
+
+const testQueueURL = "https://sqs.ap-southeast-1.amazonaws.com/000000000000/foo"
+
+type fakeSQS struct {
+	sqsiface.SQSAPI
+	mu          sync.Mutex
+	received    []*sqs.Message
+	deleted     []string
+	visibility  []int64
+	sent        []*sqs.SendMessageInput
+	deadLetters []DeadLetter
+}
+
+// ReceiveMessageWithContext hands out the queued messages, then long-polls
+// until ctx is done.
+func (f *fakeSQS) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	f.mu.Lock()
+	received := f.received
+	f.received = nil
+	f.mu.Unlock()
+
+	if len(received) > 0 {
+		return &sqs.ReceiveMessageOutput{Messages: received}, nil
+	}
+
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeSQS) DeleteMessageBatch(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, entry := range input.Entries {
+		f.deleted = append(f.deleted, aws.StringValue(entry.ReceiptHandle))
+	}
+	return &sqs.DeleteMessageBatchOutput{}, nil
+}
+
+func (f *fakeSQS) ChangeMessageVisibility(input *sqs.ChangeMessageVisibilityInput) (*sqs.ChangeMessageVisibilityOutput, error) {
+	f.visibility = append(f.visibility, aws.Int64Value(input.VisibilityTimeout))
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func (f *fakeSQS) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	f.sent = append(f.sent, input)
+	return &sqs.SendMessageOutput{}, nil
+}
+
+func (f *fakeSQS) HandleDeadLetter(letter DeadLetter) error {
+	f.deadLetters = append(f.deadLetters, letter)
+	return nil
+}
+
+func newTestConsumer(deadLetterQueueURL string, process Process) (*SQSConsumer, *fakeSQS) {
+	config := &configs.Config{}
+	config.Event.Consumer.SQS.DeadLetterQueueURL = deadLetterQueueURL
+	config.Event.Consumer.SQS.MaxReceiveCount = 3
+	config.Event.Consumer.SQS.MaxVisibilitySeconds = 100
+	config.Event.Consumer.SQS.VisibilityBackoffSeconds = 30
+
+	client := &fakeSQS{}
+	c := NewSQSConsumerWithClient(config, client)
+	c.Process = process
+	c.DeadLetter = client
+	return c, client
+}
+
+func newTestMessage(receiveCount string) *sqs.Message {
+	return newTestMessageWithID("1", receiveCount)
+}
+
+func newTestMessageWithID(id string, receiveCount string) *sqs.Message {
+	return &sqs.Message{
+		Body:          aws.String(`{"Message":"{}"}`),
+		MessageId:     aws.String("message-" + id),
+		ReceiptHandle: aws.String("receipt-" + id),
+		Attributes: map[string]*string{
+			sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String(receiveCount),
+		},
+	}
+}
+
+func TestSQSConsumer(t *testing.T) {
+	failing := func([]byte) error { return errors.New("unavailable") }
+
+	t.Run("acknowledges processed messages", func(t *testing.T) {
+		c, client := newTestConsumer("", func([]byte) error { return nil })
+
+		assert.True(t, c.handleMessage(newTestMessage("1"), testQueueURL))
+		assert.Empty(t, client.visibility)
+	})
+
+	t.Run("keeps failed messages with growing visibility", func(t *testing.T) {
+		c, client := newTestConsumer("", failing)
+
+		assert.False(t, c.handleMessage(newTestMessage("1"), testQueueURL))
+		assert.False(t, c.handleMessage(newTestMessage("2"), testQueueURL))
+		assert.Equal(t, []int64{30, 60}, client.visibility)
+	})
+
+	t.Run("dead-letters after the maximum receive count", func(t *testing.T) {
+		c, client := newTestConsumer("", failing)
+
+		assert.True(t, c.handleMessage(newTestMessage("3"), testQueueURL))
+		if assert.Len(t, client.deadLetters, 1) {
+			assert.Equal(t, testQueueURL, client.deadLetters[0].Source)
+			assert.Equal(t, "message-1", client.deadLetters[0].MessageID)
+			assert.Equal(t, 3, client.deadLetters[0].ReceiveCount)
+		}
+	})
+
+	t.Run("dead-letters permanent failures right away", func(t *testing.T) {
+		c, client := newTestConsumer("", func([]byte) error { return Permanent(errors.New("malformed")) })
+
+		assert.True(t, c.handleMessage(newTestMessage("1"), testQueueURL))
+		assert.Len(t, client.deadLetters, 1)
+	})
+
+	t.Run("moves to the dead-letter queue when configured", func(t *testing.T) {
+		c, client := newTestConsumer(testQueueURL+"-dlq", failing)
+
+		assert.True(t, c.handleMessage(newTestMessage("3"), testQueueURL))
+		assert.Empty(t, client.deadLetters)
+		if assert.Len(t, client.sent, 1) {
+			assert.Equal(t, testQueueURL+"-dlq", aws.StringValue(client.sent[0].QueueUrl))
+			assert.Equal(t, testQueueURL, aws.StringValue(client.sent[0].MessageAttributes[attributeSourceQueue].StringValue))
+		}
+	})
+}
+
+func TestSQSConsumerListen(t *testing.T) {
+	var processed int32
+	started := make(chan struct{}, 20)
+	release := make(chan struct{})
+
+	c, client := newTestConsumer("", func(body []byte) error {
+		started <- struct{}{}
+		<-release
+		atomic.AddInt32(&processed, 1)
+		return nil
+	})
+	c.config.Event.Consumer.SQS.Workers = 4
+
+	for i := 0; i < 12; i++ {
+		client.received = append(client.received, newTestMessageWithID(strconv.Itoa(i), "1"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Listen(ctx, testQueueURL)
+		close(done)
+	}()
+
+	// the pool bounds how many messages are processed at once
+	for i := 0; i < 4; i++ {
+		<-started
+	}
+	select {
+	case <-started:
+		t.Fatal("more messages in flight than workers")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// stopping drains every message already received before returning
+	cancel()
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after its context was done")
+	}
+
+	assert.Equal(t, int32(12), atomic.LoadInt32(&processed))
+	assert.Len(t, client.deleted, 12)
+}
+
+func TestSQSConsumerListenOrdersMessageGroups(t *testing.T) {
+	var mu sync.Mutex
+	processed := make([]string, 0)
+	started := make(chan string, 2)
+	release := make(chan struct{})
+
+	c, client := newTestConsumer("", func(body []byte) error {
+		started <- string(body)
+		<-release
+		mu.Lock()
+		processed = append(processed, string(body))
+		mu.Unlock()
+		return nil
+	})
+	c.config.Event.Consumer.SQS.Workers = 4
+
+	for i, body := range []string{"order.placed", "order.cancelled"} {
+		message := newTestMessageWithID(strconv.Itoa(i), "1")
+		message.Body = aws.String(body)
+		message.Attributes[sqs.MessageSystemAttributeNameMessageGroupId] = aws.String("order-1")
+		client.received = append(client.received, message)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Listen(ctx, testQueueURL)
+		close(done)
+	}()
+
+	// the second message of the group waits for the first, despite idle workers
+	assert.Equal(t, "order.placed", <-started)
+	select {
+	case body := <-started:
+		t.Fatalf("%s processed while the previous message of its group is in flight", body)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.Equal(t, "order.cancelled", <-started)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after its context was done")
+	}
+
+	assert.Equal(t, []string{"order.placed", "order.cancelled"}, processed)
+}
+
+func TestVisibilityBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, VisibilityBackoff(1, 10*time.Second, time.Minute))
+	assert.Equal(t, 40*time.Second, VisibilityBackoff(3, 10*time.Second, time.Minute))
+	assert.Equal(t, time.Minute, VisibilityBackoff(10, 10*time.Second, time.Minute))
+	assert.Equal(t, 12*time.Hour, VisibilityBackoff(100, time.Hour, 0))
+	assert.Equal(t, time.Duration(0), VisibilityBackoff(1, 0, time.Minute))
+}
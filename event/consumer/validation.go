@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/evermos/boilerplate-go/event/model"
+)
+
+// ValidateSNS wraps a Process so that it only receives SNS messages whose
+// payload satisfies the schema of its event type. The event type and version
+// are read from the message attributes, falling back to defaultEventType v1
+// for publishers that do not set them. Invalid messages fail with a
+// PermanentError, so the consumer dead-letters them instead of retrying.
+func ValidateSNS(registry *model.SchemaRegistry, defaultEventType string, process Process) Process {
+	return func(message []byte) error {
+		snsMessage := model.SNSMessage{}
+		err := json.Unmarshal(message, &snsMessage)
+		if err != nil {
+			return Permanent(fmt.Errorf("failed decoding SNS message: %w", err))
+		}
+
+		eventType, version, err := resolveEventType(snsMessage, defaultEventType)
+		if err != nil {
+			return Permanent(err)
+		}
+
+		err = registry.Validate(eventType, version, []byte(snsMessage.Message))
+		if err != nil {
+			return Permanent(err)
+		}
+
+		return process(message)
+	}
+}
+
+func resolveEventType(snsMessage model.SNSMessage, defaultEventType string) (eventType string, version int, err error) {
+	eventType, version = defaultEventType, 1
+
+	if attribute, ok := snsMessage.MessageAttributes[model.AttributeEventType]; ok {
+		eventType = attribute.Value
+	}
+
+	if attribute, ok := snsMessage.MessageAttributes[model.AttributeEventVersion]; ok {
+		version, err = strconv.Atoi(attribute.Value)
+		if err != nil {
+			err = fmt.Errorf("invalid %s attribute %q", model.AttributeEventVersion, attribute.Value)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,108 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+// FailedEventStatus filters FailedEvents by whether they have been replayed.
+type FailedEventStatus string
+
+const (
+	// FailedEventStatusPending indicates a FailedEvent that has not been
+	// replayed yet.
+	FailedEventStatusPending FailedEventStatus = "pending"
+	// FailedEventStatusReplayed indicates a FailedEvent that has been replayed.
+	FailedEventStatusReplayed FailedEventStatus = "replayed"
+)
+
+// FailedEvent is a consumed message that was dead-lettered after it could not
+// be processed.
+type FailedEvent struct {
+	ID             uuid.UUID   `db:"entity_id"`
+	Source         string      `db:"source"`
+	MessageID      string      `db:"message_id"`
+	MessageGroupID null.String `db:"message_group_id"`
+	Body           string      `db:"body"`
+	Reason         string      `db:"reason"`
+	ReceiveCount   int         `db:"receive_count"`
+	ReplayCount    int         `db:"replay_count"`
+	Created        time.Time   `db:"created"`
+	Replayed       null.Time   `db:"replayed"`
+}
+
+// FailedEventFilter filters and pages the FailedEvents to resolve.
+type FailedEventFilter struct {
+	Status   FailedEventStatus
+	Page     int
+	PageSize int
+}
+
+// FailedEventResponseFormat represents a FailedEvent's standard formatting for
+// JSON serializing.
+type FailedEventResponseFormat struct {
+	ID             uuid.UUID `json:"id"`
+	Source         string    `json:"source"`
+	MessageID      string    `json:"messageId"`
+	MessageGroupID *string   `json:"messageGroupId,omitempty"`
+	Body           string    `json:"body"`
+	Reason         string    `json:"reason"`
+	ReceiveCount   int       `json:"receiveCount"`
+	ReplayCount    int       `json:"replayCount"`
+	Created        time.Time `json:"created"`
+	Replayed       null.Time `json:"replayed,omitempty"`
+}
+
+// NewFromDeadLetter creates a FailedEvent from a dead-lettered message.
+func (f FailedEvent) NewFromDeadLetter(letter consumer.DeadLetter) (newFailedEvent FailedEvent, err error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	newFailedEvent = FailedEvent{
+		ID:             id,
+		Source:         letter.Source,
+		MessageID:      letter.MessageID,
+		MessageGroupID: null.StringFromPtr(letter.MessageGroupID),
+		Body:           letter.Body,
+		ReceiveCount:   letter.ReceiveCount,
+		Created:        time.Now(),
+	}
+	if letter.Reason != nil {
+		newFailedEvent.Reason = letter.Reason.Error()
+	}
+
+	return
+}
+
+// MarkReplayed marks this FailedEvent as sent back to its source.
+func (f *FailedEvent) MarkReplayed() {
+	f.Replayed = null.TimeFrom(time.Now())
+	f.ReplayCount++
+}
+
+// MarshalJSON overrides the standard JSON formatting.
+func (f FailedEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.ToResponseFormat())
+}
+
+// ToResponseFormat converts this FailedEvent to its response format.
+func (f FailedEvent) ToResponseFormat() FailedEventResponseFormat {
+	return FailedEventResponseFormat{
+		ID:             f.ID,
+		Source:         f.Source,
+		MessageID:      f.MessageID,
+		MessageGroupID: f.MessageGroupID.Ptr(),
+		Body:           f.Body,
+		Reason:         f.Reason,
+		ReceiveCount:   f.ReceiveCount,
+		ReplayCount:    f.ReplayCount,
+		Created:        f.Created,
+		Replayed:       f.Replayed,
+	}
+}
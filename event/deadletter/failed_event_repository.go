@@ -0,0 +1,150 @@
+package deadletter
+
+import (
+	"database/sql"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/gofrs/uuid"
+)
+
+var (
+	failedEventQueries = struct {
+		selectFailedEvent string
+		insertFailedEvent string
+		updateReplayed    string
+	}{
+		selectFailedEvent: `
+			SELECT
+				entity_id,
+				source,
+				message_id,
+				message_group_id,
+				body,
+				reason,
+				receive_count,
+				replay_count,
+				created,
+				replayed
+			FROM failed_events `,
+
+		insertFailedEvent: `
+			INSERT INTO failed_events (
+				entity_id,
+				source,
+				message_id,
+				message_group_id,
+				body,
+				reason,
+				receive_count,
+				replay_count,
+				created
+			) VALUES (
+				:entity_id,
+				:source,
+				:message_id,
+				:message_group_id,
+				:body,
+				:reason,
+				:receive_count,
+				:replay_count,
+				:created)`,
+
+		updateReplayed: `
+			UPDATE failed_events
+			SET
+				replay_count = :replay_count,
+				replayed = :replayed
+			WHERE entity_id = :entity_id`,
+	}
+)
+
+// FailedEventRepository lays out the contract for the dead-letter store.
+type FailedEventRepository interface {
+	Create(failedEvent FailedEvent) (err error)
+	ResolveAll(filter FailedEventFilter) (failedEvents []FailedEvent, err error)
+	ResolveByID(id uuid.UUID) (failedEvent FailedEvent, err error)
+	UpdateReplayed(failedEvent FailedEvent) (err error)
+}
+
+// FailedEventRepositoryMySQL is the MySQL-backed implementation of
+// FailedEventRepository, storing into the failed_events table.
+type FailedEventRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideFailedEventRepositoryMySQL is the provider for this repository.
+func ProvideFailedEventRepositoryMySQL(db *infras.MySQLConn) *FailedEventRepositoryMySQL {
+	return &FailedEventRepositoryMySQL{DB: db}
+}
+
+// Create stores a FailedEvent.
+func (r *FailedEventRepositoryMySQL) Create(failedEvent FailedEvent) (err error) {
+	stmt, err := r.DB.Write.PrepareNamed(failedEventQueries.insertFailedEvent)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(failedEvent)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveAll resolves a page of FailedEvents, newest first.
+func (r *FailedEventRepositoryMySQL) ResolveAll(filter FailedEventFilter) (failedEvents []FailedEvent, err error) {
+	query := failedEventQueries.selectFailedEvent
+	switch filter.Status {
+	case FailedEventStatusPending:
+		query += "WHERE replayed IS NULL "
+	case FailedEventStatusReplayed:
+		query += "WHERE replayed IS NOT NULL "
+	}
+	query += "ORDER BY created DESC LIMIT ? OFFSET ?"
+
+	failedEvents = make([]FailedEvent, 0)
+	err = r.DB.Read.Select(&failedEvents, query, filter.PageSize, (filter.Page-1)*filter.PageSize)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveByID resolves a FailedEvent by its ID.
+func (r *FailedEventRepositoryMySQL) ResolveByID(id uuid.UUID) (failedEvent FailedEvent, err error) {
+	err = r.DB.Read.Get(
+		&failedEvent,
+		failedEventQueries.selectFailedEvent+"WHERE entity_id = ?",
+		id.String())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = failure.NotFound("failedEvent")
+		}
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// UpdateReplayed records a replay of a FailedEvent.
+func (r *FailedEventRepositoryMySQL) UpdateReplayed(failedEvent FailedEvent) (err error) {
+	stmt, err := r.DB.Write.PrepareNamed(failedEventQueries.updateReplayed)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(failedEvent)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
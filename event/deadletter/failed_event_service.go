@@ -0,0 +1,79 @@
+package deadletter
+
+import (
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Requeuer sends a message back to the queue it was consumed from.
+type Requeuer interface {
+	Requeue(queueURL string, body string, messageGroupID *string) error
+}
+
+// FailedEventService is the service interface for dead-lettered events.
+type FailedEventService interface {
+	consumer.DeadLetterHandler
+	ResolveAll(filter FailedEventFilter) (failedEvents []FailedEvent, err error)
+	Replay(id uuid.UUID) (failedEvent FailedEvent, err error)
+}
+
+// FailedEventServiceImpl is the service implementation for dead-lettered
+// events. It stores dead letters in the repository and replays them through
+// the Requeuer.
+type FailedEventServiceImpl struct {
+	FailedEventRepository FailedEventRepository
+	Requeuer              Requeuer
+}
+
+// ProvideFailedEventServiceImpl is the provider for this service.
+func ProvideFailedEventServiceImpl(failedEventRepository FailedEventRepository, requeuer Requeuer) *FailedEventServiceImpl {
+	return &FailedEventServiceImpl{
+		FailedEventRepository: failedEventRepository,
+		Requeuer:              requeuer,
+	}
+}
+
+// HandleDeadLetter stores a dead-lettered message as a FailedEvent.
+func (s *FailedEventServiceImpl) HandleDeadLetter(letter consumer.DeadLetter) (err error) {
+	failedEvent, err := FailedEvent{}.NewFromDeadLetter(letter)
+	if err != nil {
+		return
+	}
+
+	err = s.FailedEventRepository.Create(failedEvent)
+	if err != nil {
+		return
+	}
+
+	log.Warn().
+		Str("id", failedEvent.ID.String()).
+		Str("source", failedEvent.Source).
+		Str("messageId", failedEvent.MessageID).
+		Str("reason", failedEvent.Reason).
+		Msg("Stored dead-lettered message")
+	return
+}
+
+// ResolveAll resolves a page of FailedEvents.
+func (s *FailedEventServiceImpl) ResolveAll(filter FailedEventFilter) (failedEvents []FailedEvent, err error) {
+	return s.FailedEventRepository.ResolveAll(filter)
+}
+
+// Replay sends a FailedEvent back to the queue it was consumed from.
+func (s *FailedEventServiceImpl) Replay(id uuid.UUID) (failedEvent FailedEvent, err error) {
+	failedEvent, err = s.FailedEventRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+
+	err = s.Requeuer.Requeue(failedEvent.Source, failedEvent.Body, failedEvent.MessageGroupID.Ptr())
+	if err != nil {
+		return failedEvent, failure.InternalError(err)
+	}
+
+	failedEvent.MarkReplayed()
+	err = s.FailedEventRepository.UpdateReplayed(failedEvent)
+	return
+}
@@ -1,42 +1,47 @@
 package foobarbaz
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 
 	"github.com/evermos/boilerplate-go/configs"
 	"github.com/evermos/boilerplate-go/event/consumer"
 	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/transport"
 	"github.com/evermos/boilerplate-go/internal/domain/foobarbaz"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/rs/zerolog/log"
 )
 
-// ConsumerImpl is the SQS consumer implementation for this domain.
+// ConsumerImpl is the event consumer implementation for this domain.
 type ConsumerImpl struct {
 	Config   *configs.Config
 	Service  foobarbaz.FooService
 	Consumer consumer.Consumer
+	// Process processes a message, however it was delivered.
+	Process consumer.Process
 }
 
 // ProvideConsumerImpl is the provider for this consumer.
-func ProvideConsumerImpl(config *configs.Config, service foobarbaz.FooService) ConsumerImpl {
+func ProvideConsumerImpl(config *configs.Config, service foobarbaz.FooService, registry *model.SchemaRegistry, deduplicator *consumer.Deduplicator, deadLetter consumer.DeadLetterHandler) ConsumerImpl {
 	c := ConsumerImpl{}
 	c.Config = config
 	c.Service = service
 
-	sqsConsumer := consumer.NewSQSConsumer(config)
-	sqsConsumer.Process = c.processEvent
-	c.Consumer = sqsConsumer
+	c.Process = consumer.ValidateSNS(registry, foobarbaz.FooBarBazEventType, deduplicator.Wrap("foobarbaz", c.processEvent))
+	c.Consumer = transport.NewConsumer(config, c.Process, deadLetter)
 
 	return c
 }
 
-// Start starts up the SQS subscriber
-func (c *ConsumerImpl) Start() {
+// Run runs the subscriber until ctx is done and its in-flight messages
+// are processed.
+func (c *ConsumerImpl) Run(ctx context.Context) {
 	if c.Config.Event.Consumer.SQS.Topics.FooBarBaz.Enabled {
-		go c.Consumer.Listen(c.Config.Event.Consumer.SQS.Topics.FooBarBaz.URL)
+		c.Consumer.Listen(ctx, transport.Topic(c.Config, c.Config.Event.Consumer.SQS.Topics.FooBarBaz.URL, c.Config.Event.Kafka.Topics.FooBarBaz))
 	}
 }
 
@@ -58,10 +63,12 @@ func (c *ConsumerImpl) processEvent(value []byte) (err error) {
 	err = json.Unmarshal([]byte(snsMessage.Message), &requestFormat)
 	if err != nil {
 		logger.ErrorWithStack(err)
-		return
+		return consumer.Permanent(err)
 	}
 
-	_, err = c.Service.Create(requestFormat, snsMessage.MessageID)
+	// events carry no user, so the system is recorded as the actor
+	ctx := oauth.WithPrincipal(context.Background(), oauth.SystemPrincipal())
+	_, err = c.Service.Create(ctx, requestFormat)
 	if err != nil {
 		err = c.checkError(err)
 	}
@@ -70,15 +77,12 @@ func (c *ConsumerImpl) processEvent(value []byte) (err error) {
 }
 
 func (c *ConsumerImpl) checkError(err error) error {
-	f, ok := err.(*failure.Failure)
-	if ok {
-		if f.Code == http.StatusBadRequest {
-			err = nil
-		}
-	}
+	logger.ErrorWithStack(err)
 
-	if err != nil {
-		logger.ErrorWithStack(err)
+	// a bad request fails the same way on every delivery
+	f, ok := err.(*failure.Failure)
+	if ok && f.Code == http.StatusBadRequest {
+		return consumer.Permanent(err)
 	}
 
 	return err
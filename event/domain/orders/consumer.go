@@ -0,0 +1,154 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/transport"
+	"github.com/evermos/boilerplate-go/internal/domain/warehouse"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// OrderPlacedEventType is published by the order service when an order
+	// is placed.
+	OrderPlacedEventType = "order.placed"
+	// OrderCancelledEventType is published by the order service when an
+	// order is cancelled before it is shipped.
+	OrderCancelledEventType = "order.cancelled"
+	// OrderShippedEventType is published by the order service when an order
+	// leaves the warehouse.
+	OrderShippedEventType = "order.shipped"
+	// OrderReturnedEventType is published by the order service when a shipped
+	// order is returned to the warehouse.
+	OrderReturnedEventType = "order.returned"
+)
+
+// stockMovements are the stock movements driven by each order event.
+var stockMovements = map[string]warehouse.StockMovementType{
+	OrderPlacedEventType:    warehouse.StockMovementReserve,
+	OrderCancelledEventType: warehouse.StockMovementRelease,
+	OrderShippedEventType:   warehouse.StockMovementDeduct,
+	OrderReturnedEventType:  warehouse.StockMovementRestock,
+}
+
+// OrderEventPayload is the payload of the order lifecycle events.
+type OrderEventPayload struct {
+	OrderID     uuid.UUID          `json:"orderId"`
+	WarehouseID uuid.UUID          `json:"warehouseId"`
+	Items       []OrderItemPayload `json:"items"`
+	OccurredAt  time.Time          `json:"occurredAt"`
+}
+
+// OrderItemPayload is an ordered product.
+type OrderItemPayload struct {
+	ProductID uuid.UUID `json:"productId"`
+	Quantity  int       `json:"quantity"`
+}
+
+// ConsumerImpl consumes the order lifecycle events and moves warehouse stock
+// accordingly: placed orders reserve stock, cancelled ones release it, shipped
+// ones deduct it and returned ones restock it. Movements are keyed by the SNS
+// MessageID, so a redelivered event never moves stock twice.
+type ConsumerImpl struct {
+	Config   *configs.Config
+	Service  warehouse.WarehouseService
+	Consumer consumer.Consumer
+	// Process processes a message, however it was delivered.
+	Process consumer.Process
+}
+
+// ProvideConsumerImpl is the provider for this consumer.
+func ProvideConsumerImpl(config *configs.Config, service warehouse.WarehouseService, registry *model.SchemaRegistry, deduplicator *consumer.Deduplicator, deadLetter consumer.DeadLetterHandler) ConsumerImpl {
+	c := ConsumerImpl{}
+	c.Config = config
+	c.Service = service
+
+	// order events always carry their type, there is no default to fall back to
+	c.Process = consumer.ValidateSNS(registry, "", deduplicator.Wrap("orders", c.processEvent))
+	c.Consumer = transport.NewConsumer(config, c.Process, deadLetter)
+
+	return c
+}
+
+// Run runs the subscriber until ctx is done and its in-flight messages are
+// processed.
+func (c *ConsumerImpl) Run(ctx context.Context) {
+	if c.Config.Event.Consumer.SQS.Topics.Order.Enabled {
+		c.Consumer.Listen(ctx, transport.Topic(c.Config, c.Config.Event.Consumer.SQS.Topics.Order.URL, c.Config.Event.Kafka.Topics.Order))
+	}
+}
+
+func (c *ConsumerImpl) processEvent(value []byte) (err error) {
+	snsMessage := model.SNSMessage{}
+	err = json.Unmarshal(value, &snsMessage)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return consumer.Permanent(err)
+	}
+
+	eventType := snsMessage.MessageAttributes[model.AttributeEventType].Value
+	movementType, ok := stockMovements[eventType]
+	if !ok {
+		return consumer.Permanent(fmt.Errorf("unexpected event type %q on the order queue", eventType))
+	}
+
+	payload := OrderEventPayload{}
+	err = json.Unmarshal([]byte(snsMessage.Message), &payload)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return consumer.Permanent(err)
+	}
+
+	log.
+		Info().
+		Str("messageId", snsMessage.MessageID.String()).
+		Str("eventType", eventType).
+		Str("orderId", payload.OrderID.String()).
+		Msg("Received order event")
+
+	request := warehouse.StockMovementRequest{
+		MessageID:   snsMessage.MessageID.String(),
+		OrderID:     payload.OrderID,
+		WarehouseID: payload.WarehouseID,
+		Type:        movementType,
+	}
+	for _, item := range payload.Items {
+		request.Items = append(request.Items, warehouse.StockMovementItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	// events carry no user, so the system is recorded as the actor
+	ctx := oauth.WithPrincipal(context.Background(), oauth.SystemPrincipal())
+	_, err = c.Service.MoveStock(ctx, request)
+	if err != nil {
+		err = c.checkError(err)
+	}
+
+	return
+}
+
+func (c *ConsumerImpl) checkError(err error) error {
+	logger.ErrorWithStack(err)
+
+	// insufficient stock or unknown products fail the same way on every
+	// delivery, stale versions are retried
+	f, ok := err.(*failure.Failure)
+	if ok && (f.Code == http.StatusBadRequest || f.Code == http.StatusNotFound) {
+		return consumer.Permanent(err)
+	}
+
+	return err
+}
@@ -0,0 +1,112 @@
+package orders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/internal/domain/warehouse"
+	warehouse_mock "github.com/evermos/boilerplate-go/internal/domain/warehouse/mock"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/gofrs/uuid"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// systemActor matches contexts in which the system is the actor.
+type systemActor struct{}
+
+func (systemActor) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	actor, err := oauth.ActorFromContext(ctx)
+	return err == nil && actor == oauth.SystemActorID
+}
+
+func (systemActor) String() string {
+	return "is a context acting as the system"
+}
+
+func newTestMessage(t *testing.T, eventType string, payload OrderEventPayload) (uuid.UUID, []byte) {
+	message, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	messageID, _ := uuid.NewV4()
+	body, err := json.Marshal(model.SNSMessage{
+		Type:      "Notification",
+		MessageID: messageID,
+		Message:   string(message),
+		MessageAttributes: map[string]model.SNSMessageAttribute{
+			model.AttributeEventType:    {Type: "String", Value: eventType},
+			model.AttributeEventVersion: {Type: "Number", Value: "1"},
+		},
+	})
+	require.NoError(t, err)
+	return messageID, body
+}
+
+func TestConsumerProcessEvent(t *testing.T) {
+	orderID, _ := uuid.NewV4()
+	warehouseID, _ := uuid.NewV4()
+	productID, _ := uuid.NewV4()
+	payload := OrderEventPayload{
+		OrderID:     orderID,
+		WarehouseID: warehouseID,
+		Items:       []OrderItemPayload{{ProductID: productID, Quantity: 2}},
+	}
+
+	for eventType, movementType := range stockMovements {
+		eventType, movementType := eventType, movementType
+		t.Run(eventType, func(t *testing.T) {
+			service := warehouse_mock.NewMockWarehouseService(gomock.NewController(t))
+			c := ConsumerImpl{Service: service}
+			messageID, body := newTestMessage(t, eventType, payload)
+
+			service.EXPECT().MoveStock(systemActor{}, warehouse.StockMovementRequest{
+				MessageID:   messageID.String(),
+				OrderID:     orderID,
+				WarehouseID: warehouseID,
+				Type:        movementType,
+				Items:       []warehouse.StockMovementItem{{ProductID: productID, Quantity: 2}},
+			}).Return(nil, nil)
+
+			assert.NoError(t, c.processEvent(body))
+		})
+	}
+
+	t.Run("rejects unexpected event types permanently", func(t *testing.T) {
+		c := ConsumerImpl{}
+		_, body := newTestMessage(t, "order.paid", payload)
+
+		assert.True(t, consumer.IsPermanent(c.processEvent(body)))
+	})
+
+	t.Run("fails permanently when stock cannot be moved", func(t *testing.T) {
+		service := warehouse_mock.NewMockWarehouseService(gomock.NewController(t))
+		c := ConsumerImpl{Service: service}
+		_, body := newTestMessage(t, OrderPlacedEventType, payload)
+
+		service.EXPECT().MoveStock(gomock.Any(), gomock.Any()).Return(nil, failure.BadRequestFromString("insufficient stock"))
+
+		assert.True(t, consumer.IsPermanent(c.processEvent(body)))
+	})
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		service := warehouse_mock.NewMockWarehouseService(gomock.NewController(t))
+		c := ConsumerImpl{Service: service}
+		_, body := newTestMessage(t, OrderShippedEventType, payload)
+
+		service.EXPECT().MoveStock(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+
+		err := c.processEvent(body)
+		assert.Error(t, err)
+		assert.False(t, consumer.IsPermanent(err))
+	})
+}
@@ -0,0 +1,276 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Consumer consumes Kafka topics as a member of the configured consumer
+// group. Messages are handed to Process as SNS notifications, like the ones
+// consumed from SQS, so processing does not depend on the transport.
+//
+// Messages are processed one at a time, in partition order. A failed message
+// is retried in place with a growing backoff and dead-lettered once it has
+// been attempted MaxAttempts times. Its offset is only committed once it is
+// processed or dead-lettered, so that a consumer stopped mid-way resumes from
+// it.
+type Consumer struct {
+	Process    consumer.Process
+	DeadLetter consumer.DeadLetterHandler
+	config     *configs.Config
+	newReader  func(topic string) messageReader
+	writer     messageWriter
+}
+
+// NewConsumer creates a consumer reading from the configured brokers.
+func NewConsumer(config *configs.Config) *Consumer {
+	if config.Event.Kafka.GroupID == "" {
+		log.Fatal().Msg("a Kafka consumer group is required, configure EVENT.KAFKA.GROUP_ID")
+	}
+
+	c := &Consumer{
+		DeadLetter: consumer.LogDeadLetterHandler{},
+		config:     config,
+		writer:     newWriter(config),
+	}
+	c.newReader = c.newKafkaReader
+	return c
+}
+
+func (c *Consumer) newKafkaReader(topic string) messageReader {
+	return kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers:     c.config.Event.Kafka.Brokers,
+		GroupID:     c.config.Event.Kafka.GroupID,
+		Topic:       topic,
+		MaxWait:     time.Duration(c.config.Event.Kafka.MaxWaitMillis) * time.Millisecond,
+		StartOffset: startOffset(c.config.Event.Kafka.StartOffset),
+	})
+}
+
+// Listen consumes a topic until ctx is done. The message being processed when
+// ctx is done is still committed once processed, then Listen returns.
+func (c *Consumer) Listen(ctx context.Context, topic string) {
+	reader := c.newReader(topic)
+	defer reader.Close()
+
+	log.Info().Str("topic", topic).Str("groupId", c.config.Event.Kafka.GroupID).Msg("Kafka Consumer will start consuming.")
+
+	for failures := 0; ; {
+		message, err := reader.FetchMessage(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			failures++
+			log.Error().Err(err).Str("topic", topic).Int("failures", failures).Msg("failed fetching message, will retry")
+			if !sleep(ctx, c.backoff(failures)) {
+				break
+			}
+			continue
+		}
+		failures = 0
+
+		if !c.handleMessage(ctx, topic, message) {
+			break
+		}
+
+		// committed even when ctx is done, the message is processed already
+		err = reader.CommitMessages(context.Background(), message)
+		if err != nil {
+			log.Error().Err(err).Str("topic", topic).Int64("offset", message.Offset).Msg("failed committing message")
+		}
+	}
+
+	log.Info().Str("topic", topic).Msg("Kafka Consumer stopped.")
+}
+
+// handleMessage processes a message, dead-lettering it when retrying is
+// futile, and reports whether its offset may be committed. It only returns
+// false when ctx is done before the message is settled.
+func (c *Consumer) handleMessage(ctx context.Context, topic string, message kafkago.Message) (commit bool) {
+	body, err := notificationOf(topic, message)
+	attempts := 1
+	if err == nil {
+		attempts, err = c.process(ctx, body)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+	} else {
+		// dead-lettered as is, it cannot be processed in any attempt
+		body = message.Value
+		err = consumer.Permanent(err)
+	}
+
+	letter := consumer.DeadLetter{
+		Body:         string(body),
+		MessageID:    messageIDOf(topic, message),
+		Reason:       err,
+		ReceiveCount: attempts,
+		Source:       topic,
+	}
+	if len(message.Key) > 0 {
+		key := string(message.Key)
+		letter.MessageGroupID = &key
+	}
+
+	for failures := 1; ; failures++ {
+		err = c.deadLetter(letter, message)
+		if err == nil {
+			return true
+		}
+
+		// keep the message, committing past it would lose it
+		log.Error().Err(err).Str("messageId", letter.MessageID).Msg("failed dead-lettering message")
+		if !sleep(ctx, c.backoff(failures)) {
+			return false
+		}
+	}
+}
+
+// process attempts to process a message until it succeeds, fails permanently
+// or runs out of attempts.
+func (c *Consumer) process(ctx context.Context, body []byte) (attempts int, err error) {
+	maxAttempts := c.config.Event.Kafka.MaxAttempts
+	for {
+		attempts++
+		err = c.Process(body)
+		if err == nil || consumer.IsPermanent(err) || (maxAttempts > 0 && attempts >= maxAttempts) {
+			return
+		}
+
+		log.Error().Err(err).Int("attempts", attempts).Msg("failed processing message, will retry")
+		if !sleep(ctx, c.backoff(attempts)) {
+			return attempts, ctx.Err()
+		}
+	}
+}
+
+// deadLetter writes a message to the configured dead-letter topic, or hands
+// it to the DeadLetterHandler when no topic is configured.
+func (c *Consumer) deadLetter(letter consumer.DeadLetter, message kafkago.Message) error {
+	topic := c.config.Event.Kafka.DeadLetterTopic
+	if topic == "" {
+		return c.DeadLetter.HandleDeadLetter(letter)
+	}
+
+	headers := append([]kafkago.Header{}, message.Headers...)
+	headers = append(headers,
+		kafkago.Header{Key: headerSourceTopic, Value: []byte(letter.Source)},
+		kafkago.Header{Key: headerFailureReason, Value: []byte(letter.Reason.Error())},
+	)
+
+	return c.writer.WriteMessages(context.Background(), kafkago.Message{
+		Topic:   topic,
+		Key:     message.Key,
+		Value:   message.Value,
+		Headers: headers,
+	})
+}
+
+// Requeue writes a dead-lettered SNS notification back to the topic it was
+// consumed from.
+func (c *Consumer) Requeue(topic string, body string, messageGroupID *string) error {
+	notification := model.SNSMessage{}
+	err := json.Unmarshal([]byte(body), &notification)
+	if err != nil {
+		return fmt.Errorf("failed decoding dead-lettered notification: %w", err)
+	}
+
+	messageID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	message := kafkago.Message{
+		Topic:   topic,
+		Value:   []byte(notification.Message),
+		Headers: []kafkago.Header{{Key: headerMessageID, Value: []byte(messageID.String())}},
+	}
+	for _, key := range []string{model.AttributeEventType, model.AttributeEventVersion} {
+		if attribute, ok := notification.MessageAttributes[key]; ok {
+			message.Headers = append(message.Headers, kafkago.Header{Key: key, Value: []byte(attribute.Value)})
+		}
+	}
+	if messageGroupID != nil {
+		message.Key = []byte(*messageGroupID)
+	}
+
+	return c.writer.WriteMessages(context.Background(), message)
+}
+
+// backoff doubles the configured backoff on every failure, up to a minute.
+func (c *Consumer) backoff(failures int) time.Duration {
+	backoff := defaultBackoff
+	if millis := c.config.Event.Kafka.BackoffMillis; millis > 0 {
+		backoff = time.Duration(millis) * time.Millisecond
+	}
+
+	for i := 1; i < failures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// notificationOf wraps a Kafka message in an SNS notification.
+func notificationOf(topic string, message kafkago.Message) ([]byte, error) {
+	messageID, err := uuid.FromString(messageIDOf(topic, message))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", headerMessageID, err)
+	}
+
+	notification := model.SNSMessage{
+		Type:              "Notification",
+		MessageID:         messageID,
+		TopicARN:          topic,
+		Message:           string(message.Value),
+		Timestamp:         message.Time.UTC().Format(time.RFC3339Nano),
+		MessageAttributes: make(map[string]model.SNSMessageAttribute),
+	}
+	if eventType, ok := headerOf(message, model.AttributeEventType); ok {
+		notification.MessageAttributes[model.AttributeEventType] = model.SNSMessageAttribute{Type: "String", Value: eventType}
+	}
+	if version, ok := headerOf(message, model.AttributeEventVersion); ok {
+		notification.MessageAttributes[model.AttributeEventVersion] = model.SNSMessageAttribute{Type: "Number", Value: version}
+	}
+
+	return json.Marshal(notification)
+}
+
+// messageIDOf resolves the identifier of a message. Messages written without
+// one are identified by their position, which is stable across deliveries.
+func messageIDOf(topic string, message kafkago.Message) string {
+	if messageID, ok := headerOf(message, headerMessageID); ok {
+		return messageID
+	}
+	position := topic + "/" + strconv.Itoa(message.Partition) + "/" + strconv.FormatInt(message.Offset, 10)
+	return uuid.NewV5(uuid.NamespaceURL, "kafka://"+position).String()
+}
+
+// sleep waits for d, and reports false when ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
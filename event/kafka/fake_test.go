@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// fakeBroker is an in-process broker keeping a partitioned log per topic and
+// the offsets committed by a single consumer group.
+type fakeBroker struct {
+	mu         sync.Mutex
+	partitions int
+	logs       map[string][][]kafkago.Message
+	committed  map[string]map[int]int64
+	changed    chan struct{}
+}
+
+func newFakeBroker(partitions int) *fakeBroker {
+	return &fakeBroker{
+		partitions: partitions,
+		logs:       make(map[string][][]kafkago.Message),
+		committed:  make(map[string]map[int]int64),
+		changed:    make(chan struct{}),
+	}
+}
+
+// WriteMessages appends messages to their topic, partitioned by key like the
+// producer's balancer.
+func (b *fakeBroker) WriteMessages(_ context.Context, messages ...kafkago.Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	partitions := make([]int, b.partitions)
+	for i := range partitions {
+		partitions[i] = i
+	}
+	balancer := &kafkago.Hash{}
+
+	for _, message := range messages {
+		log := b.log(message.Topic)
+		message.Partition = balancer.Balance(message, partitions...)
+		message.Offset = int64(len(log[message.Partition]))
+		log[message.Partition] = append(log[message.Partition], message)
+	}
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+	return nil
+}
+
+// messages returns the messages of a topic, partition by partition.
+func (b *fakeBroker) messages(topic string) (messages []kafkago.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, partition := range b.log(topic) {
+		messages = append(messages, partition...)
+	}
+	return
+}
+
+// committedCount returns how many messages of a topic were committed.
+func (b *fakeBroker) committedCount(topic string) (count int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, offset := range b.committed[topic] {
+		count += offset
+	}
+	return
+}
+
+func (b *fakeBroker) log(topic string) [][]kafkago.Message {
+	if _, ok := b.logs[topic]; !ok {
+		b.logs[topic] = make([][]kafkago.Message, b.partitions)
+		b.committed[topic] = make(map[int]int64)
+	}
+	return b.logs[topic]
+}
+
+// reader joins the consumer group, resuming from its committed offsets.
+func (b *fakeBroker) reader(topic string) messageReader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.log(topic)
+	fetched := make(map[int]int64)
+	for partition, offset := range b.committed[topic] {
+		fetched[partition] = offset
+	}
+	return &fakeReader{broker: b, topic: topic, fetched: fetched}
+}
+
+type fakeReader struct {
+	broker  *fakeBroker
+	topic   string
+	fetched map[int]int64
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafkago.Message, error) {
+	for {
+		r.broker.mu.Lock()
+		for partition, log := range r.broker.logs[r.topic] {
+			if offset := r.fetched[partition]; offset < int64(len(log)) {
+				r.fetched[partition] = offset + 1
+				r.broker.mu.Unlock()
+				return log[offset], nil
+			}
+		}
+		changed := r.broker.changed
+		r.broker.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return kafkago.Message{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, messages ...kafkago.Message) error {
+	r.broker.mu.Lock()
+	defer r.broker.mu.Unlock()
+
+	for _, message := range messages {
+		if message.Offset+1 > r.broker.committed[r.topic][message.Partition] {
+			r.broker.committed[r.topic][message.Partition] = message.Offset + 1
+		}
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	return nil
+}
@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/model"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Transport is the EVENT.TRANSPORT value selecting Kafka instead of SNS and
+// SQS.
+const Transport = "kafka"
+
+const (
+	// headerMessageID carries the unique identifier of a message, which
+	// consumers see as the SNS MessageId.
+	headerMessageID     = "message_id"
+	headerSourceTopic   = "source_topic"
+	headerFailureReason = "failure_reason"
+
+	defaultBackoff = time.Second
+	maxBackoff     = time.Minute
+)
+
+// messageWriter is the part of kafka-go's Writer used by this package.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, messages ...kafkago.Message) error
+}
+
+// messageReader is the part of kafka-go's Reader used by this package.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafkago.Message, error)
+	CommitMessages(ctx context.Context, messages ...kafkago.Message) error
+	Close() error
+}
+
+func newWriter(config *configs.Config) *kafkago.Writer {
+	return &kafkago.Writer{
+		Addr:         kafkago.TCP(config.Event.Kafka.Brokers...),
+		Balancer:     &kafkago.Hash{},
+		BatchTimeout: time.Duration(config.Event.Kafka.BatchTimeoutMillis) * time.Millisecond,
+		RequiredAcks: requiredAcks(config.Event.Kafka.Acks),
+	}
+}
+
+// requiredAcks resolves the acknowledgements a write waits for, all in-sync
+// replicas unless configured otherwise.
+func requiredAcks(acks string) kafkago.RequiredAcks {
+	switch strings.ToLower(acks) {
+	case "none", "0":
+		return kafkago.RequireNone
+	case "one", "1":
+		return kafkago.RequireOne
+	default:
+		return kafkago.RequireAll
+	}
+}
+
+// startOffset resolves where a new consumer group starts consuming, the
+// oldest message unless configured otherwise.
+func startOffset(offset string) int64 {
+	if strings.ToLower(offset) == "last" {
+		return kafkago.LastOffset
+	}
+	return kafkago.FirstOffset
+}
+
+func createHeaders(event model.EventWrapper, messageID string) []kafkago.Header {
+	headers := []kafkago.Header{{Key: headerMessageID, Value: []byte(messageID)}}
+
+	if event.EventType != "" {
+		headers = append(headers, kafkago.Header{Key: model.AttributeEventType, Value: []byte(event.EventType)})
+	}
+
+	if event.Version > 0 {
+		headers = append(headers, kafkago.Header{Key: model.AttributeEventVersion, Value: []byte(strconv.Itoa(event.Version))})
+	}
+
+	return headers
+}
+
+func headerOf(message kafkago.Message, key string) (value string, ok bool) {
+	for _, header := range message.Headers {
+		if header.Key == key {
+			return string(header.Value), true
+		}
+	}
+	return "", false
+}
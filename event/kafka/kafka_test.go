@@ -0,0 +1,245 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTopic = "foo.fifo"
+
+type recordingDeadLetterHandler struct {
+	mu      sync.Mutex
+	letters []consumer.DeadLetter
+}
+
+func (h *recordingDeadLetterHandler) HandleDeadLetter(letter consumer.DeadLetter) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.letters = append(h.letters, letter)
+	return nil
+}
+
+func newTestConfig() *configs.Config {
+	config := &configs.Config{}
+	config.Event.Kafka.BackoffMillis = 1
+	config.Event.Kafka.MaxAttempts = 3
+	return config
+}
+
+func newTestConsumer(broker *fakeBroker, config *configs.Config, process consumer.Process) (*Consumer, *recordingDeadLetterHandler) {
+	deadLetters := &recordingDeadLetterHandler{}
+	c := &Consumer{
+		Process:    process,
+		DeadLetter: deadLetters,
+		config:     config,
+		newReader:  broker.reader,
+		writer:     broker,
+	}
+	return c, deadLetters
+}
+
+func publish(t *testing.T, broker *fakeBroker, group string, values ...string) {
+	p := &Producer{writer: broker}
+	for _, value := range values {
+		event, err := model.NewVersionedEvent("foo.created", 2, map[string]string{"value": value})
+		require.NoError(t, err)
+		require.NoError(t, p.Publish(model.NewPublishRequest(testTopic, event, group)))
+	}
+}
+
+// listenUntil runs the consumer until the topic has committed messages.
+func listenUntil(t *testing.T, c *Consumer, broker *fakeBroker, committed int64) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Listen(ctx, testTopic)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for broker.committedCount(testTopic) < committed {
+		if time.Now().After(deadline) {
+			t.Fatalf("committed %d of %d messages", broker.committedCount(testTopic), committed)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestProducer(t *testing.T) {
+	broker := newFakeBroker(4)
+	publish(t, broker, "group-a", "a1", "a2")
+	publish(t, broker, "group-b", "b1")
+
+	messages := broker.messages(testTopic)
+	require.Len(t, messages, 3)
+
+	partitions := make(map[string]int)
+	for _, message := range messages {
+		eventType, _ := headerOf(message, model.AttributeEventType)
+		version, _ := headerOf(message, model.AttributeEventVersion)
+		_, hasMessageID := headerOf(message, headerMessageID)
+		assert.Equal(t, "foo.created", eventType)
+		assert.Equal(t, "2", version)
+		assert.True(t, hasMessageID)
+
+		// the message group is the partition key
+		key := string(message.Key)
+		if partition, ok := partitions[key]; ok {
+			assert.Equal(t, partition, message.Partition)
+		}
+		partitions[key] = message.Partition
+	}
+	assert.Len(t, partitions, 2)
+}
+
+func TestConsumer(t *testing.T) {
+	t.Run("hands SNS notifications to Process and commits them", func(t *testing.T) {
+		broker := newFakeBroker(2)
+		publish(t, broker, "group-a", "a1", "a2", "a3")
+
+		var notifications []model.SNSMessage
+		c, _ := newTestConsumer(broker, newTestConfig(), func(body []byte) error {
+			notification := model.SNSMessage{}
+			err := json.Unmarshal(body, &notification)
+			notifications = append(notifications, notification)
+			return err
+		})
+		listenUntil(t, c, broker, 3)
+
+		require.Len(t, notifications, 3)
+		for i, value := range []string{"a1", "a2", "a3"} {
+			messageID, _ := headerOf(broker.messages(testTopic)[i], headerMessageID)
+			assert.Equal(t, messageID, notifications[i].MessageID.String())
+			assert.Equal(t, testTopic, notifications[i].TopicARN)
+			assert.JSONEq(t, `{"value":"`+value+`"}`, notifications[i].Message)
+			assert.Equal(t, "foo.created", notifications[i].MessageAttributes[model.AttributeEventType].Value)
+			assert.Equal(t, "2", notifications[i].MessageAttributes[model.AttributeEventVersion].Value)
+		}
+	})
+
+	t.Run("retries failed messages in place", func(t *testing.T) {
+		broker := newFakeBroker(1)
+		publish(t, broker, "group-a", "a1", "a2")
+
+		attempts := 0
+		var processed []string
+		c, deadLetters := newTestConsumer(broker, newTestConfig(), func(body []byte) error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("unavailable")
+			}
+			notification := model.SNSMessage{}
+			require.NoError(t, json.Unmarshal(body, &notification))
+			processed = append(processed, notification.Message)
+			return nil
+		})
+		listenUntil(t, c, broker, 2)
+
+		assert.Equal(t, []string{`{"value":"a1"}`, `{"value":"a2"}`}, processed)
+		assert.Empty(t, deadLetters.letters)
+	})
+
+	t.Run("dead-letters messages after the maximum attempts", func(t *testing.T) {
+		broker := newFakeBroker(1)
+		publish(t, broker, "group-a", "a1")
+
+		c, deadLetters := newTestConsumer(broker, newTestConfig(), func([]byte) error { return errors.New("unavailable") })
+		listenUntil(t, c, broker, 1)
+
+		if assert.Len(t, deadLetters.letters, 1) {
+			assert.Equal(t, 3, deadLetters.letters[0].ReceiveCount)
+			assert.Equal(t, testTopic, deadLetters.letters[0].Source)
+			assert.Equal(t, "group-a", *deadLetters.letters[0].MessageGroupID)
+		}
+	})
+
+	t.Run("writes permanent failures to the dead-letter topic", func(t *testing.T) {
+		broker := newFakeBroker(1)
+		publish(t, broker, "group-a", "a1")
+
+		config := newTestConfig()
+		config.Event.Kafka.DeadLetterTopic = "foo-dlq"
+		c, deadLetters := newTestConsumer(broker, config, func([]byte) error {
+			return consumer.Permanent(errors.New("malformed"))
+		})
+		listenUntil(t, c, broker, 1)
+
+		assert.Empty(t, deadLetters.letters)
+		messages := broker.messages("foo-dlq")
+		if assert.Len(t, messages, 1) {
+			source, _ := headerOf(messages[0], headerSourceTopic)
+			reason, _ := headerOf(messages[0], headerFailureReason)
+			assert.Equal(t, testTopic, source)
+			assert.Equal(t, "malformed", reason)
+			assert.Equal(t, "group-a", string(messages[0].Key))
+		}
+	})
+
+	t.Run("leaves unsettled messages uncommitted when stopped", func(t *testing.T) {
+		broker := newFakeBroker(1)
+		publish(t, broker, "group-a", "a1")
+
+		config := newTestConfig()
+		config.Event.Kafka.BackoffMillis = int64(time.Hour / time.Millisecond)
+		failed := make(chan struct{}, 1)
+		c, _ := newTestConsumer(broker, config, func([]byte) error {
+			failed <- struct{}{}
+			return errors.New("unavailable")
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			c.Listen(ctx, testTopic)
+			close(done)
+		}()
+		<-failed
+		cancel()
+		<-done
+		assert.Equal(t, int64(0), broker.committedCount(testTopic))
+
+		// the next member of the group resumes from it
+		resumed, _ := newTestConsumer(broker, newTestConfig(), func([]byte) error { return nil })
+		listenUntil(t, resumed, broker, 1)
+	})
+}
+
+func TestConsumerRequeue(t *testing.T) {
+	broker := newFakeBroker(1)
+	publish(t, broker, "group-a", "a1")
+
+	c, deadLetters := newTestConsumer(broker, newTestConfig(), func([]byte) error {
+		return consumer.Permanent(errors.New("malformed"))
+	})
+	listenUntil(t, c, broker, 1)
+	require.Len(t, deadLetters.letters, 1)
+	letter := deadLetters.letters[0]
+
+	require.NoError(t, c.Requeue(letter.Source, letter.Body, letter.MessageGroupID))
+
+	messages := broker.messages(testTopic)
+	require.Len(t, messages, 2)
+	assert.Equal(t, messages[0].Value, messages[1].Value)
+	assert.Equal(t, "group-a", string(messages[1].Key))
+	eventType, _ := headerOf(messages[1], model.AttributeEventType)
+	assert.Equal(t, "foo.created", eventType)
+}
+
+func TestRequiredAcks(t *testing.T) {
+	assert.Equal(t, "all", requiredAcks("").String())
+	assert.Equal(t, "one", requiredAcks("one").String())
+	assert.Equal(t, "none", requiredAcks("0").String())
+}
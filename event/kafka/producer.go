@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+// Producer publishes events to Kafka. The topic of a publish request is the
+// Kafka topic and its message group the partition key, so that the events of
+// a group keep their order. The event type and version are carried as
+// headers.
+type Producer struct {
+	writer messageWriter
+}
+
+// NewProducer creates a producer writing to the configured brokers, waiting
+// for the configured acknowledgements.
+func NewProducer(config *configs.Config) *Producer {
+	log.Info().Strs("brokers", config.Event.Kafka.Brokers).Msg("Kafka Producer ready to publish messages.")
+	return &Producer{writer: newWriter(config)}
+}
+
+// Publish publishes an event to Kafka.
+func (p *Producer) Publish(request model.PublishRequest) error {
+	messageID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	message := kafkago.Message{
+		Topic:   request.Topic,
+		Value:   request.Event.Data.Value,
+		Headers: createHeaders(request.Event, messageID.String()),
+		Time:    request.Event.Data.Timestamp,
+	}
+	if request.MessageGroupID != nil {
+		message.Key = []byte(*request.MessageGroupID)
+	}
+
+	err = p.writer.WriteMessages(context.Background(), message)
+	if err != nil {
+		log.Err(err).Str("topic", request.Topic).Msg("failed publishing message")
+		return err
+	}
+
+	log.Info().
+		Str("topic", request.Topic).
+		Str("messageId", messageID.String()).
+		Str("key", string(message.Key)).
+		Msg("Published Kafka message")
+
+	return nil
+}
@@ -0,0 +1,517 @@
+package memory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// Transport is the EVENT.TRANSPORT value selecting the in-memory broker
+// instead of SNS and SQS.
+const Transport = "memory"
+
+const (
+	// defaultVisibilityTimeout is the visibility timeout of queues not
+	// configured otherwise, the same as SQS's default.
+	defaultVisibilityTimeout = 30 * time.Second
+	// deduplicationInterval is how long FIFO queues remember deduplication
+	// ids.
+	deduplicationInterval = 5 * time.Minute
+	// maxReceiveMessages is the most messages a single receive returns.
+	maxReceiveMessages = 10
+
+	fifoSuffix = ".fifo"
+)
+
+// QueueOptions configures a queue of the broker.
+type QueueOptions struct {
+	// DeadLetterQueueURL is the queue messages are moved to once they have
+	// been received MaxReceiveCount times, like an SQS redrive policy.
+	DeadLetterQueueURL string
+	MaxReceiveCount    int
+	VisibilityTimeout  time.Duration
+}
+
+type message struct {
+	id            string
+	body          string
+	groupID       *string
+	attributes    map[string]*sqs.MessageAttributeValue
+	receiptHandle string
+	receiveCount  int
+	visibleAt     time.Time
+}
+
+func (m *message) inFlight(now time.Time) bool {
+	return m.receiveCount > 0 && m.visibleAt.After(now)
+}
+
+type queue struct {
+	url          string
+	fifo         bool
+	options      QueueOptions
+	messages     []*message
+	deduplicated map[string]time.Time
+}
+
+// Broker is an in-process stand-in for SNS topics fanning out to SQS queues,
+// so that events can be produced and consumed without AWS. It implements
+// producer.Producer, and the part of sqsiface.SQSAPI used by
+// consumer.SQSConsumer; calling any other SQS operation panics.
+//
+// Topics and queues are plain names: a queue is created on first use, and
+// queues whose name ends with ".fifo" behave as FIFO queues. Messages of a
+// FIFO message group are delivered one at a time, in the order they were
+// sent, so that concurrent consumers preserve the group's ordering.
+type Broker struct {
+	sqsiface.SQSAPI
+
+	mu                sync.Mutex
+	queues            map[string]*queue
+	subscriptions     map[string][]string
+	changed           chan struct{}
+	visibilityTimeout time.Duration
+	now               func() time.Time
+}
+
+var (
+	broker     *Broker
+	brokerOnce sync.Once
+)
+
+// ProvideBroker is the provider for the broker. All injectors share a single
+// broker, so that events published by the service reach its own consumers.
+// Its subscriptions are read from EVENT.MEMORY.SUBSCRIPTIONS, a list of
+// topic=queue pairs.
+func ProvideBroker(config *configs.Config) *Broker {
+	brokerOnce.Do(func() {
+		broker = NewBroker()
+		if seconds := config.Event.Memory.VisibilityTimeoutSeconds; seconds > 0 {
+			broker.visibilityTimeout = time.Duration(seconds) * time.Second
+		}
+
+		for _, subscription := range config.Event.Memory.Subscriptions {
+			parts := strings.SplitN(subscription, "=", 2)
+			if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+				log.Fatal().Str("subscription", subscription).Msg("invalid in-memory subscription, expecting topic=queue")
+			}
+			broker.Subscribe(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+		}
+
+		log.Info().Strs("subscriptions", config.Event.Memory.Subscriptions).Msg("In-memory event broker ready.")
+	})
+	return broker
+}
+
+// NewBroker creates an empty broker.
+func NewBroker() *Broker {
+	return &Broker{
+		queues:            make(map[string]*queue),
+		subscriptions:     make(map[string][]string),
+		changed:           make(chan struct{}),
+		visibilityTimeout: defaultVisibilityTimeout,
+		now:               time.Now,
+	}
+}
+
+// Subscribe delivers the messages published to a topic to a queue.
+func (b *Broker) Subscribe(topic string, queueURL string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, subscribed := range b.subscriptions[topic] {
+		if subscribed == queueURL {
+			return
+		}
+	}
+	b.subscriptions[topic] = append(b.subscriptions[topic], queueURL)
+	b.queue(queueURL)
+}
+
+// ConfigureQueue sets the options of a queue, creating it if needed.
+func (b *Broker) ConfigureQueue(queueURL string, options QueueOptions) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.queue(queueURL).options = options
+}
+
+// Depth returns the number of messages in a queue, in flight or not.
+func (b *Broker) Depth(queueURL string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.queue(queueURL).messages)
+}
+
+// Publish publishes an event to a topic, wrapping it in an SNS notification
+// for every subscribed queue.
+func (b *Broker) Publish(request model.PublishRequest) error {
+	fifo := strings.HasSuffix(request.Topic, fifoSuffix)
+	if fifo && request.MessageGroupID == nil {
+		return fmt.Errorf("publishing to FIFO topic %s requires a message group", request.Topic)
+	}
+	if !fifo && request.MessageGroupID != nil {
+		return fmt.Errorf("message groups are only accepted by FIFO topics, not %s", request.Topic)
+	}
+
+	messageID, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	notification, err := json.Marshal(model.SNSMessage{
+		Type:              "Notification",
+		MessageID:         messageID,
+		TopicARN:          request.Topic,
+		Message:           string(request.Event.Data.Value),
+		Timestamp:         b.now().UTC().Format(time.RFC3339Nano),
+		MessageAttributes: createMessageAttributes(request.Event),
+	})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscriptions := b.subscriptions[request.Topic]
+	for _, queueURL := range subscriptions {
+		q := b.queue(queueURL)
+		input := &sqs.SendMessageInput{MessageBody: aws.String(string(notification))}
+		if q.fifo {
+			input.MessageDeduplicationId = aws.String(messageID.String())
+			input.MessageGroupId = request.MessageGroupID
+		}
+
+		_, err = b.send(q, input)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Debug().
+		Str("topic", request.Topic).
+		Str("messageId", messageID.String()).
+		Int("subscriptions", len(subscriptions)).
+		Msg("Published in-memory message")
+
+	return nil
+}
+
+func createMessageAttributes(event model.EventWrapper) map[string]model.SNSMessageAttribute {
+	attributes := make(map[string]model.SNSMessageAttribute)
+
+	if event.EventType != "" {
+		attributes[model.AttributeEventType] = model.SNSMessageAttribute{Type: "String", Value: event.EventType}
+	}
+
+	if event.Version > 0 {
+		attributes[model.AttributeEventVersion] = model.SNSMessageAttribute{Type: "Number", Value: strconv.Itoa(event.Version)}
+	}
+
+	return attributes
+}
+
+// SendMessage sends a message to a queue.
+func (b *Broker) SendMessage(input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.send(b.queue(aws.StringValue(input.QueueUrl)), input)
+}
+
+// ReceiveMessage receives messages from a queue.
+func (b *Broker) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	return b.ReceiveMessageWithContext(context.Background(), input)
+}
+
+// ReceiveMessageWithContext receives messages from a queue, waiting up to
+// WaitTimeSeconds for one to become available.
+func (b *Broker) ReceiveMessageWithContext(ctx aws.Context, input *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	max := int(aws.Int64Value(input.MaxNumberOfMessages))
+	if max < 1 {
+		max = 1
+	}
+	if max > maxReceiveMessages {
+		max = maxReceiveMessages
+	}
+	deadline := b.now().Add(time.Duration(aws.Int64Value(input.WaitTimeSeconds)) * time.Second)
+
+	for {
+		b.mu.Lock()
+		q := b.queue(aws.StringValue(input.QueueUrl))
+		messages, nextVisible := b.receive(q, max)
+		changed := b.changed
+		b.mu.Unlock()
+
+		now := b.now()
+		if len(messages) > 0 || !now.Before(deadline) {
+			return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+		}
+
+		wait := deadline.Sub(now)
+		if !nextVisible.IsZero() && nextVisible.Sub(now) < wait {
+			wait = nextVisible.Sub(now)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-changed:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// DeleteMessage deletes a received message.
+func (b *Broker) DeleteMessage(input *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.delete(b.queue(aws.StringValue(input.QueueUrl)), aws.StringValue(input.ReceiptHandle)) {
+		return nil, fmt.Errorf("receipt handle %s is invalid", aws.StringValue(input.ReceiptHandle))
+	}
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+// DeleteMessageBatch deletes received messages.
+func (b *Broker) DeleteMessageBatch(input *sqs.DeleteMessageBatchInput) (*sqs.DeleteMessageBatchOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q := b.queue(aws.StringValue(input.QueueUrl))
+	output := &sqs.DeleteMessageBatchOutput{}
+	for _, entry := range input.Entries {
+		if b.delete(q, aws.StringValue(entry.ReceiptHandle)) {
+			output.Successful = append(output.Successful, &sqs.DeleteMessageBatchResultEntry{Id: entry.Id})
+			continue
+		}
+		output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{
+			Id:          entry.Id,
+			Code:        aws.String(sqs.ErrCodeReceiptHandleIsInvalid),
+			Message:     aws.String("receipt handle is invalid"),
+			SenderFault: aws.Bool(true),
+		})
+	}
+	return output, nil
+}
+
+// ChangeMessageVisibility changes how long a received message stays
+// invisible.
+func (b *Broker) ChangeMessageVisibility(input *sqs.ChangeMessageVisibilityInput) (*sqs.ChangeMessageVisibilityOutput, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m := b.find(b.queue(aws.StringValue(input.QueueUrl)), aws.StringValue(input.ReceiptHandle))
+	if m == nil {
+		return nil, fmt.Errorf("receipt handle %s is invalid", aws.StringValue(input.ReceiptHandle))
+	}
+
+	m.visibleAt = b.now().Add(time.Duration(aws.Int64Value(input.VisibilityTimeout)) * time.Second)
+	b.notify()
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+// queue resolves a queue, creating it on first use. b.mu must be held.
+func (b *Broker) queue(url string) *queue {
+	q, ok := b.queues[url]
+	if !ok {
+		q = &queue{
+			url:          url,
+			fifo:         strings.HasSuffix(url, fifoSuffix),
+			deduplicated: make(map[string]time.Time),
+		}
+		b.queues[url] = q
+	}
+	return q
+}
+
+// send appends a message to a queue. b.mu must be held.
+func (b *Broker) send(q *queue, input *sqs.SendMessageInput) (*sqs.SendMessageOutput, error) {
+	body := aws.StringValue(input.MessageBody)
+	now := b.now()
+
+	m := &message{
+		body:       body,
+		attributes: input.MessageAttributes,
+	}
+
+	if q.fifo {
+		if input.MessageGroupId == nil {
+			return nil, fmt.Errorf("sending to FIFO queue %s requires a message group", q.url)
+		}
+		m.groupID = aws.String(aws.StringValue(input.MessageGroupId))
+
+		// without an explicit id, deduplicate on the content like SQS's
+		// content-based deduplication
+		deduplicationID := aws.StringValue(input.MessageDeduplicationId)
+		if deduplicationID == "" {
+			sum := sha256.Sum256([]byte(body))
+			deduplicationID = hex.EncodeToString(sum[:])
+		}
+		for id, until := range q.deduplicated {
+			if !until.After(now) {
+				delete(q.deduplicated, id)
+			}
+		}
+		if _, ok := q.deduplicated[deduplicationID]; ok {
+			return &sqs.SendMessageOutput{}, nil
+		}
+		q.deduplicated[deduplicationID] = now.Add(deduplicationInterval)
+	} else if input.MessageGroupId != nil {
+		return nil, fmt.Errorf("message groups are only accepted by FIFO queues, not %s", q.url)
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	m.id = id.String()
+
+	q.messages = append(q.messages, m)
+	b.notify()
+	return &sqs.SendMessageOutput{MessageId: aws.String(m.id)}, nil
+}
+
+// receive takes up to max visible messages off a queue, moving those received
+// too often to its dead-letter queue. It also returns when the earliest
+// invisible message becomes visible again. b.mu must be held.
+func (b *Broker) receive(q *queue, max int) (received []*sqs.Message, nextVisible time.Time) {
+	now := b.now()
+	visibilityTimeout := q.options.VisibilityTimeout
+	if visibilityTimeout <= 0 {
+		visibilityTimeout = b.visibilityTimeout
+	}
+
+	blockedGroups := make(map[string]bool)
+	if q.fifo {
+		for _, m := range q.messages {
+			if m.inFlight(now) {
+				blockedGroups[*m.groupID] = true
+			}
+		}
+	}
+
+	for i := 0; i < len(q.messages) && len(received) < max; i++ {
+		m := q.messages[i]
+		if m.visibleAt.After(now) {
+			if nextVisible.IsZero() || m.visibleAt.Before(nextVisible) {
+				nextVisible = m.visibleAt
+			}
+			continue
+		}
+		if q.fifo && blockedGroups[*m.groupID] {
+			continue
+		}
+
+		if q.options.DeadLetterQueueURL != "" && q.options.MaxReceiveCount > 0 && m.receiveCount >= q.options.MaxReceiveCount {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			i--
+			b.redrive(q, m)
+			continue
+		}
+
+		receiptHandle, _ := uuid.NewV4()
+		m.receiptHandle = receiptHandle.String()
+		m.receiveCount++
+		m.visibleAt = now.Add(visibilityTimeout)
+		if q.fifo {
+			blockedGroups[*m.groupID] = true
+		}
+
+		received = append(received, toSQSMessage(m))
+	}
+
+	return
+}
+
+// redrive moves a message to the dead-letter queue of q. b.mu must be held.
+func (b *Broker) redrive(q *queue, m *message) {
+	deadLetterQueue := b.queue(q.options.DeadLetterQueueURL)
+	deadLetter := &message{
+		id:         m.id,
+		body:       m.body,
+		attributes: m.attributes,
+	}
+	if deadLetterQueue.fifo {
+		deadLetter.groupID = m.groupID
+		if deadLetter.groupID == nil {
+			deadLetter.groupID = aws.String(q.url)
+		}
+	}
+	deadLetterQueue.messages = append(deadLetterQueue.messages, deadLetter)
+	b.notify()
+
+	log.Warn().
+		Str("messageId", m.id).
+		Str("queue", q.url).
+		Str("deadLetterQueue", deadLetterQueue.url).
+		Int("receiveCount", m.receiveCount).
+		Msg("Moved in-memory message to its dead-letter queue")
+}
+
+// find resolves an in-flight message by its receipt handle. b.mu must be
+// held.
+func (b *Broker) find(q *queue, receiptHandle string) *message {
+	for _, m := range q.messages {
+		if m.receiptHandle != "" && m.receiptHandle == receiptHandle {
+			return m
+		}
+	}
+	return nil
+}
+
+// delete removes a message by its receipt handle. b.mu must be held.
+func (b *Broker) delete(q *queue, receiptHandle string) bool {
+	for i, m := range q.messages {
+		if m.receiptHandle != "" && m.receiptHandle == receiptHandle {
+			q.messages = append(q.messages[:i], q.messages[i+1:]...)
+			// a FIFO group may be unblocked
+			b.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// notify wakes up the receives waiting for messages. b.mu must be held.
+func (b *Broker) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func toSQSMessage(m *message) *sqs.Message {
+	attributes := map[string]*string{
+		sqs.MessageSystemAttributeNameApproximateReceiveCount: aws.String(strconv.Itoa(m.receiveCount)),
+	}
+	if m.groupID != nil {
+		attributes[sqs.MessageSystemAttributeNameMessageGroupId] = aws.String(*m.groupID)
+	}
+
+	return &sqs.Message{
+		Attributes:        attributes,
+		Body:              aws.String(m.body),
+		MessageAttributes: m.attributes,
+		MessageId:         aws.String(m.id),
+		ReceiptHandle:     aws.String(m.receiptHandle),
+	}
+}
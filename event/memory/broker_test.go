@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestBroker() (*Broker, *clock) {
+	c := &clock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	b := NewBroker()
+	b.now = c.Now
+	return b, c
+}
+
+func newTestRequest(t *testing.T, topic string, value string, groupID string) model.PublishRequest {
+	event, err := model.NewVersionedEvent("foo.created", 2, map[string]string{"value": value})
+	require.NoError(t, err)
+	return model.NewPublishRequest(topic, event, groupID)
+}
+
+func receive(t *testing.T, b *Broker, queueURL string) []*sqs.Message {
+	output, err := b.ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: aws.Int64(10),
+	})
+	require.NoError(t, err)
+	return output.Messages
+}
+
+func deleteMessages(t *testing.T, b *Broker, queueURL string, messages ...*sqs.Message) {
+	for _, m := range messages {
+		_, err := b.DeleteMessage(&sqs.DeleteMessageInput{QueueUrl: aws.String(queueURL), ReceiptHandle: m.ReceiptHandle})
+		require.NoError(t, err)
+	}
+}
+
+func TestBrokerPublish(t *testing.T) {
+	t.Run("fans out SNS notifications to every subscribed queue", func(t *testing.T) {
+		b, _ := newTestBroker()
+		b.Subscribe("foo", "first")
+		b.Subscribe("foo", "second")
+
+		require.NoError(t, b.Publish(newTestRequest(t, "foo", "bar", "")))
+
+		for _, queueURL := range []string{"first", "second"} {
+			messages := receive(t, b, queueURL)
+			require.Len(t, messages, 1)
+
+			notification := model.SNSMessage{}
+			require.NoError(t, json.Unmarshal([]byte(aws.StringValue(messages[0].Body)), &notification))
+			assert.Equal(t, "Notification", notification.Type)
+			assert.Equal(t, "foo", notification.TopicARN)
+			assert.JSONEq(t, `{"value":"bar"}`, notification.Message)
+			assert.Equal(t, "foo.created", notification.MessageAttributes[model.AttributeEventType].Value)
+			assert.Equal(t, "2", notification.MessageAttributes[model.AttributeEventVersion].Value)
+		}
+	})
+
+	t.Run("drops messages of topics without subscriptions", func(t *testing.T) {
+		b, _ := newTestBroker()
+
+		assert.NoError(t, b.Publish(newTestRequest(t, "foo", "bar", "")))
+	})
+
+	t.Run("requires a message group for FIFO topics", func(t *testing.T) {
+		b, _ := newTestBroker()
+
+		assert.Error(t, b.Publish(newTestRequest(t, "foo.fifo", "bar", "")))
+	})
+}
+
+func TestBrokerFIFO(t *testing.T) {
+	b, _ := newTestBroker()
+	b.Subscribe("foo.fifo", "foo.fifo")
+
+	for _, value := range []string{"a1", "a2", "a3"} {
+		require.NoError(t, b.Publish(newTestRequest(t, "foo.fifo", value, "a")))
+	}
+	require.NoError(t, b.Publish(newTestRequest(t, "foo.fifo", "b1", "b")))
+
+	// one message per group is in flight at a time, in the order they were sent
+	var values []string
+	for len(values) < 4 {
+		messages := receive(t, b, "foo.fifo")
+		require.NotEmpty(t, messages)
+		for _, m := range messages {
+			notification := model.SNSMessage{}
+			require.NoError(t, json.Unmarshal([]byte(aws.StringValue(m.Body)), &notification))
+			values = append(values, notification.Message)
+		}
+		assert.Empty(t, receive(t, b, "foo.fifo"))
+		deleteMessages(t, b, "foo.fifo", messages...)
+	}
+
+	assert.Equal(t, []string{`{"value":"a1"}`, `{"value":"b1"}`, `{"value":"a2"}`, `{"value":"a3"}`}, values)
+}
+
+func TestBrokerDeduplication(t *testing.T) {
+	b, c := newTestBroker()
+	send := func() {
+		_, err := b.SendMessage(&sqs.SendMessageInput{
+			QueueUrl:               aws.String("foo.fifo"),
+			MessageBody:            aws.String("bar"),
+			MessageGroupId:         aws.String("a"),
+			MessageDeduplicationId: aws.String("1"),
+		})
+		require.NoError(t, err)
+	}
+
+	send()
+	send()
+	assert.Equal(t, 1, b.Depth("foo.fifo"))
+
+	c.Advance(deduplicationInterval)
+	send()
+	assert.Equal(t, 2, b.Depth("foo.fifo"))
+}
+
+func TestBrokerVisibility(t *testing.T) {
+	b, c := newTestBroker()
+	b.ConfigureQueue("foo", QueueOptions{VisibilityTimeout: time.Minute})
+	_, err := b.SendMessage(&sqs.SendMessageInput{QueueUrl: aws.String("foo"), MessageBody: aws.String("bar")})
+	require.NoError(t, err)
+
+	messages := receive(t, b, "foo")
+	require.Len(t, messages, 1)
+	assert.Equal(t, "1", aws.StringValue(messages[0].Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+	assert.Empty(t, receive(t, b, "foo"))
+
+	// redelivered once the visibility timeout expires
+	c.Advance(time.Minute)
+	messages = receive(t, b, "foo")
+	require.Len(t, messages, 1)
+	assert.Equal(t, "2", aws.StringValue(messages[0].Attributes[sqs.MessageSystemAttributeNameApproximateReceiveCount]))
+
+	// or right away when its visibility is changed
+	_, err = b.ChangeMessageVisibility(&sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String("foo"),
+		ReceiptHandle:     messages[0].ReceiptHandle,
+		VisibilityTimeout: aws.Int64(0),
+	})
+	require.NoError(t, err)
+	messages = receive(t, b, "foo")
+	require.Len(t, messages, 1)
+
+	deleteMessages(t, b, "foo", messages...)
+	assert.Equal(t, 0, b.Depth("foo"))
+}
+
+func TestBrokerRedrive(t *testing.T) {
+	b, c := newTestBroker()
+	b.ConfigureQueue("foo", QueueOptions{DeadLetterQueueURL: "foo-dlq", MaxReceiveCount: 2, VisibilityTimeout: time.Second})
+	_, err := b.SendMessage(&sqs.SendMessageInput{QueueUrl: aws.String("foo"), MessageBody: aws.String("bar")})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		require.Len(t, receive(t, b, "foo"), 1)
+		c.Advance(time.Second)
+	}
+
+	assert.Empty(t, receive(t, b, "foo"))
+	assert.Equal(t, 0, b.Depth("foo"))
+
+	messages := receive(t, b, "foo-dlq")
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, "bar", aws.StringValue(messages[0].Body))
+	}
+}
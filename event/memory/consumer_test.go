@@ -0,0 +1,69 @@
+package memory_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/event/memory"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRequest(t *testing.T, value string) model.PublishRequest {
+	event, err := model.NewVersionedEvent("foo.created", 2, map[string]string{"value": value})
+	require.NoError(t, err)
+	return model.NewPublishRequest("foo", event, "")
+}
+
+func TestBrokerWithSQSConsumer(t *testing.T) {
+	b := memory.NewBroker()
+	b.Subscribe("foo", "foo-queue")
+
+	config := &configs.Config{}
+	config.Event.Consumer.SQS.MaxMessage = 10
+	config.Event.Consumer.SQS.WaitTimeSeconds = 1
+	config.Event.Consumer.SQS.Workers = 2
+
+	registry := model.NewSchemaRegistry()
+	require.NoError(t, registry.Register("foo.created", 2, `{"type":"object","properties":{"value":{"type":"string"}},"required":["value"]}`))
+
+	received := make(chan string, 3)
+	c := consumer.NewSQSConsumerWithClient(config, b)
+	c.Process = consumer.ValidateSNS(registry, "foo.created", func(body []byte) error {
+		notification := model.SNSMessage{}
+		err := json.Unmarshal(body, &notification)
+		received <- notification.Message
+		return err
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Listen(ctx, "foo-queue")
+		close(done)
+	}()
+
+	for _, value := range []string{"a", "b", "c"} {
+		require.NoError(t, b.Publish(newTestRequest(t, value)))
+	}
+
+	var values []string
+	for len(values) < 3 {
+		select {
+		case value := <-received:
+			values = append(values, value)
+		case <-time.After(5 * time.Second):
+			t.Fatal("messages were not consumed")
+		}
+	}
+	assert.ElementsMatch(t, []string{`{"value":"a"}`, `{"value":"b"}`, `{"value":"c"}`}, values)
+
+	cancel()
+	<-done
+	assert.Equal(t, 0, b.Depth("foo-queue"))
+}
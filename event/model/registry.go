@@ -0,0 +1,104 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ErrSchemaNotFound is returned when no schema is registered for an event
+// type and version.
+type ErrSchemaNotFound struct {
+	EventType string
+	Version   int
+}
+
+func (e *ErrSchemaNotFound) Error() string {
+	return fmt.Sprintf("no schema registered for %s v%d", e.EventType, e.Version)
+}
+
+// SchemaKey identifies a schema by event type and version.
+type SchemaKey struct {
+	EventType string
+	Version   int
+}
+
+// SchemaRegistry maps event types and versions to the JSON Schemas their
+// payloads must satisfy.
+type SchemaRegistry struct {
+	schemas map[SchemaKey]*Schema
+}
+
+// NewSchemaRegistry creates an empty schema registry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{schemas: make(map[SchemaKey]*Schema)}
+}
+
+// ProvideSchemaRegistry is the provider for the registry holding the schemas
+// shipped with this service.
+func ProvideSchemaRegistry() *SchemaRegistry {
+	registry := NewSchemaRegistry()
+	for _, s := range eventSchemas {
+		if err := registry.Register(s.EventType, s.Version, s.Schema); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+// Register parses and registers the schema of an event type and version.
+func (r *SchemaRegistry) Register(eventType string, version int, rawSchema string) (err error) {
+	key := SchemaKey{EventType: eventType, Version: version}
+	if _, exists := r.schemas[key]; exists {
+		return fmt.Errorf("schema for %s v%d is already registered", eventType, version)
+	}
+
+	schema, err := ParseSchema(rawSchema)
+	if err != nil {
+		return fmt.Errorf("%s v%d: %w", eventType, version, err)
+	}
+
+	r.schemas[key] = schema
+	return
+}
+
+// Resolve returns the schema of an event type and version.
+func (r *SchemaRegistry) Resolve(eventType string, version int) (schema *Schema, err error) {
+	schema, ok := r.schemas[SchemaKey{EventType: eventType, Version: version}]
+	if !ok {
+		return nil, &ErrSchemaNotFound{EventType: eventType, Version: version}
+	}
+	return
+}
+
+// Keys returns the registered event types and versions in a stable order.
+func (r *SchemaRegistry) Keys() (keys []SchemaKey) {
+	for key := range r.schemas {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].EventType != keys[j].EventType {
+			return keys[i].EventType < keys[j].EventType
+		}
+		return keys[i].Version < keys[j].Version
+	})
+	return
+}
+
+// Validate checks a payload against the schema of its event type and version.
+func (r *SchemaRegistry) Validate(eventType string, version int, payload []byte) (err error) {
+	schema, err := r.Resolve(eventType, version)
+	if err != nil {
+		return
+	}
+
+	problems := schema.Validate(payload)
+	if len(problems) > 0 {
+		return &SchemaValidationError{EventType: eventType, Version: version, Problems: problems}
+	}
+	return
+}
+
+// ValidateEvent checks the payload of an event against its schema.
+func (r *SchemaRegistry) ValidateEvent(event EventWrapper) error {
+	return r.Validate(event.EventType, event.Version, event.Data.Value)
+}
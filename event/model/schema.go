@@ -0,0 +1,283 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Schema is the subset of JSON Schema used to describe event payloads. It
+// supports type, properties, required, additionalProperties, items, enum,
+// format (uuid and date-time) and minimum.
+type Schema struct {
+	Type                 SchemaTypes        `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+}
+
+// SchemaTypes is the list of types a value may have. JSON Schema allows it to
+// be written either as a single string or as an array of strings.
+type SchemaTypes []string
+
+// UnmarshalJSON decodes a single type or a list of types.
+func (t *SchemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaTypes{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("type must be a string or an array of strings: %w", err)
+	}
+	*t = multiple
+	return nil
+}
+
+// SchemaValidationError is returned when a payload does not satisfy its schema.
+type SchemaValidationError struct {
+	EventType string
+	Version   int
+	Problems  []string
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("%s v%d payload is invalid: %s", e.EventType, e.Version, strings.Join(e.Problems, "; "))
+}
+
+// ParseSchema parses a JSON Schema document.
+func ParseSchema(raw string) (schema *Schema, err error) {
+	schema = &Schema{}
+	err = json.Unmarshal([]byte(raw), schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing schema: %w", err)
+	}
+	return
+}
+
+// Validate checks the given JSON document against the schema and returns the
+// list of problems found, each prefixed with the path of the offending value.
+func (s *Schema) Validate(document []byte) (problems []string) {
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []string{fmt.Sprintf("$: not a valid JSON document: %v", err)}
+	}
+
+	return s.validate("$", value)
+}
+
+func (s *Schema) validate(path string, value interface{}) (problems []string) {
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), typeOf(value))}
+	}
+
+	if len(s.Enum) > 0 && !s.matchesEnum(value) {
+		problems = append(problems, fmt.Sprintf("%s: value is not one of the allowed values", path))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		problems = append(problems, s.validateObject(path, v)...)
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				problems = append(problems, s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case string:
+		if problem := s.validateFormat(v); problem != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", path, problem))
+		}
+	case json.Number:
+		if s.Minimum != nil {
+			if f, err := v.Float64(); err == nil && f < *s.Minimum {
+				problems = append(problems, fmt.Sprintf("%s: must be at least %v", path, *s.Minimum))
+			}
+		}
+	}
+
+	return
+}
+
+func (s *Schema) validateObject(path string, object map[string]interface{}) (problems []string) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s.%s: is required", path, name))
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				problems = append(problems, fmt.Sprintf("%s.%s: is not allowed", path, name))
+			}
+			continue
+		}
+		problems = append(problems, property.validate(path+"."+name, object[name])...)
+	}
+
+	return
+}
+
+func (s *Schema) validateFormat(value string) string {
+	switch s.Format {
+	case "uuid":
+		if !uuidPattern.MatchString(value) {
+			return "must be a UUID"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	}
+	return ""
+}
+
+func (s *Schema) matchesType(value interface{}) bool {
+	actual := typeOf(value)
+	for _, expected := range s.Type {
+		if expected == actual {
+			return true
+		}
+		if expected == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Schema) matchesEnum(value interface{}) bool {
+	encoded, _ := json.Marshal(value)
+	for _, allowed := range s.Enum {
+		candidate, _ := json.Marshal(allowed)
+		if bytes.Equal(encoded, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// CheckCompatibility reports the changes from previous to next that would
+// break producers or consumers relying on previous. Removing or retyping a
+// property, changing which properties are required, narrowing an enum,
+// changing a format or closing an object to additional properties are all
+// breaking; such changes must be published under a new version instead.
+func CheckCompatibility(previous, next *Schema) (breaks []string) {
+	return checkCompatibility("$", previous, next)
+}
+
+func checkCompatibility(path string, previous, next *Schema) (breaks []string) {
+	if next == nil {
+		return []string{fmt.Sprintf("%s: was removed", path)}
+	}
+
+	if !sameStrings(previous.Type, next.Type) {
+		breaks = append(breaks, fmt.Sprintf("%s: type changed from %v to %v", path, previous.Type, next.Type))
+	}
+
+	if previous.Format != next.Format {
+		breaks = append(breaks, fmt.Sprintf("%s: format changed from %q to %q", path, previous.Format, next.Format))
+	}
+
+	if !sameStrings(previous.Required, next.Required) {
+		breaks = append(breaks, fmt.Sprintf("%s: required changed from %v to %v", path, previous.Required, next.Required))
+	}
+
+	if allowsAdditional(previous) && !allowsAdditional(next) {
+		breaks = append(breaks, fmt.Sprintf("%s: no longer allows additional properties", path))
+	}
+
+	if len(next.Enum) > 0 {
+		if len(previous.Enum) == 0 {
+			breaks = append(breaks, fmt.Sprintf("%s: is now restricted to an enum", path))
+		}
+		for _, value := range previous.Enum {
+			if !next.matchesEnum(value) {
+				breaks = append(breaks, fmt.Sprintf("%s: enum value %v was removed", path, value))
+			}
+		}
+	}
+
+	if next.Minimum != nil && (previous.Minimum == nil || *next.Minimum > *previous.Minimum) {
+		breaks = append(breaks, fmt.Sprintf("%s: minimum was raised", path))
+	}
+
+	if previous.Items != nil {
+		breaks = append(breaks, checkCompatibility(path+"[]", previous.Items, next.Items)...)
+	}
+
+	names := make([]string, 0, len(previous.Properties))
+	for name := range previous.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		breaks = append(breaks, checkCompatibility(path+"."+name, previous.Properties[name], next.Properties[name])...)
+	}
+
+	return
+}
+
+func allowsAdditional(s *Schema) bool {
+	return s.AdditionalProperties == nil || *s.AdditionalProperties
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package model_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaRegistry(t *testing.T) {
+	registry := model.ProvideSchemaRegistry()
+
+	t.Run("valid payload", func(t *testing.T) {
+		event, err := model.NewVersionedEvent("brand.created", 1, map[string]interface{}{
+			"brandId":    "0b9e3c1e-6a8f-4d2a-9a6e-3f1d2c4b5a69",
+			"brandName":  "Evermos",
+			"version":    1,
+			"occurredAt": "2021-06-01T10:00:00.123456+07:00",
+		})
+		require.NoError(t, err)
+		assert.NoError(t, registry.ValidateEvent(event))
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		event, err := model.NewVersionedEvent("brand.created", 1, map[string]interface{}{
+			"brandId":    "not-a-uuid",
+			"version":    "1",
+			"occurredAt": "2021-06-01T10:00:00+07:00",
+		})
+		require.NoError(t, err)
+
+		err = registry.ValidateEvent(event)
+		validationErr, ok := err.(*model.SchemaValidationError)
+		require.True(t, ok, "expected a SchemaValidationError, got %v", err)
+		assert.ElementsMatch(t, []string{
+			"$.brandName: is required",
+			"$.brandId: must be a UUID",
+			"$.version: expected integer, got string",
+		}, validationErr.Problems)
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		event, err := model.NewVersionedEvent("brand.created", 2, map[string]string{})
+		require.NoError(t, err)
+
+		err = registry.ValidateEvent(event)
+		_, ok := err.(*model.ErrSchemaNotFound)
+		assert.True(t, ok, "expected ErrSchemaNotFound, got %v", err)
+	})
+
+	t.Run("marshal error", func(t *testing.T) {
+		_, err := model.NewEvent("brand.created", make(chan int))
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	previous := `{
+		"type": "object",
+		"required": ["id", "status"],
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"status": {"type": "string", "enum": ["new", "paid"]},
+			"amount": {"type": "number"}
+		}
+	}`
+
+	cases := []struct {
+		name     string
+		next     string
+		breaking bool
+	}{
+		{
+			name: "optional property added",
+			next: `{
+				"type": "object",
+				"required": ["id", "status"],
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"status": {"type": "string", "enum": ["new", "paid", "refunded"]},
+					"amount": {"type": "number"},
+					"note": {"type": "string"}
+				}
+			}`,
+		},
+		{
+			name: "property removed",
+			next: `{
+				"type": "object",
+				"required": ["id", "status"],
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"status": {"type": "string", "enum": ["new", "paid"]}
+				}
+			}`,
+			breaking: true,
+		},
+		{
+			name: "property retyped",
+			next: `{
+				"type": "object",
+				"required": ["id", "status"],
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"status": {"type": "string", "enum": ["new", "paid"]},
+					"amount": {"type": "string"}
+				}
+			}`,
+			breaking: true,
+		},
+		{
+			name: "property made required",
+			next: `{
+				"type": "object",
+				"required": ["id", "status", "amount"],
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"status": {"type": "string", "enum": ["new", "paid"]},
+					"amount": {"type": "number"}
+				}
+			}`,
+			breaking: true,
+		},
+		{
+			name: "enum value removed",
+			next: `{
+				"type": "object",
+				"required": ["id", "status"],
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"status": {"type": "string", "enum": ["new"]},
+					"amount": {"type": "number"}
+				}
+			}`,
+			breaking: true,
+		},
+	}
+
+	previousSchema, err := model.ParseSchema(previous)
+	require.NoError(t, err)
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			nextSchema, err := model.ParseSchema(c.next)
+			require.NoError(t, err)
+
+			breaks := model.CheckCompatibility(previousSchema, nextSchema)
+			assert.Equal(t, c.breaking, len(breaks) > 0, "breaks: %v", breaks)
+		})
+	}
+}
+
+// TestSchemasAreCompatible guards released schemas against breaking changes.
+// Each schema is locked in testdata/schemas; a breaking change must be shipped
+// as a new version with its own snapshot.
+func TestSchemasAreCompatible(t *testing.T) {
+	registry := model.ProvideSchemaRegistry()
+
+	for _, key := range registry.Keys() {
+		name := fmt.Sprintf("%s.v%d", key.EventType, key.Version)
+		t.Run(name, func(t *testing.T) {
+			raw, err := ioutil.ReadFile(filepath.Join("testdata", "schemas", name+".json"))
+			require.NoError(t, err, "every released schema needs a snapshot in testdata/schemas")
+
+			locked, err := model.ParseSchema(string(raw))
+			require.NoError(t, err)
+
+			current, err := registry.Resolve(key.EventType, key.Version)
+			require.NoError(t, err)
+
+			assert.Empty(t, model.CheckCompatibility(locked, current))
+		})
+	}
+}
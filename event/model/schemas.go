@@ -0,0 +1,174 @@
+package model
+
+// eventSchemas are the payload schemas shipped with this service. A schema must
+// never change incompatibly once released; breaking changes are published
+// under a new version of the event type instead.
+var eventSchemas = []struct {
+	EventType string
+	Version   int
+	Schema    string
+}{
+	{
+		EventType: "brand.created",
+		Version:   1,
+		Schema:    brandSchemaV1,
+	},
+	{
+		EventType: "brand.updated",
+		Version:   1,
+		Schema:    brandSchemaV1,
+	},
+	{
+		EventType: "evm.boilerplate-go.foo-bar-baz.fifo",
+		Version:   1,
+		Schema:    fooSchemaV1,
+	},
+	{
+		EventType: "order.cancelled",
+		Version:   1,
+		Schema:    orderSchemaV1,
+	},
+	{
+		EventType: "order.placed",
+		Version:   1,
+		Schema:    orderSchemaV1,
+	},
+	{
+		EventType: "order.returned",
+		Version:   1,
+		Schema:    orderSchemaV1,
+	},
+	{
+		EventType: "order.shipped",
+		Version:   1,
+		Schema:    orderSchemaV1,
+	},
+	{
+		EventType: "product.created",
+		Version:   1,
+		Schema:    productSchemaV1,
+	},
+	{
+		EventType: "product.deleted",
+		Version:   1,
+		Schema:    productSchemaV1,
+	},
+	{
+		EventType: "product.updated",
+		Version:   1,
+		Schema:    productSchemaV1,
+	},
+	{
+		EventType: "stock.changed",
+		Version:   1,
+		Schema:    stockChangedSchemaV1,
+	},
+	{
+		EventType: "variant.price_changed",
+		Version:   1,
+		Schema:    variantPriceChangedSchemaV1,
+	},
+}
+
+const brandSchemaV1 = `{
+	"type": "object",
+	"required": ["brandId", "brandName", "version", "occurredAt"],
+	"properties": {
+		"brandId": {"type": "string", "format": "uuid"},
+		"brandName": {"type": "string"},
+		"version": {"type": "integer", "minimum": 1},
+		"occurredAt": {"type": "string", "format": "date-time"}
+	}
+}`
+
+const fooSchemaV1 = `{
+	"type": "object",
+	"required": ["name", "shippingFee", "status", "items"],
+	"properties": {
+		"name": {"type": "string"},
+		"shippingFee": {"type": "number", "minimum": 0},
+		"status": {
+			"type": "string",
+			"enum": ["new", "pending", "verified", "paid", "inTransit", "delivered", "failedToDeliver"]
+		},
+		"items": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["id", "sku", "productName", "quantity", "unitPrice", "discount"],
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"sku": {"type": "string"},
+					"productName": {"type": "string"},
+					"quantity": {"type": "integer", "minimum": 1},
+					"unitPrice": {"type": "number", "minimum": 0},
+					"discount": {"type": "number", "minimum": 0}
+				}
+			}
+		}
+	}
+}`
+
+// orderSchemaV1 is the payload of the order lifecycle events published by
+// the order service.
+const orderSchemaV1 = `{
+	"type": "object",
+	"required": ["orderId", "warehouseId", "items", "occurredAt"],
+	"properties": {
+		"orderId": {"type": "string", "format": "uuid"},
+		"warehouseId": {"type": "string", "format": "uuid"},
+		"items": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"required": ["productId", "quantity"],
+				"properties": {
+					"productId": {"type": "string", "format": "uuid"},
+					"quantity": {"type": "integer", "minimum": 1}
+				}
+			}
+		},
+		"occurredAt": {"type": "string", "format": "date-time"}
+	}
+}`
+
+const productSchemaV1 = `{
+	"type": "object",
+	"required": ["productId", "productName", "variantId", "deleted", "version", "occurredAt"],
+	"properties": {
+		"productId": {"type": "string", "format": "uuid"},
+		"productName": {"type": "string"},
+		"variantId": {"type": "string", "format": "uuid"},
+		"deleted": {"type": "boolean"},
+		"version": {"type": "integer", "minimum": 1},
+		"occurredAt": {"type": "string", "format": "date-time"}
+	}
+}`
+
+const stockChangedSchemaV1 = `{
+	"type": "object",
+	"required": ["quantityId", "productId", "warehouseId", "previousQuantity", "quantity", "status", "version", "occurredAt"],
+	"properties": {
+		"quantityId": {"type": "string", "format": "uuid"},
+		"productId": {"type": "string", "format": "uuid"},
+		"warehouseId": {"type": "string", "format": "uuid"},
+		"previousQuantity": {"type": "integer"},
+		"quantity": {"type": "integer"},
+		"status": {"type": "string"},
+		"version": {"type": "integer", "minimum": 1},
+		"occurredAt": {"type": "string", "format": "date-time"}
+	}
+}`
+
+const variantPriceChangedSchemaV1 = `{
+	"type": "object",
+	"required": ["variantId", "brandId", "previousPrice", "price", "version", "occurredAt"],
+	"properties": {
+		"variantId": {"type": "string", "format": "uuid"},
+		"brandId": {"type": "string", "format": "uuid"},
+		"previousPrice": {"type": "number"},
+		"price": {"type": "number"},
+		"version": {"type": "integer", "minimum": 1},
+		"occurredAt": {"type": "string", "format": "date-time"}
+	}
+}`
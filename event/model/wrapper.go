@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -19,11 +20,28 @@ type SNSMessage struct {
 	Signature        string    `json:"Signature"`
 	SigningCertURL   string    `json:"SigningCertURL"`
 	UnsubscribeURL   string    `json:"UnsubscribeURL"`
+
+	MessageAttributes map[string]SNSMessageAttribute `json:"MessageAttributes,omitempty"`
+}
+
+// SNSMessageAttribute is a message attribute delivered along an SNS message.
+type SNSMessageAttribute struct {
+	Type  string `json:"Type"`
+	Value string `json:"Value"`
 }
 
+const (
+	// AttributeEventType is the message attribute carrying the event type.
+	AttributeEventType = "event_type"
+	// AttributeEventVersion is the message attribute carrying the version of
+	// the event's payload schema.
+	AttributeEventVersion = "event_version"
+)
+
 // EventWrapper is the wrapper object for events.
 type EventWrapper struct {
 	EventType string `json:"event_type"`
+	Version   int    `json:"version"`
 	Data      Data   `json:"data"`
 }
 
@@ -35,16 +53,27 @@ type Data struct {
 
 // NewEvent creates a new event given an event type and an arbitrary model.
 // Returns an EventWrapper object.
-func NewEvent(eventType string, model interface{}) EventWrapper {
-	value, _ := json.Marshal(model)
+func NewEvent(eventType string, model interface{}) (EventWrapper, error) {
+	return NewVersionedEvent(eventType, 1, model)
+}
+
+// NewVersionedEvent creates a new event given an event type, the version of
+// its payload schema and an arbitrary model.
+func NewVersionedEvent(eventType string, version int, model interface{}) (event EventWrapper, err error) {
+	value, err := json.Marshal(model)
+	if err != nil {
+		return
+	}
 
-	return EventWrapper{
+	event = EventWrapper{
 		EventType: eventType,
+		Version:   version,
 		Data: Data{
 			Timestamp: time.Now(),
 			Value:     value,
 		},
 	}
+	return
 }
 
 // PublishRequest is a wrapper for all message publishing requests.
@@ -54,3 +83,18 @@ type PublishRequest struct {
 	MessageGroupID *string
 	Topic          string
 }
+
+// NewPublishRequest creates a publish request for an event. The message group
+// is only set for FIFO topics, which are the only ones accepting it.
+func NewPublishRequest(topic string, event EventWrapper, messageGroupID string) PublishRequest {
+	request := PublishRequest{
+		Event: event,
+		Topic: topic,
+	}
+
+	if strings.HasSuffix(topic, ".fifo") && messageGroupID != "" {
+		request.MessageGroupID = &messageGroupID
+	}
+
+	return request
+}
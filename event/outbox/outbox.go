@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+// MessageStatus indicates the delivery status of an outbox Message.
+type MessageStatus string
+
+const (
+	// MessageStatusPending indicates a Message waiting to be published.
+	MessageStatusPending MessageStatus = "pending"
+	// MessageStatusSent indicates a Message that has been published.
+	MessageStatusSent MessageStatus = "sent"
+	// MessageStatusFailed indicates a Message that exhausted its attempts.
+	MessageStatusFailed MessageStatus = "failed"
+)
+
+// Message is an event stored in the outbox, waiting to be relayed to the
+// producer.
+type Message struct {
+	ID             uuid.UUID     `db:"entity_id"`
+	Topic          string        `db:"topic"`
+	EventType      string        `db:"event_type"`
+	MessageGroupID null.String   `db:"message_group_id"`
+	Payload        []byte        `db:"payload"`
+	Status         MessageStatus `db:"status"`
+	Attempts       int           `db:"attempts"`
+	LastError      null.String   `db:"last_error"`
+	Created        time.Time     `db:"created"`
+	NextAttempt    time.Time     `db:"next_attempt"`
+	Sent           null.Time     `db:"sent"`
+}
+
+// NewMessage creates a pending Message from a publish request.
+func NewMessage(request model.PublishRequest) (message Message, err error) {
+	payload, err := json.Marshal(request.Event)
+	if err != nil {
+		return
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	message = Message{
+		ID:          id,
+		Topic:       request.Topic,
+		EventType:   request.Event.EventType,
+		Payload:     payload,
+		Status:      MessageStatusPending,
+		Created:     now,
+		NextAttempt: now,
+	}
+	if request.MessageGroupID != nil {
+		message.MessageGroupID = null.StringFrom(*request.MessageGroupID)
+	}
+
+	return
+}
+
+// ToPublishRequest restores the publish request stored in this Message.
+func (m Message) ToPublishRequest() (request model.PublishRequest, err error) {
+	err = json.Unmarshal(m.Payload, &request.Event)
+	if err != nil {
+		return
+	}
+
+	request.Topic = m.Topic
+	request.MessageGroupID = m.MessageGroupID.Ptr()
+	return
+}
+
+// MarkSent marks this Message as published.
+func (m *Message) MarkSent() {
+	m.Status = MessageStatusSent
+	m.Sent = null.TimeFrom(time.Now())
+	m.LastError = null.String{}
+}
+
+// MarkAttemptFailed records a failed publish attempt. The Message is scheduled
+// for another attempt with exponential backoff, or marked as failed once it
+// has used up maxAttempts.
+func (m *Message) MarkAttemptFailed(err error, maxAttempts int, backoff time.Duration, maxBackoff time.Duration) {
+	m.Attempts++
+	m.LastError = null.StringFrom(err.Error())
+
+	if maxAttempts > 0 && m.Attempts >= maxAttempts {
+		m.Status = MessageStatusFailed
+		return
+	}
+
+	delay := backoff
+	for i := 1; i < m.Attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	m.NextAttempt = time.Now().Add(delay)
+}
@@ -0,0 +1,186 @@
+package outbox
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/guregu/null"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	outboxQueries = struct {
+		selectPending          string
+		selectLag              string
+		insertMessageBulk      string
+		insertMessageBulkValue string
+		updateMessage          string
+	}{
+		selectPending: `
+			SELECT
+				entity_id,
+				topic,
+				event_type,
+				message_group_id,
+				payload,
+				status,
+				attempts,
+				last_error,
+				created,
+				next_attempt,
+				sent
+			FROM outbox
+			WHERE status = 'pending' AND next_attempt <= ?
+				AND NOT EXISTS (
+					SELECT 1
+					FROM outbox held
+					WHERE held.message_group_id = outbox.message_group_id
+						AND held.status = 'pending'
+						AND held.next_attempt > ?
+						AND held.created < outbox.created)
+			ORDER BY created
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`,
+
+		selectLag: `
+			SELECT
+				COUNT(entity_id) AS pending,
+				MIN(created) AS oldest
+			FROM outbox
+			WHERE status = 'pending'`,
+
+		insertMessageBulk: `
+			INSERT INTO outbox (
+				entity_id,
+				topic,
+				event_type,
+				message_group_id,
+				payload,
+				status,
+				attempts,
+				created,
+				next_attempt
+			) VALUES `,
+
+		insertMessageBulkValue: `(?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+
+		updateMessage: `
+			UPDATE outbox
+			SET
+				status = :status,
+				attempts = :attempts,
+				last_error = :last_error,
+				next_attempt = :next_attempt,
+				sent = :sent
+			WHERE entity_id = :entity_id`,
+	}
+)
+
+// Lag describes the backlog of the outbox.
+type Lag struct {
+	Pending int64     `db:"pending"`
+	Oldest  null.Time `db:"oldest"`
+}
+
+// Repository is the repository for outbox Messages.
+type Repository interface {
+	// TxPublish stores publish requests in the outbox as part of the given
+	// transaction, so they are only relayed once the transaction commits.
+	TxPublish(tx *sqlx.Tx, requests ...model.PublishRequest) (err error)
+	TxResolvePending(tx *sqlx.Tx, limit int) (messages []Message, err error)
+	TxUpdate(tx *sqlx.Tx, message Message) (err error)
+	ResolveLag() (lag Lag, err error)
+}
+
+// RepositoryMySQL is the MySQL-backed implementation of Repository.
+type RepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideRepositoryMySQL is the provider for this repository.
+func ProvideRepositoryMySQL(db *infras.MySQLConn) *RepositoryMySQL {
+	return &RepositoryMySQL{DB: db}
+}
+
+// TxPublish stores publish requests in the outbox transactionally given the
+// *sqlx.Tx param.
+func (r *RepositoryMySQL) TxPublish(tx *sqlx.Tx, requests ...model.PublishRequest) (err error) {
+	if len(requests) == 0 {
+		return
+	}
+
+	values := make([]string, 0, len(requests))
+	args := make([]interface{}, 0, len(requests)*9)
+	for _, request := range requests {
+		message, err := NewMessage(request)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return err
+		}
+
+		values = append(values, outboxQueries.insertMessageBulkValue)
+		args = append(args,
+			message.ID.String(),
+			message.Topic,
+			message.EventType,
+			message.MessageGroupID,
+			message.Payload,
+			message.Status,
+			message.Attempts,
+			message.Created,
+			message.NextAttempt)
+	}
+
+	query := fmt.Sprintf("%v %v", outboxQueries.insertMessageBulk, strings.Join(values, ","))
+	_, err = tx.Exec(query, args...)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// TxResolvePending locks and resolves Messages that are due for publishing.
+// Rows locked by another relay are skipped, and so are the Messages queued
+// behind a Message of their group that is held for a retry.
+func (r *RepositoryMySQL) TxResolvePending(tx *sqlx.Tx, limit int) (messages []Message, err error) {
+	now := time.Now()
+	err = tx.Select(&messages, outboxQueries.selectPending, now, now, limit)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// TxUpdate updates the delivery state of a Message.
+func (r *RepositoryMySQL) TxUpdate(tx *sqlx.Tx, message Message) (err error) {
+	stmt, err := tx.PrepareNamed(outboxQueries.updateMessage)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(message)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveLag resolves the number of pending Messages and the creation time of
+// the oldest one.
+func (r *RepositoryMySQL) ResolveLag() (lag Lag, err error) {
+	err = r.DB.Read.Get(&lag, outboxQueries.selectLag)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
@@ -0,0 +1,60 @@
+package outbox_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/outbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessage(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		groupID := "group-1"
+		event, err := model.NewEvent("foo.created", map[string]string{"name": "foo"})
+		assert.NoError(t, err)
+		request := model.PublishRequest{
+			Event:          event,
+			MessageGroupID: &groupID,
+			Topic:          "arn:aws:sns:ap-southeast-1:000000000000:foo",
+		}
+
+		message, err := outbox.NewMessage(request)
+		assert.NoError(t, err)
+		assert.Equal(t, outbox.MessageStatusPending, message.Status)
+
+		restored, err := message.ToPublishRequest()
+		assert.NoError(t, err)
+		assert.Equal(t, request.Topic, restored.Topic)
+		assert.Equal(t, groupID, *restored.MessageGroupID)
+		assert.Equal(t, request.Event.EventType, restored.Event.EventType)
+		assert.Equal(t, request.Event.Version, restored.Event.Version)
+		assert.Equal(t, request.Event.Data.Value, restored.Event.Data.Value)
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		event, _ := model.NewEvent("foo.created", nil)
+		message, _ := outbox.NewMessage(model.PublishRequest{Event: event})
+		expectedDelays := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+
+		for _, expected := range expectedDelays {
+			before := time.Now()
+			message.MarkAttemptFailed(errors.New("unavailable"), 10, time.Second, 5*time.Second)
+			assert.Equal(t, outbox.MessageStatusPending, message.Status)
+			assert.WithinDuration(t, before.Add(expected), message.NextAttempt, 100*time.Millisecond)
+		}
+	})
+
+	t.Run("fails after maximum attempts", func(t *testing.T) {
+		event, _ := model.NewEvent("foo.created", nil)
+		message, _ := outbox.NewMessage(model.PublishRequest{Event: event})
+
+		message.MarkAttemptFailed(errors.New("unavailable"), 2, time.Second, time.Minute)
+		message.MarkAttemptFailed(errors.New("unavailable"), 2, time.Second, time.Minute)
+
+		assert.Equal(t, outbox.MessageStatusFailed, message.Status)
+		assert.Equal(t, "unavailable", message.LastError.String)
+	})
+}
@@ -0,0 +1,264 @@
+package outbox
+
+import (
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/producer"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+// metrics are exposed through expvar under the "outbox" key.
+var metrics = struct {
+	pending    *expvar.Int
+	lagSeconds *expvar.Float
+	published  *expvar.Int
+	retried    *expvar.Int
+	failed     *expvar.Int
+}{
+	pending:    new(expvar.Int),
+	lagSeconds: new(expvar.Float),
+	published:  new(expvar.Int),
+	retried:    new(expvar.Int),
+	failed:     new(expvar.Int),
+}
+
+func init() {
+	m := expvar.NewMap("outbox")
+	m.Set("pending", metrics.pending)
+	m.Set("lagSeconds", metrics.lagSeconds)
+	m.Set("published", metrics.published)
+	m.Set("retried", metrics.retried)
+	m.Set("failed", metrics.failed)
+}
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 50
+	defaultBackoff      = 5 * time.Second
+	defaultMaxBackoff   = 10 * time.Minute
+)
+
+// RelayConfig is the configuration of Relay, with defaults applied to the
+// values left unset.
+type RelayConfig struct {
+	Enabled      bool
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+}
+
+// NewRelayConfig reads the RelayConfig from the outbox configuration. Unset
+// or invalid values fall back to their defaults, and the maximum backoff is
+// never below the backoff.
+func NewRelayConfig(config *configs.Config) RelayConfig {
+	outboxConfig := config.Event.Outbox
+	relayConfig := RelayConfig{
+		Enabled:      outboxConfig.Enabled,
+		PollInterval: time.Duration(outboxConfig.PollIntervalMillis) * time.Millisecond,
+		BatchSize:    outboxConfig.BatchSize,
+		MaxAttempts:  outboxConfig.MaxAttempts,
+		Backoff:      time.Duration(outboxConfig.BackoffSeconds) * time.Second,
+		MaxBackoff:   time.Duration(outboxConfig.MaxBackoffSeconds) * time.Second,
+	}
+	if relayConfig.PollInterval <= 0 {
+		relayConfig.PollInterval = defaultPollInterval
+	}
+	if relayConfig.BatchSize <= 0 {
+		relayConfig.BatchSize = defaultBatchSize
+	}
+	if relayConfig.Backoff <= 0 {
+		relayConfig.Backoff = defaultBackoff
+	}
+	if relayConfig.MaxBackoff <= 0 {
+		relayConfig.MaxBackoff = defaultMaxBackoff
+	}
+	if relayConfig.MaxBackoff < relayConfig.Backoff {
+		relayConfig.MaxBackoff = relayConfig.Backoff
+	}
+
+	return relayConfig
+}
+
+// Relay polls the outbox and publishes pending Messages through the producer.
+type Relay struct {
+	Config     RelayConfig
+	DB         *infras.MySQLConn
+	Repository Repository
+	Producer   producer.Producer
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// ProvideRelay is the provider for Relay.
+func ProvideRelay(config *configs.Config, db *infras.MySQLConn, repository Repository, producer producer.Producer) *Relay {
+	return &Relay{
+		Config:     NewRelayConfig(config),
+		DB:         db,
+		Repository: repository,
+		Producer:   producer,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Start starts polling the outbox in the background.
+func (r *Relay) Start() {
+	if !r.Config.Enabled {
+		log.Info().Msg("Outbox relay is disabled.")
+		close(r.done)
+		return
+	}
+
+	log.Info().
+		Dur("pollInterval", r.Config.PollInterval).
+		Int("batchSize", r.Config.BatchSize).
+		Msg("Outbox relay will start polling.")
+
+	go r.run(r.Config.PollInterval)
+}
+
+// Stop stops polling and waits for the batch in flight to finish.
+func (r *Relay) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
+
+func (r *Relay) run(interval time.Duration) {
+	defer close(r.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			log.Info().Msg("Outbox relay stopped.")
+			return
+		case <-ticker.C:
+			// keep relaying while full batches are being drained
+			for r.relayBatch() >= r.Config.BatchSize {
+				select {
+				case <-r.stop:
+					log.Info().Msg("Outbox relay stopped.")
+					return
+				default:
+				}
+			}
+			r.updateLag()
+		}
+	}
+}
+
+// relayBatch publishes a single batch of due Messages and returns the number
+// of Messages it processed.
+func (r *Relay) relayBatch() (processed int) {
+	err := r.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		messages, err := r.Repository.TxResolvePending(tx, r.Config.BatchSize)
+		if err != nil {
+			e <- err
+			return
+		}
+
+		err = r.relayMessages(messages, func(message Message) error {
+			return r.Repository.TxUpdate(tx, message)
+		})
+		if err != nil {
+			e <- err
+			return
+		}
+
+		processed = len(messages)
+		e <- nil
+	})
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return 0
+	}
+
+	return
+}
+
+// relayMessages publishes Messages in order and updates each one published.
+// Once a Message is held for a retry, the rest of its group is left pending
+// so that the group is still published in order.
+func (r *Relay) relayMessages(messages []Message, update func(message Message) error) error {
+	held := make(map[string]bool)
+	for _, message := range messages {
+		group := message.MessageGroupID
+		if group.Valid && held[group.String] {
+			continue
+		}
+
+		r.publish(&message)
+		if group.Valid && message.Status == MessageStatusPending {
+			held[group.String] = true
+		}
+
+		if err := update(message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Relay) publish(message *Message) {
+	request, err := message.ToPublishRequest()
+	if err == nil {
+		err = r.Producer.Publish(request)
+	}
+
+	if err == nil {
+		message.MarkSent()
+		metrics.published.Add(1)
+		return
+	}
+
+	message.MarkAttemptFailed(err, r.Config.MaxAttempts, r.Config.Backoff, r.Config.MaxBackoff)
+
+	if message.Status == MessageStatusFailed {
+		metrics.failed.Add(1)
+		log.Error().
+			Err(err).
+			Str("id", message.ID.String()).
+			Str("eventType", message.EventType).
+			Int("attempts", message.Attempts).
+			Msg("failed relaying outbox message after maximum attempts, failing permanently")
+		return
+	}
+
+	metrics.retried.Add(1)
+	log.Warn().
+		Err(err).
+		Str("id", message.ID.String()).
+		Str("eventType", message.EventType).
+		Int("attempts", message.Attempts).
+		Time("nextAttempt", message.NextAttempt).
+		Msg("failed relaying outbox message, will retry")
+}
+
+func (r *Relay) updateLag() {
+	lag, err := r.Repository.ResolveLag()
+	if err != nil {
+		return
+	}
+
+	metrics.pending.Set(lag.Pending)
+	if lag.Oldest.Valid {
+		metrics.lagSeconds.Set(time.Since(lag.Oldest.Time).Seconds())
+	} else {
+		metrics.lagSeconds.Set(0)
+	}
+}
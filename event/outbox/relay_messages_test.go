@@ -0,0 +1,106 @@
+package outbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProducer fails the first publish of the given event types.
+type fakeProducer struct {
+	failing   map[string]bool
+	published []string
+}
+
+func (p *fakeProducer) Publish(request model.PublishRequest) error {
+	if p.failing[request.Event.EventType] {
+		delete(p.failing, request.Event.EventType)
+		return errors.New("broker unavailable")
+	}
+
+	p.published = append(p.published, request.Event.EventType)
+	return nil
+}
+
+func TestRelayMessages(t *testing.T) {
+	newMessage := func(eventType string, group string) Message {
+		event, err := model.NewEvent(eventType, nil)
+		require.NoError(t, err)
+
+		request := model.PublishRequest{Event: event}
+		if group != "" {
+			request.MessageGroupID = &group
+		}
+
+		message, err := NewMessage(request)
+		require.NoError(t, err)
+		return message
+	}
+
+	t.Run("holds the rest of a group while its first message awaits a retry", func(t *testing.T) {
+		producer := &fakeProducer{failing: map[string]bool{"order.created": true}}
+		relay := &Relay{
+			Config:   RelayConfig{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Minute},
+			Producer: producer,
+		}
+		messages := []Message{
+			newMessage("order.created", "order-1"),
+			newMessage("foo.created", "foo-1"),
+			newMessage("order.paid", "order-1"),
+			newMessage("bar.created", ""),
+			newMessage("order.shipped", "order-1"),
+		}
+
+		updated := make(map[string]Message)
+		err := relay.relayMessages(messages, func(message Message) error {
+			updated[message.EventType] = message
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"foo.created", "bar.created"}, producer.published)
+		assert.Len(t, updated, 3)
+		assert.Equal(t, MessageStatusPending, updated["order.created"].Status)
+		assert.Equal(t, 1, updated["order.created"].Attempts)
+		assert.Equal(t, MessageStatusSent, updated["foo.created"].Status)
+		assert.NotContains(t, updated, "order.paid")
+		assert.NotContains(t, updated, "order.shipped")
+	})
+
+	t.Run("releases a group once its first message fails permanently", func(t *testing.T) {
+		producer := &fakeProducer{failing: map[string]bool{"order.created": true}}
+		relay := &Relay{
+			Config:   RelayConfig{MaxAttempts: 1, Backoff: time.Second, MaxBackoff: time.Minute},
+			Producer: producer,
+		}
+		messages := []Message{
+			newMessage("order.created", "order-1"),
+			newMessage("order.paid", "order-1"),
+		}
+
+		err := relay.relayMessages(messages, func(message Message) error { return nil })
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"order.paid"}, producer.published)
+	})
+
+	t.Run("stops at a failed update", func(t *testing.T) {
+		producer := &fakeProducer{}
+		relay := &Relay{Producer: producer}
+		messages := []Message{
+			newMessage("order.created", "order-1"),
+			newMessage("order.paid", "order-1"),
+		}
+
+		err := relay.relayMessages(messages, func(message Message) error {
+			return errors.New("connection lost")
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, []string{"order.created"}, producer.published)
+	})
+}
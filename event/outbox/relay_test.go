@@ -0,0 +1,52 @@
+package outbox_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/outbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRelayConfig(t *testing.T) {
+	t.Run("applies defaults to unset values", func(t *testing.T) {
+		relayConfig := outbox.NewRelayConfig(&configs.Config{})
+
+		assert.Equal(t, time.Second, relayConfig.PollInterval)
+		assert.Equal(t, 50, relayConfig.BatchSize)
+		assert.Equal(t, 5*time.Second, relayConfig.Backoff)
+		assert.Equal(t, 10*time.Minute, relayConfig.MaxBackoff)
+	})
+
+	t.Run("reads configured values", func(t *testing.T) {
+		config := &configs.Config{}
+		config.Event.Outbox.PollIntervalMillis = 200
+		config.Event.Outbox.BatchSize = 10
+		config.Event.Outbox.MaxAttempts = 3
+		config.Event.Outbox.BackoffSeconds = 2
+		config.Event.Outbox.MaxBackoffSeconds = 60
+
+		relayConfig := outbox.NewRelayConfig(config)
+
+		assert.Equal(t, 200*time.Millisecond, relayConfig.PollInterval)
+		assert.Equal(t, 10, relayConfig.BatchSize)
+		assert.Equal(t, 3, relayConfig.MaxAttempts)
+		assert.Equal(t, 2*time.Second, relayConfig.Backoff)
+		assert.Equal(t, time.Minute, relayConfig.MaxBackoff)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		config := &configs.Config{}
+		config.Event.Outbox.PollIntervalMillis = -1
+		config.Event.Outbox.BatchSize = -5
+		config.Event.Outbox.BackoffSeconds = 30
+		config.Event.Outbox.MaxBackoffSeconds = 10
+
+		relayConfig := outbox.NewRelayConfig(config)
+
+		assert.Equal(t, time.Second, relayConfig.PollInterval)
+		assert.Equal(t, 50, relayConfig.BatchSize)
+		assert.Equal(t, 30*time.Second, relayConfig.MaxBackoff)
+	})
+}
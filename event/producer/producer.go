@@ -1,8 +1,26 @@
 package producer
 
-import "github.com/evermos/boilerplate-go/event/model"
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/kafka"
+	"github.com/evermos/boilerplate-go/event/memory"
+	"github.com/evermos/boilerplate-go/event/model"
+)
 
 // Producer represents an event producer interface.
 type Producer interface {
 	Publish(request model.PublishRequest) error
 }
+
+// NewProducer creates the producer of the transport selected through
+// configuration, SNS unless stated otherwise.
+func NewProducer(config *configs.Config) Producer {
+	switch config.Event.Transport {
+	case kafka.Transport:
+		return kafka.NewProducer(config)
+	case memory.Transport:
+		return memory.ProvideBroker(config)
+	default:
+		return NewSNSProducer(config)
+	}
+}
@@ -1,6 +1,8 @@
 package producer
 
 import (
+	"strconv"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -55,14 +57,37 @@ func NewSNSProducer(config *configs.Config) *SNSProducer {
 // Publish publishes a message to SNS.
 func (p *SNSProducer) Publish(request model.PublishRequest) error {
 	err := p.sendMessage(&sns.PublishInput{
-		Message:        aws.String(string(request.Event.Data.Value)),
-		MessageGroupId: request.MessageGroupID,
-		TopicArn:       &request.Topic,
+		Message:           aws.String(string(request.Event.Data.Value)),
+		MessageAttributes: createMessageAttributes(request.Event),
+		MessageGroupId:    request.MessageGroupID,
+		TopicArn:          &request.Topic,
 	})
 
 	return err
 }
 
+// createMessageAttributes carries the event type and its payload version as
+// SNS message attributes, so subscribers can route without decoding the body.
+func createMessageAttributes(event model.EventWrapper) map[string]*sns.MessageAttributeValue {
+	attributes := make(map[string]*sns.MessageAttributeValue)
+
+	if event.EventType != "" {
+		attributes[model.AttributeEventType] = &sns.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(event.EventType),
+		}
+	}
+
+	if event.Version > 0 {
+		attributes[model.AttributeEventVersion] = &sns.MessageAttributeValue{
+			DataType:    aws.String("Number"),
+			StringValue: aws.String(strconv.Itoa(event.Version)),
+		}
+	}
+
+	return attributes
+}
+
 func (p *SNSProducer) sendMessage(msg *sns.PublishInput) error {
 	resp, err := p.sns.Publish(msg)
 	if err != nil {
@@ -3,12 +3,14 @@ package producer
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
 
 	"github.com/evermos/boilerplate-go/configs"
 	"github.com/evermos/boilerplate-go/event/model"
@@ -52,9 +54,10 @@ func (s *SNSProducerV2) Publish(request model.PublishRequest) error {
 
 func (s *SNSProducerV2) publish(request model.PublishRequest) error {
 	msg := &sns.PublishInput{
-		Message:        aws.String(string(request.Event.Data.Value)),
-		MessageGroupId: request.MessageGroupID,
-		TopicArn:       &request.Topic,
+		Message:           aws.String(string(request.Event.Data.Value)),
+		MessageAttributes: createMessageAttributesV2(request.Event),
+		MessageGroupId:    request.MessageGroupID,
+		TopicArn:          &request.Topic,
 	}
 
 	resp, err := s.client.Publish(context.TODO(), msg)
@@ -86,3 +89,23 @@ func (s *SNSProducerV2) publish(request model.PublishRequest) error {
 
 	return nil
 }
+
+func createMessageAttributesV2(event model.EventWrapper) map[string]types.MessageAttributeValue {
+	attributes := make(map[string]types.MessageAttributeValue)
+
+	if event.EventType != "" {
+		attributes[model.AttributeEventType] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(event.EventType),
+		}
+	}
+
+	if event.Version > 0 {
+		attributes[model.AttributeEventVersion] = types.MessageAttributeValue{
+			DataType:    aws.String("Number"),
+			StringValue: aws.String(strconv.Itoa(event.Version)),
+		}
+	}
+
+	return attributes
+}
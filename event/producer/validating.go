@@ -0,0 +1,45 @@
+package producer
+
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/rs/zerolog/log"
+)
+
+// ValidatingProducer validates event payloads against the schema registry
+// before handing them to the underlying producer, so that no payload which
+// consumers would reject is ever published.
+type ValidatingProducer struct {
+	Producer Producer
+	Registry *model.SchemaRegistry
+}
+
+// ProvideValidatingProducer is the provider for the producer used by this
+// service, publishing through the configured transport.
+func ProvideValidatingProducer(config *configs.Config, registry *model.SchemaRegistry) *ValidatingProducer {
+	return NewValidatingProducer(registry, NewProducer(config))
+}
+
+// NewValidatingProducer wraps the given producer with schema validation.
+func NewValidatingProducer(registry *model.SchemaRegistry, producer Producer) *ValidatingProducer {
+	return &ValidatingProducer{
+		Producer: producer,
+		Registry: registry,
+	}
+}
+
+// Publish validates the event payload and publishes it.
+func (p *ValidatingProducer) Publish(request model.PublishRequest) error {
+	err := p.Registry.ValidateEvent(request.Event)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("eventType", request.Event.EventType).
+			Int("version", request.Event.Version).
+			Str("topic", request.Topic).
+			Msg("refusing to publish invalid event")
+		return err
+	}
+
+	return p.Producer.Publish(request)
+}
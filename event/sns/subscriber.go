@@ -0,0 +1,133 @@
+package sns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event"
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/rs/zerolog/log"
+)
+
+const httpTimeout = 10 * time.Second
+
+// Subscriber receives SNS messages pushed to an HTTPS subscription and
+// dispatches them to the domain consumers subscribed to their topics, as an
+// alternative to polling SQS.
+type Subscriber struct {
+	Verifier   *Verifier
+	DeadLetter consumer.DeadLetterHandler
+
+	client    *http.Client
+	processes map[string]consumer.Process
+}
+
+// ProvideSubscriber is the provider for Subscriber. It subscribes the domain
+// consumers to their configured topics.
+func ProvideSubscriber(config *configs.Config, consumers event.Consumers, deadLetter consumer.DeadLetterHandler) *Subscriber {
+	client := &http.Client{Timeout: httpTimeout}
+	s := NewSubscriber(NewVerifier(client, config.Event.Consumer.SNS.CertHosts, nil), client, deadLetter)
+
+	topics := config.Event.Consumer.SNS.Topics
+	s.Subscribe(topics.FooBarBaz.ARN, consumers.FooBarBaz.Process)
+	s.Subscribe(topics.Order.ARN, consumers.Orders.Process)
+
+	return s
+}
+
+// NewSubscriber creates a Subscriber confirming subscriptions with client.
+func NewSubscriber(verifier *Verifier, client *http.Client, deadLetter consumer.DeadLetterHandler) *Subscriber {
+	return &Subscriber{
+		Verifier:   verifier,
+		DeadLetter: deadLetter,
+		client:     client,
+		processes:  make(map[string]consumer.Process),
+	}
+}
+
+// Subscribe dispatches the messages of a topic to process. Topics without an
+// ARN are not subscribed.
+func (s *Subscriber) Subscribe(topicARN string, process consumer.Process) {
+	if topicARN != "" {
+		s.processes[topicARN] = process
+	}
+}
+
+// Receive verifies and handles a pushed message. Notifications failing
+// permanently are dead-lettered, other failures are returned so that SNS
+// delivers the message again.
+func (s *Subscriber) Receive(body []byte) error {
+	message := Message{}
+	err := json.Unmarshal(body, &message)
+	if err != nil {
+		return failure.BadRequest(err)
+	}
+
+	err = s.Verifier.Verify(message)
+	if errors.Is(err, ErrInvalidSignature) {
+		return failure.Unauthorized(err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	process, ok := s.processes[message.TopicARN]
+	if !ok {
+		return failure.BadRequestFromString(fmt.Sprintf("topic %s is not subscribed", message.TopicARN))
+	}
+
+	switch message.Type {
+	case MessageTypeSubscriptionConfirmation:
+		return s.confirm(message)
+	case MessageTypeUnsubscribeConfirmation:
+		log.Info().Str("topicArn", message.TopicARN).Msg("Unsubscribed from SNS topic.")
+		return nil
+	case MessageTypeNotification:
+		return s.dispatch(message, body, process)
+	default:
+		return failure.BadRequestFromString(fmt.Sprintf("unexpected message type %q", message.Type))
+	}
+}
+
+func (s *Subscriber) confirm(message Message) error {
+	err := s.Verifier.CheckURL(message.SubscribeURL)
+	if err != nil {
+		return failure.BadRequest(err)
+	}
+
+	resp, err := s.client.Get(message.SubscribeURL)
+	if err != nil {
+		return fmt.Errorf("failed confirming subscription: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed confirming subscription: %s", resp.Status)
+	}
+
+	log.Info().Str("topicArn", message.TopicARN).Msg("Confirmed SNS subscription.")
+	return nil
+}
+
+func (s *Subscriber) dispatch(message Message, body []byte, process consumer.Process) error {
+	err := process(body)
+	if err == nil || !consumer.IsPermanent(err) {
+		return err
+	}
+
+	return s.DeadLetter.HandleDeadLetter(consumer.DeadLetter{
+		Body:         string(body),
+		MessageID:    message.MessageID,
+		Reason:       err,
+		ReceiveCount: 1,
+		Source:       message.TopicARN,
+	})
+}
@@ -0,0 +1,105 @@
+package sns
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingDeadLetterHandler struct {
+	letters []consumer.DeadLetter
+}
+
+func (h *recordingDeadLetterHandler) HandleDeadLetter(letter consumer.DeadLetter) error {
+	h.letters = append(h.letters, letter)
+	return nil
+}
+
+func newTestSubscriber(authority *testAuthority, process consumer.Process) (*Subscriber, *recordingDeadLetterHandler) {
+	deadLetters := &recordingDeadLetterHandler{}
+	s := NewSubscriber(authority.verifier(), authority.server.Client(), deadLetters)
+	s.Subscribe(testTopicARN, process)
+	return s, deadLetters
+}
+
+func marshal(t *testing.T, message Message) []byte {
+	body, err := json.Marshal(message)
+	require.NoError(t, err)
+	return body
+}
+
+func TestSubscriberReceive(t *testing.T) {
+	authority := newTestAuthority(t)
+
+	t.Run("confirms subscriptions", func(t *testing.T) {
+		s, _ := newTestSubscriber(authority, func([]byte) error { return nil })
+		confirmation := newTestMessage()
+		confirmation.Type = MessageTypeSubscriptionConfirmation
+		confirmation.Token = "token"
+		confirmation.SubscribeURL = authority.server.URL + "/confirm"
+
+		assert.NoError(t, s.Receive(marshal(t, authority.sign(t, confirmation, "1"))))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&authority.confirmed))
+	})
+
+	t.Run("dispatches notifications to the consumer of their topic", func(t *testing.T) {
+		var received []model.SNSMessage
+		s, _ := newTestSubscriber(authority, func(body []byte) error {
+			message := model.SNSMessage{}
+			require.NoError(t, json.Unmarshal(body, &message))
+			received = append(received, message)
+			return nil
+		})
+
+		assert.NoError(t, s.Receive(marshal(t, authority.sign(t, newTestMessage(), "2"))))
+		if assert.Len(t, received, 1) {
+			assert.Equal(t, "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324", received[0].MessageID.String())
+			assert.Equal(t, `{"foo":"bar"}`, received[0].Message)
+		}
+	})
+
+	t.Run("rejects messages of topics not subscribed", func(t *testing.T) {
+		s, _ := newTestSubscriber(authority, func([]byte) error { return nil })
+		message := newTestMessage()
+		message.TopicARN = "arn:aws:sns:ap-southeast-1:000000000000:bar"
+
+		err := s.Receive(marshal(t, authority.sign(t, message, "2")))
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+	})
+
+	t.Run("rejects messages not signed by SNS", func(t *testing.T) {
+		s, _ := newTestSubscriber(authority, func([]byte) error { return nil })
+		message := authority.sign(t, newTestMessage(), "2")
+		message.TopicARN = "arn:aws:sns:ap-southeast-1:000000000000:bar"
+
+		err := s.Receive(marshal(t, message))
+		assert.Equal(t, http.StatusUnauthorized, failure.GetCode(err))
+	})
+
+	t.Run("dead-letters permanent failures", func(t *testing.T) {
+		s, deadLetters := newTestSubscriber(authority, func([]byte) error {
+			return consumer.Permanent(errors.New("malformed"))
+		})
+
+		assert.NoError(t, s.Receive(marshal(t, authority.sign(t, newTestMessage(), "2"))))
+		if assert.Len(t, deadLetters.letters, 1) {
+			assert.Equal(t, testTopicARN, deadLetters.letters[0].Source)
+			assert.Equal(t, "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324", deadLetters.letters[0].MessageID)
+		}
+	})
+
+	t.Run("fails transient failures so that SNS retries", func(t *testing.T) {
+		s, deadLetters := newTestSubscriber(authority, func([]byte) error { return errors.New("unavailable") })
+
+		assert.Error(t, s.Receive(marshal(t, authority.sign(t, newTestMessage(), "2"))))
+		assert.Empty(t, deadLetters.letters)
+	})
+}
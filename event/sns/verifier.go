@@ -0,0 +1,249 @@
+package sns
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MessageTypeNotification is the type of published messages.
+	MessageTypeNotification = "Notification"
+	// MessageTypeSubscriptionConfirmation is the type of the message sent when
+	// the endpoint is subscribed to a topic.
+	MessageTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	// MessageTypeUnsubscribeConfirmation is the type of the message sent when
+	// the endpoint is unsubscribed from a topic.
+	MessageTypeUnsubscribeConfirmation = "UnsubscribeConfirmation"
+
+	// maxCertificateSize bounds the signing certificate downloads.
+	maxCertificateSize = 64 << 10
+)
+
+// defaultHostPattern matches the SNS endpoints of every AWS region, which is
+// where SNS serves its signing certificates from.
+var defaultHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// ErrInvalidSignature is returned for messages that are not signed by SNS.
+var ErrInvalidSignature = errors.New("invalid SNS message signature")
+
+// Message is an SNS message as delivered to HTTP subscriptions. Its fields
+// are kept as sent, since the signature covers their exact values.
+type Message struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicARN         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// stringToSign builds the canonical string SNS signs for the message type.
+func (m Message) stringToSign() string {
+	var builder strings.Builder
+	add := func(key string, value string) {
+		builder.WriteString(key + "\n" + value + "\n")
+	}
+
+	add("Message", m.Message)
+	add("MessageId", m.MessageID)
+	if m.Type == MessageTypeNotification {
+		if m.Subject != "" {
+			add("Subject", m.Subject)
+		}
+		add("Timestamp", m.Timestamp)
+	} else {
+		add("SubscribeURL", m.SubscribeURL)
+		add("Timestamp", m.Timestamp)
+		add("Token", m.Token)
+	}
+	add("TopicArn", m.TopicARN)
+	add("Type", m.Type)
+
+	return builder.String()
+}
+
+// Verifier verifies that messages are signed by SNS. Signing certificates are
+// only downloaded over HTTPS from allow-listed hosts, and are cached until
+// they expire.
+type Verifier struct {
+	client *http.Client
+	hosts  map[string]bool
+	roots  *x509.CertPool
+
+	mu           sync.Mutex
+	certificates map[string]*x509.Certificate
+}
+
+// NewVerifier creates a Verifier downloading certificates with client. The
+// certificates must be served from one of hosts, or from an SNS endpoint when
+// no hosts are given, and chain to roots, or to the system roots when nil.
+func NewVerifier(client *http.Client, hosts []string, roots *x509.CertPool) *Verifier {
+	v := &Verifier{
+		client:       client,
+		hosts:        make(map[string]bool),
+		roots:        roots,
+		certificates: make(map[string]*x509.Certificate),
+	}
+	for _, host := range hosts {
+		v.hosts[strings.ToLower(host)] = true
+	}
+	return v
+}
+
+// Verify verifies the signature of a message.
+func (v *Verifier) Verify(message Message) error {
+	var hash crypto.Hash
+	switch message.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSignature, message.SignatureVersion)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	certificate, err := v.certificate(message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: signing certificate has no RSA key", ErrInvalidSignature)
+	}
+
+	err = rsa.VerifyPKCS1v15(publicKey, hash, digest(hash, message.stringToSign()), signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// CheckURL checks that a URL points to an allow-listed host over HTTPS.
+func (v *Verifier) CheckURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("%w: %s is not served over HTTPS", ErrInvalidSignature, rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	allowed := defaultHostPattern.MatchString(host)
+	if len(v.hosts) > 0 {
+		allowed = v.hosts[host]
+	}
+	if !allowed {
+		return fmt.Errorf("%w: host %s is not allowed", ErrInvalidSignature, host)
+	}
+	return nil
+}
+
+func digest(hash crypto.Hash, value string) []byte {
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(value))
+		return sum[:]
+	}
+	sum := sha256.Sum256([]byte(value))
+	return sum[:]
+}
+
+// certificate resolves the signing certificate from the cache, downloading
+// it when missing or expired.
+func (v *Verifier) certificate(certURL string) (*x509.Certificate, error) {
+	err := v.CheckURL(certURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(certURL, ".pem") {
+		return nil, fmt.Errorf("%w: %s is not a certificate", ErrInvalidSignature, certURL)
+	}
+
+	v.mu.Lock()
+	certificate, ok := v.certificates[certURL]
+	v.mu.Unlock()
+	if ok && time.Now().Before(certificate.NotAfter) {
+		return certificate, nil
+	}
+
+	certificate, err = v.download(certURL)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	v.certificates[certURL] = certificate
+	v.mu.Unlock()
+	return certificate, nil
+}
+
+func (v *Verifier) download(certURL string) (*x509.Certificate, error) {
+	resp, err := v.client.Get(certURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed downloading signing certificate: %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, maxCertificateSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed downloading signing certificate: %w", err)
+	}
+
+	// the signing certificate comes first, followed by its intermediates
+	var chain []*x509.Certificate
+	for block, rest := pem.Decode(body); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+		chain = append(chain, certificate)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%w: no certificate at %s", ErrInvalidSignature, certURL)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range chain[1:] {
+		intermediates.AddCert(certificate)
+	}
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	return chain[0], nil
+}
@@ -0,0 +1,185 @@
+package sns
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTopicARN = "arn:aws:sns:ap-southeast-1:000000000000:foo"
+
+// testAuthority is a locally generated CA issuing signing certificates, which
+// are served along other endpoints over TLS.
+type testAuthority struct {
+	server    *httptest.Server
+	roots     *x509.CertPool
+	key       *rsa.PrivateKey
+	pem       []byte
+	downloads int32
+	confirmed int32
+}
+
+func newTestAuthority(t *testing.T) *testAuthority {
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+
+	a := &testAuthority{
+		roots: x509.NewCertPool(),
+		key:   key,
+		pem:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+	a.roots.AddCert(ca)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cert.pem", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&a.downloads, 1)
+		_, _ = w.Write(a.pem)
+	})
+	mux.HandleFunc("/confirm", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&a.confirmed, 1)
+	})
+	a.server = httptest.NewTLSServer(mux)
+	t.Cleanup(a.server.Close)
+
+	return a
+}
+
+func (a *testAuthority) host() string {
+	u, _ := url.Parse(a.server.URL)
+	return u.Hostname()
+}
+
+func (a *testAuthority) verifier() *Verifier {
+	return NewVerifier(a.server.Client(), []string{a.host()}, a.roots)
+}
+
+// sign signs a message the way SNS does.
+func (a *testAuthority) sign(t *testing.T, message Message, version string) Message {
+	message.SignatureVersion = version
+	message.SigningCertURL = a.server.URL + "/cert.pem"
+
+	hash := crypto.SHA1
+	if version == "2" {
+		hash = crypto.SHA256
+	}
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.key, hash, digest(hash, message.stringToSign()))
+	require.NoError(t, err)
+	message.Signature = base64.StdEncoding.EncodeToString(signature)
+
+	return message
+}
+
+func newTestMessage() Message {
+	return Message{
+		Type:      MessageTypeNotification,
+		MessageID: "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicARN:  testTopicARN,
+		Message:   `{"foo":"bar"}`,
+		Timestamp: "2021-05-19T12:00:00.000Z",
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	authority := newTestAuthority(t)
+
+	t.Run("verifies both signature versions", func(t *testing.T) {
+		verifier := authority.verifier()
+		for _, version := range []string{"1", "2"} {
+			assert.NoError(t, verifier.Verify(authority.sign(t, newTestMessage(), version)), version)
+		}
+
+		confirmation := newTestMessage()
+		confirmation.Type = MessageTypeSubscriptionConfirmation
+		confirmation.Token = "token"
+		confirmation.SubscribeURL = authority.server.URL + "/confirm"
+		assert.NoError(t, verifier.Verify(authority.sign(t, confirmation, "2")))
+	})
+
+	t.Run("rejects tampered messages", func(t *testing.T) {
+		message := authority.sign(t, newTestMessage(), "2")
+		message.Message = `{"foo":"baz"}`
+
+		assert.True(t, errors.Is(authority.verifier().Verify(message), ErrInvalidSignature))
+	})
+
+	t.Run("rejects unsupported signature versions", func(t *testing.T) {
+		message := authority.sign(t, newTestMessage(), "2")
+		message.SignatureVersion = "3"
+
+		assert.True(t, errors.Is(authority.verifier().Verify(message), ErrInvalidSignature))
+	})
+
+	t.Run("rejects certificates from hosts not allowed", func(t *testing.T) {
+		message := authority.sign(t, newTestMessage(), "2")
+		verifier := NewVerifier(authority.server.Client(), []string{"sns.ap-southeast-1.amazonaws.com"}, authority.roots)
+
+		assert.True(t, errors.Is(verifier.Verify(message), ErrInvalidSignature))
+	})
+
+	t.Run("rejects certificates of another authority", func(t *testing.T) {
+		message := authority.sign(t, newTestMessage(), "2")
+		verifier := NewVerifier(authority.server.Client(), []string{authority.host()}, x509.NewCertPool())
+
+		assert.True(t, errors.Is(verifier.Verify(message), ErrInvalidSignature))
+	})
+
+	t.Run("caches certificates", func(t *testing.T) {
+		verifier := authority.verifier()
+		before := atomic.LoadInt32(&authority.downloads)
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, verifier.Verify(authority.sign(t, newTestMessage(), "1")))
+		}
+
+		assert.Equal(t, before+1, atomic.LoadInt32(&authority.downloads))
+	})
+}
+
+func TestVerifierCheckURL(t *testing.T) {
+	verifier := NewVerifier(http.DefaultClient, nil, nil)
+
+	assert.NoError(t, verifier.CheckURL("https://sns.ap-southeast-1.amazonaws.com/SimpleNotificationService-0000.pem"))
+	assert.NoError(t, verifier.CheckURL("https://sns.cn-north-1.amazonaws.com.cn/SimpleNotificationService-0000.pem"))
+	assert.Error(t, verifier.CheckURL("http://sns.ap-southeast-1.amazonaws.com/SimpleNotificationService-0000.pem"))
+	assert.Error(t, verifier.CheckURL("https://sns.ap-southeast-1.amazonaws.com.example.com/cert.pem"))
+	assert.Error(t, verifier.CheckURL("https://example.com/sns.ap-southeast-1.amazonaws.com/cert.pem"))
+}
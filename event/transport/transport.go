@@ -0,0 +1,46 @@
+package transport
+
+import (
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/event/deadletter"
+	"github.com/evermos/boilerplate-go/event/kafka"
+)
+
+// NewConsumer creates a consumer of the transport selected through
+// configuration, SQS unless stated otherwise. It processes messages with
+// process and hands those that cannot be processed to deadLetter.
+func NewConsumer(config *configs.Config, process consumer.Process, deadLetter consumer.DeadLetterHandler) consumer.Consumer {
+	switch config.Event.Transport {
+	case kafka.Transport:
+		kafkaConsumer := kafka.NewConsumer(config)
+		kafkaConsumer.Process = process
+		kafkaConsumer.DeadLetter = deadLetter
+		return kafkaConsumer
+	default:
+		sqsConsumer := consumer.NewSQSConsumer(config)
+		sqsConsumer.Process = process
+		sqsConsumer.DeadLetter = deadLetter
+		return sqsConsumer
+	}
+}
+
+// ProvideRequeuer is the provider for the Requeuer replaying dead-lettered
+// events through the configured transport.
+func ProvideRequeuer(config *configs.Config) deadletter.Requeuer {
+	switch config.Event.Transport {
+	case kafka.Transport:
+		return kafka.NewConsumer(config)
+	default:
+		return consumer.NewSQSConsumer(config)
+	}
+}
+
+// Topic selects the topic of the configured transport: kafkaTopic when events
+// travel through Kafka, otherwise the SNS topic ARN or SQS queue URL in aws.
+func Topic(config *configs.Config, aws string, kafkaTopic string) string {
+	if config.Event.Transport == kafka.Transport {
+		return kafkaTopic
+	}
+	return aws
+}
@@ -0,0 +1,30 @@
+package transport_test
+
+import (
+	"testing"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/kafka"
+	"github.com/evermos/boilerplate-go/event/memory"
+	"github.com/evermos/boilerplate-go/event/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopic(t *testing.T) {
+	const (
+		queueURL   = "https://sqs.ap-southeast-1.amazonaws.com/000000000000/order"
+		kafkaTopic = "order"
+	)
+
+	for transportName, expected := range map[string]string{
+		"":               queueURL,
+		"sns":            queueURL,
+		memory.Transport: queueURL,
+		kafka.Transport:  kafkaTopic,
+	} {
+		config := &configs.Config{}
+		config.Event.Transport = transportName
+
+		assert.Equal(t, expected, transport.Topic(config, queueURL, kafkaTopic), transportName)
+	}
+}
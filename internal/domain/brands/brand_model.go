@@ -1,12 +1,23 @@
 package brands
 
 import (
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
 	"time"
 )
 
+const (
+	// BrandCreatedEventType is the event type published when a brand is created.
+	BrandCreatedEventType = "brand.created"
+	// BrandUpdatedEventType is the event type published when a brand is updated.
+	BrandUpdatedEventType = "brand.updated"
+	// BrandEventVersion is the current version of the brand event payload.
+	BrandEventVersion = 1
+)
+
 type Brands struct {
 	BrandId   uuid.UUID   `db:"brandId"`
 	BrandName string      `db:"brandName"`
@@ -16,21 +27,61 @@ type Brands struct {
 	UpdatedBy nuuid.NUUID `db:"updatedBy"`
 	Deleted   null.Time   `db:"deletedAt"`
 	DeletedBy nuuid.NUUID `db:"deletedBy"`
+	Version   int64       `db:"version"`
+}
+
+// BrandEventPayload is the payload of brand events.
+type BrandEventPayload struct {
+	BrandID    uuid.UUID `json:"brandId"`
+	BrandName  string    `json:"brandName"`
+	Version    int64     `json:"version"`
+	OccurredAt time.Time `json:"occurredAt"`
 }
 
 type BrandRequestFormat struct {
 	BrandName string `json:"brandName"`
 }
 
-func (b Brands) NewFromRequestFormat(req BrandRequestFormat, brandId uuid.UUID) (newBrand Brands, err error) {
-	brandId, _ = uuid.NewV4()
+func (b Brands) NewFromRequestFormat(req BrandRequestFormat, userId uuid.UUID) (newBrand Brands, err error) {
+	brandId, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
 	newBrand = Brands{
 		BrandId:   brandId,
 		BrandName: req.BrandName,
 		CreatedAt: time.Now(),
-		CreatedBy: brandId,
+		CreatedBy: userId,
+		Version:   1,
 	}
 	brands := make([]Brands, 0)
 	brands = append(brands, newBrand)
 	return
 }
+
+func (b *Brands) Update(req BrandRequestFormat, userId uuid.UUID) (err error) {
+	b.BrandName = req.BrandName
+	b.UpdatedAt = null.TimeFrom(time.Now())
+	b.UpdatedBy = nuuid.From(userId)
+	return
+}
+
+// VerifyVersion checks that the caller is modifying the latest version of
+// this brand.
+func (b *Brands) VerifyVersion(version int64) (err error) {
+	if b.Version != version {
+		return failure.PreconditionFailed("brand", "version mismatch, resolve the latest version and retry")
+	}
+	return
+}
+
+// NewEvent creates an event of the given type carrying the current state of
+// this brand.
+func (b Brands) NewEvent(eventType string) (model.EventWrapper, error) {
+	return model.NewVersionedEvent(eventType, BrandEventVersion, BrandEventPayload{
+		BrandID:    b.BrandId,
+		BrandName:  b.BrandName,
+		Version:    b.Version,
+		OccurredAt: time.Now(),
+	})
+}
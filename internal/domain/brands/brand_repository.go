@@ -1,16 +1,20 @@
 package brands
 
 import (
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/outbox"
 	"github.com/evermos/boilerplate-go/infras"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
 	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
 	brandQueries = struct {
 		selectBrand string
 		insertBrand string
+		updateBrand string
 	}{
 		selectBrand: `
 		SELECT
@@ -21,44 +25,76 @@ var (
 			b.updatedAt,
 			b.updatedBy,
 			b.deletedAt,
-			b.deletedBy
+			b.deletedBy,
+			b.version
 		FROM
-			brands b;
+			brand b
 `,
 		insertBrand: `
 			INSERT INTO brand (
-			           brandId, brandName, createdAt,createdBy
+			           brandId, brandName, createdAt,createdBy, version
 			) VALUES (
-			          :brandId, :brandName, NOW(),:createdBy)`,
+			          :brandId, :brandName, NOW(),:createdBy, :version)`,
+		updateBrand: `
+			UPDATE brand
+			SET
+				brandName = :brandName,
+				updatedAt = :updatedAt,
+				updatedBy = :updatedBy,
+				version = version + 1
+			WHERE brandId = :brandId AND version = :version`,
 	}
 )
 
 type BrandRepository interface {
-	Create(brand Brands) (err error)
+	Create(brand Brands, events ...model.PublishRequest) (err error)
 	ExistsByID(id uuid.UUID) (exists bool, err error)
 	ResolveByID(id uuid.UUID) (brand Brands, err error)
+	Update(brand Brands, events ...model.PublishRequest) (err error)
 }
 
 type BrandRepositoryMySQL struct {
-	DB *infras.MySQLConn
+	DB     *infras.MySQLConn
+	Outbox outbox.Repository
 }
 
-func ProvideBrandRepository(db *infras.MySQLConn) *BrandRepositoryMySQL {
+func ProvideBrandRepository(db *infras.MySQLConn, outboxRepository outbox.Repository) *BrandRepositoryMySQL {
 	return &BrandRepositoryMySQL{
-		DB: db}
+		DB:     db,
+		Outbox: outboxRepository}
 }
-func (b *BrandRepositoryMySQL) Create(brand Brands) (err error) {
+
+// Create creates a new brand. The given events are written to the outbox in
+// the same transaction.
+func (b *BrandRepositoryMySQL) Create(brand Brands, events ...model.PublishRequest) (err error) {
 	exists, err := b.ExistsByID(brand.BrandId)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
 	}
 	if exists {
-		err = failure.Conflict("create", "bra d", "already exists")
+		err = failure.Conflict("create", "brand", "already exists")
 		logger.ErrorWithStack(err)
 		return
 	}
-	stmt, err := b.DB.Write.PrepareNamed(brandQueries.insertBrand)
+
+	return b.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := b.txCreate(tx, brand); err != nil {
+			e <- err
+			return
+		}
+
+		if err := b.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+func (b *BrandRepositoryMySQL) txCreate(tx *sqlx.Tx, brand Brands) (err error) {
+	stmt, err := tx.PrepareNamed(brandQueries.insertBrand)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
@@ -96,3 +132,48 @@ func (b *BrandRepositoryMySQL) ResolveByID(id uuid.UUID) (brand Brands, err erro
 	}
 	return
 }
+
+// Update updates a brand. The given events are written to the outbox in the
+// same transaction.
+func (b *BrandRepositoryMySQL) Update(brand Brands, events ...model.PublishRequest) (err error) {
+	return b.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := b.txUpdate(tx, brand); err != nil {
+			e <- err
+			return
+		}
+
+		if err := b.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+func (b *BrandRepositoryMySQL) txUpdate(tx *sqlx.Tx, brand Brands) (err error) {
+	stmt, err := tx.PrepareNamed(brandQueries.updateBrand)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(brand)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.PreconditionFailed("brand", "modified by another request")
+		logger.ErrorWithStack(err)
+	}
+	return
+}
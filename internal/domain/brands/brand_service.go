@@ -1,40 +1,55 @@
 package brands
 
 import (
+	"context"
+
 	"github.com/evermos/boilerplate-go/configs"
-	"github.com/evermos/boilerplate-go/event/producer"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/transport"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/gofrs/uuid"
 )
 
 type BrandService interface {
-	Create(requestFormat BrandRequestFormat, brandId uuid.UUID) (brand Brands, err error)
+	Create(ctx context.Context, requestFormat BrandRequestFormat) (brand Brands, err error)
 	ResolveByID(id uuid.UUID) (brand Brands, err error)
+	Update(ctx context.Context, id uuid.UUID, version int64, requestFormat BrandRequestFormat) (brand Brands, err error)
 }
 
 type BrandServiceImpl struct {
 	BrandRepository BrandRepository
-	Producer        producer.Producer
 	Config          *configs.Config
 }
 
-func ProvideBrandServiceImpl(brandRepository BrandRepository, producer producer.Producer, config *configs.Config) *BrandServiceImpl {
+func ProvideBrandServiceImpl(brandRepository BrandRepository, config *configs.Config) *BrandServiceImpl {
 	return &BrandServiceImpl{
 		BrandRepository: brandRepository,
-		Producer:        producer,
 		Config:          config,
 	}
 }
 
-func (b *BrandServiceImpl) Create(requestFormat BrandRequestFormat, brandId uuid.UUID) (brand Brands, err error) {
-	brand, err = brand.NewFromRequestFormat(requestFormat, brandId)
+func (b *BrandServiceImpl) Create(ctx context.Context, requestFormat BrandRequestFormat) (brand Brands, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	brand, err = brand.NewFromRequestFormat(requestFormat, userId)
 	if err != nil {
 		return
 	}
 	if err != nil {
 		return brand, failure.BadRequest(err)
 	}
-	err = b.BrandRepository.Create(brand)
+	events := make([]model.PublishRequest, 0)
+	if topic := b.Config.Event.Producer.SNS.Topics.BrandCreated; topic.Enabled {
+		event, err := brand.NewEvent(BrandCreatedEventType)
+		if err != nil {
+			return brand, err
+		}
+		events = append(events, model.NewPublishRequest(transport.Topic(b.Config, topic.ARN, b.Config.Event.Kafka.Topics.BrandCreated), event, brand.BrandId.String()))
+	}
+	err = b.BrandRepository.Create(brand, events...)
 	if err != nil {
 		return
 	}
@@ -48,3 +63,48 @@ func (b *BrandServiceImpl) ResolveByID(id uuid.UUID) (brand Brands, err error) {
 	}
 	return
 }
+
+func (b *BrandServiceImpl) Update(ctx context.Context, id uuid.UUID, version int64, requestFormat BrandRequestFormat) (brand Brands, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	brand, err = b.ResolveByID(id)
+	if err != nil {
+		return
+	}
+	err = brand.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+	err = brand.Update(requestFormat, userId)
+	if err != nil {
+		return
+	}
+	events, err := b.updatedEvents(brand)
+	if err != nil {
+		return
+	}
+	err = b.BrandRepository.Update(brand, events...)
+	if err != nil {
+		return
+	}
+	brand.Version++
+	return
+}
+
+// updatedEvents builds the events for an update, carrying the version the
+// brand will have once the update is committed.
+func (b *BrandServiceImpl) updatedEvents(brand Brands) (events []model.PublishRequest, err error) {
+	topic := b.Config.Event.Producer.SNS.Topics.BrandUpdated
+	if !topic.Enabled {
+		return
+	}
+	brand.Version++
+	event, err := brand.NewEvent(BrandUpdatedEventType)
+	if err != nil {
+		return
+	}
+	events = append(events, model.NewPublishRequest(transport.Topic(b.Config, topic.ARN, b.Config.Event.Kafka.Topics.BrandUpdated), event, brand.BrandId.String()))
+	return
+}
@@ -55,6 +55,7 @@ type Foo struct {
 	UpdatedBy     nuuid.NUUID `db:"updated_by"`
 	Deleted       null.Time   `db:"deleted"`
 	DeletedBy     nuuid.NUUID `db:"deleted_by"`
+	Version       int64       `db:"version" validate:"required,min=1"`
 	Items         []FooItem   `db:"-" validate:"required,dive,required"`
 }
 
@@ -88,6 +89,7 @@ func (f Foo) NewFromRequestFormat(req FooRequestFormat, userID uuid.UUID) (newFo
 		Status:      req.Status,
 		Created:     time.Now(),
 		CreatedBy:   userID,
+		Version:     1,
 	}
 
 	items := make([]FooItem, 0)
@@ -151,6 +153,7 @@ func (f Foo) ToResponseFormat() FooResponseFormat {
 		UpdatedBy:     f.UpdatedBy.Ptr(),
 		Deleted:       f.Deleted,
 		DeletedBy:     f.DeletedBy.Ptr(),
+		Version:       f.Version,
 		Items:         make([]FooItemResponseFormat, 0),
 	}
 
@@ -234,6 +237,15 @@ func (f *Foo) UpdateStatus(newStatus FooStatus) (err error) {
 	return nil
 }
 
+// VerifyVersion checks that the caller is modifying the latest version of
+// this Foo.
+func (f *Foo) VerifyVersion(version int64) (err error) {
+	if f.Version != version {
+		return failure.PreconditionFailed("foo", "version mismatch, resolve the latest version and retry")
+	}
+	return
+}
+
 // Validate validates the entity.
 func (f *Foo) Validate() (err error) {
 	validator := shared.GetValidator()
@@ -264,6 +276,7 @@ type FooResponseFormat struct {
 	UpdatedBy     *uuid.UUID              `json:"updatedBy,omitempty"`
 	Deleted       null.Time               `json:"deleted,omitempty"`
 	DeletedBy     *uuid.UUID              `json:"deletedBy,omitempty"`
+	Version       int64                   `json:"version"`
 	Items         []FooItemResponseFormat `json:"items"`
 }
 
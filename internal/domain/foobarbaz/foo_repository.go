@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/outbox"
 	"github.com/evermos/boilerplate-go/infras"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
@@ -38,7 +40,8 @@ var (
 				foo.updated,
 				foo.updated_by,
 				foo.deleted,
-				foo.deleted_by
+				foo.deleted_by,
+				foo.version
 			FROM foo `,
 
 		selectFooItem: `
@@ -69,7 +72,8 @@ var (
 				updated,
 				updated_by,
 				deleted,
-				deleted_by
+				deleted_by,
+				version
 			) VALUES (
 				:entity_id,
 				:name,
@@ -84,7 +88,8 @@ var (
 				:updated,
 				:updated_by,
 				:deleted,
-				:deleted_by)`,
+				:deleted_by,
+				:version)`,
 
 		insertFooItemBulk: `
 			INSERT INTO foo_item (
@@ -125,14 +130,15 @@ var (
 				updated = :updated,
 				updated_by = :updated_by,
 				deleted = :deleted,
-				deleted_by = :deleted_by
-			WHERE entity_id = :entity_id `,
+				deleted_by = :deleted_by,
+				version = version + 1
+			WHERE entity_id = :entity_id AND version = :version `,
 	}
 )
 
 // FooRepository is the repository for Foo data.
 type FooRepository interface {
-	Create(foo Foo) (err error)
+	Create(foo Foo, events ...model.PublishRequest) (err error)
 	ExistsByID(id uuid.UUID) (exists bool, err error)
 	ResolveByID(id uuid.UUID) (foo Foo, err error)
 	ResolveItemsByFooIDs(ids []uuid.UUID) (fooItems []FooItem, err error)
@@ -141,18 +147,21 @@ type FooRepository interface {
 
 // FooRepositoryMySQL is the MySQL-backed implementation of FooRepository.
 type FooRepositoryMySQL struct {
-	DB *infras.MySQLConn
+	DB     *infras.MySQLConn
+	Outbox outbox.Repository
 }
 
 // ProvideFooRepositoryMySQL is the provider for this repository.
-func ProvideFooRepositoryMySQL(db *infras.MySQLConn) *FooRepositoryMySQL {
+func ProvideFooRepositoryMySQL(db *infras.MySQLConn, outboxRepository outbox.Repository) *FooRepositoryMySQL {
 	s := new(FooRepositoryMySQL)
 	s.DB = db
+	s.Outbox = outboxRepository
 	return s
 }
 
-// Create creates a new Foo.
-func (r *FooRepositoryMySQL) Create(foo Foo) (err error) {
+// Create creates a new Foo. The given events are written to the outbox in the
+// same transaction.
+func (r *FooRepositoryMySQL) Create(foo Foo, events ...model.PublishRequest) (err error) {
 	exists, err := r.ExistsByID(foo.ID)
 	if err != nil {
 		logger.ErrorWithStack(err)
@@ -176,6 +185,11 @@ func (r *FooRepositoryMySQL) Create(foo Foo) (err error) {
 			return
 		}
 
+		if err := r.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
 		e <- nil
 	})
 }
@@ -228,7 +242,8 @@ func (r *FooRepositoryMySQL) ResolveItemsByFooIDs(ids []uuid.UUID) (fooItems []F
 	return
 }
 
-// Update updates a Foo.
+// Update updates a Foo. The update only succeeds when the stored version still
+// matches the Foo's version.
 func (r *FooRepositoryMySQL) Update(foo Foo) (err error) {
 	exists, err := r.ExistsByID(foo.ID)
 	if err != nil {
@@ -352,9 +367,21 @@ func (r *FooRepositoryMySQL) txUpdate(tx *sqlx.Tx, foo Foo) (err error) {
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(foo)
+	result, err := stmt.Exec(foo)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
 	if err != nil {
 		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.PreconditionFailed("foo", "modified by another request")
+		logger.ErrorWithStack(err)
 	}
 
 	return
@@ -3,63 +3,69 @@ package foobarbaz
 //go:generate go run github.com/golang/mock/mockgen -source foo_service.go -destination mock/foo_service_mock.go -package foobarbaz_mock
 
 import (
+	"context"
+
 	"github.com/evermos/boilerplate-go/configs"
 	"github.com/evermos/boilerplate-go/event/model"
-	"github.com/evermos/boilerplate-go/event/producer"
+	"github.com/evermos/boilerplate-go/event/transport"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/gofrs/uuid"
 )
 
 // FooService is the service interface for Foo entities.
 type FooService interface {
-	Create(requestFormat FooRequestFormat, userID uuid.UUID) (foo Foo, err error)
+	Create(ctx context.Context, requestFormat FooRequestFormat) (foo Foo, err error)
 	ResolveByID(id uuid.UUID, withItems bool) (foo Foo, err error)
-	SoftDelete(id uuid.UUID, userID uuid.UUID) (foo Foo, err error)
-	Update(id uuid.UUID, requestFormat FooRequestFormat, userID uuid.UUID) (foo Foo, err error)
+	SoftDelete(ctx context.Context, id uuid.UUID, version int64) (foo Foo, err error)
+	Update(ctx context.Context, id uuid.UUID, version int64, requestFormat FooRequestFormat) (foo Foo, err error)
 }
 
 // FooServiceImpl is the service implementation for Foo entities.
 type FooServiceImpl struct {
 	FooRepository FooRepository
-	Producer      producer.Producer
 	Config        *configs.Config
 }
 
 // ProvideFooServiceImpl is the provider for this service.
-func ProvideFooServiceImpl(fooRepository FooRepository, producer producer.Producer, config *configs.Config) *FooServiceImpl {
+func ProvideFooServiceImpl(fooRepository FooRepository, config *configs.Config) *FooServiceImpl {
 	s := new(FooServiceImpl)
 	s.FooRepository = fooRepository
 	s.Config = config
-	s.Producer = producer
 
 	return s
 }
 
 // Create creates a new Foo.
-func (s *FooServiceImpl) Create(requestFormat FooRequestFormat, userID uuid.UUID) (foo Foo, err error) {
-	foo, err = foo.NewFromRequestFormat(requestFormat, userID)
+func (s *FooServiceImpl) Create(ctx context.Context, requestFormat FooRequestFormat) (foo Foo, err error) {
+	userID, err := oauth.ActorFromContext(ctx)
 	if err != nil {
 		return
 	}
 
+	foo, err = foo.NewFromRequestFormat(requestFormat, userID)
 	if err != nil {
-		return foo, failure.BadRequest(err)
+		return
 	}
 
-	err = s.FooRepository.Create(foo)
-
 	if err != nil {
-		return
+		return foo, failure.BadRequest(err)
 	}
 
+	// the event is relayed from the outbox once the Foo is committed
+	events := make([]model.PublishRequest, 0)
 	if s.Config.Event.Producer.SNS.Topics.FooCreated.Enabled {
-		e := model.NewEvent(FooBarBazEventType, requestFormat)
-		s.Producer.Publish(model.PublishRequest{
+		e, err := model.NewEvent(FooBarBazEventType, requestFormat)
+		if err != nil {
+			return foo, err
+		}
+		events = append(events, model.PublishRequest{
 			Event: e,
-			Topic: s.Config.Event.Producer.SNS.Topics.FooCreated.ARN,
+			Topic: transport.Topic(s.Config, s.Config.Event.Producer.SNS.Topics.FooCreated.ARN, s.Config.Event.Kafka.Topics.FooCreated),
 		})
 	}
 
+	err = s.FooRepository.Create(foo, events...)
 	return
 }
 
@@ -84,12 +90,23 @@ func (s *FooServiceImpl) ResolveByID(id uuid.UUID, withItems bool) (foo Foo, err
 }
 
 // SoftDelete marks a Foo as deleted by setting its `deleted` and `deletedBy` properties.
-func (s *FooServiceImpl) SoftDelete(id uuid.UUID, userID uuid.UUID) (foo Foo, err error) {
+// The given version must match the Foo's current version.
+func (s *FooServiceImpl) SoftDelete(ctx context.Context, id uuid.UUID, version int64) (foo Foo, err error) {
+	userID, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+
 	foo, err = s.FooRepository.ResolveByID(id)
 	if err != nil {
 		return
 	}
 
+	err = foo.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+
 	// need to get the items so they don't get deleted
 	items, err := s.FooRepository.ResolveItemsByFooIDs([]uuid.UUID{foo.ID})
 	if err != nil {
@@ -104,21 +121,41 @@ func (s *FooServiceImpl) SoftDelete(id uuid.UUID, userID uuid.UUID) (foo Foo, er
 	}
 
 	err = s.FooRepository.Update(foo)
+	if err != nil {
+		return
+	}
+
+	foo.Version++
 	return
 }
 
-// Update updates a Foo.
-func (s *FooServiceImpl) Update(id uuid.UUID, requestFormat FooRequestFormat, userID uuid.UUID) (foo Foo, err error) {
+// Update updates a Foo. The given version must match the Foo's current version.
+func (s *FooServiceImpl) Update(ctx context.Context, id uuid.UUID, version int64, requestFormat FooRequestFormat) (foo Foo, err error) {
+	userID, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+
 	foo, err = s.FooRepository.ResolveByID(id)
 	if err != nil {
 		return
 	}
 
+	err = foo.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+
 	err = foo.Update(requestFormat, userID)
 	if err != nil {
 		return
 	}
 
 	err = s.FooRepository.Update(foo)
+	if err != nil {
+		return
+	}
+
+	foo.Version++
 	return
 }
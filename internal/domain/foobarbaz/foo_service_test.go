@@ -1,12 +1,17 @@
 package foobarbaz_test
 
 import (
+	"context"
+
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/evermos/boilerplate-go/internal/domain/foobarbaz"
 	foobarbaz_mock "github.com/evermos/boilerplate-go/internal/domain/foobarbaz/mock"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/gofrs/uuid"
 	"github.com/golang/mock/gomock"
 	"github.com/guregu/null"
@@ -101,4 +106,70 @@ func TestFooService(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("update", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			version   int64
+			setupMock func(*foobarbaz_mock.MockFooRepository, foobarbaz.Foo)
+			code      int
+		}{
+			{
+				name:    "stale version",
+				version: 1,
+				setupMock: func(mockRepo *foobarbaz_mock.MockFooRepository, ent foobarbaz.Foo) {
+					mockRepo.EXPECT().ResolveByID(ent.ID).Return(ent, nil)
+				},
+				code: http.StatusPreconditionFailed,
+			},
+			{
+				name:    "concurrent update",
+				version: 2,
+				setupMock: func(mockRepo *foobarbaz_mock.MockFooRepository, ent foobarbaz.Foo) {
+					mockRepo.EXPECT().ResolveByID(ent.ID).Return(ent, nil)
+					mockRepo.EXPECT().Update(gomock.Any()).Return(failure.PreconditionFailed("foo", "modified by another request"))
+				},
+				code: http.StatusPreconditionFailed,
+			},
+		}
+
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				mockRepo := foobarbaz_mock.NewMockFooRepository(ctrl)
+				s := &foobarbaz.FooServiceImpl{
+					FooRepository: mockRepo,
+				}
+				ent := foobarbaz.Foo{
+					ID:        uuidFromString("4e80c5bf-b79b-4c90-8f91-82647f439e55"),
+					Name:      "The First Foo",
+					Status:    foobarbaz.FooStatusNew,
+					Created:   time.Now(),
+					CreatedBy: getRandomUUID(),
+					Version:   2,
+				}
+				test.setupMock(mockRepo, ent)
+				ctx := oauth.WithPrincipal(context.Background(), oauth.Principal{UserID: nuuid.From(getRandomUUID())})
+				_, err := s.Update(ctx, ent.ID, test.version, foobarbaz.FooRequestFormat{
+					Name:        "The First Foo",
+					ShippingFee: 15000,
+					Status:      foobarbaz.FooStatusNew,
+					Items: []foobarbaz.FooItemRequestFormat{
+						{
+							ID:          getRandomUUID(),
+							SKU:         "SKU-00001",
+							ProductName: "Product Name 1",
+							Quantity:    2,
+							UnitPrice:   10000,
+							Discount:    1200,
+						},
+					},
+				})
+
+				assert.Equal(t, test.code, failure.GetCode(err))
+			})
+		}
+	})
 }
@@ -2,7 +2,7 @@ package products
 
 import (
 	"encoding/json"
-	"fmt"
+	"github.com/evermos/boilerplate-go/event/model"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
@@ -10,6 +10,20 @@ import (
 	"time"
 )
 
+const (
+	// ProductCreatedEventType is the event type published when a product is
+	// created.
+	ProductCreatedEventType = "product.created"
+	// ProductUpdatedEventType is the event type published when a product is
+	// updated.
+	ProductUpdatedEventType = "product.updated"
+	// ProductDeletedEventType is the event type published when a product is
+	// deleted.
+	ProductDeletedEventType = "product.deleted"
+	// ProductEventVersion is the current version of the product event payload.
+	ProductEventVersion = 1
+)
+
 type Product struct {
 	ProductId   uuid.UUID   `db:"productId"`
 	ProductName string      `db:"productName"`
@@ -27,6 +41,17 @@ type Product struct {
 	UpdatedBy   nuuid.NUUID `db:"updatedBy"`
 	Deleted     null.Time   `db:"deletedAt"`
 	DeletedBy   nuuid.NUUID `db:"deletedBy"`
+	Version     int64       `db:"version"`
+}
+
+// ProductEventPayload is the payload of product events.
+type ProductEventPayload struct {
+	ProductID   uuid.UUID `json:"productId"`
+	ProductName string    `json:"productName"`
+	VariantID   uuid.UUID `json:"variantId"`
+	Deleted     bool      `json:"deleted"`
+	Version     int64     `json:"version"`
+	OccurredAt  time.Time `json:"occurredAt"`
 }
 
 type ProductSearchParams struct {
@@ -67,51 +92,81 @@ type ProductResponseFormat struct {
 	UpdatedBy   *uuid.UUID `json:"updatedBy,omitempty"`
 	Deleted     null.Time  `json:"deleted,omitempty"`
 	DeletedBy   *uuid.UUID `json:"deletedBy,omitempty"`
+	Version     int64      `json:"version"`
 }
 
 func (p Product) MarshalJSON() ([]byte, error) {
 	return json.Marshal(p.ToResponseFormat())
 }
 
-func (p *Product) SoftDelete(id uuid.UUID) (err error) {
+func (p *Product) SoftDelete(userID uuid.UUID) (err error) {
 	if p.IsDeleted() {
-		return failure.Conflict("softDelete", "foo", "already marked as deleted")
+		return failure.Conflict("softDelete", "product", "already marked as deleted")
 	}
 
 	p.Deleted = null.TimeFrom(time.Now())
-	p.DeletedBy = nuuid.From(id)
+	p.DeletedBy = nuuid.From(userID)
 	return
 }
+
+// NewEvent creates an event of the given type carrying the current state of
+// this product.
+func (p Product) NewEvent(eventType string) (model.EventWrapper, error) {
+	return model.NewVersionedEvent(eventType, ProductEventVersion, ProductEventPayload{
+		ProductID:   p.ProductId,
+		ProductName: p.ProductName,
+		VariantID:   p.VariantId,
+		Deleted:     p.IsDeleted(),
+		Version:     p.Version,
+		OccurredAt:  time.Now(),
+	})
+}
+
 func (p *Product) IsDeleted() (deleted bool) {
 	return p.Deleted.Valid && p.DeletedBy.Valid
 }
 
-func (p *Product) Update(id uuid.UUID) (err error) {
-	fmt.Println(id)
-	return err
+func (p *Product) Update(req ProductRequestFormat, userID uuid.UUID) (err error) {
+	p.ProductName = req.ProductName
+	p.VariantId = req.VariantId
+	p.UpdatedAt = null.TimeFrom(time.Now())
+	p.UpdatedBy = nuuid.From(userID)
+	return
 }
-func (p Product) NewFromRequestFormat(req ProductRequestFormat, productID uuid.UUID) (newProduct Product, err error) {
-	productID, _ = uuid.NewV4()
+
+// VerifyVersion checks that the caller is modifying the latest version of
+// this Product.
+func (p *Product) VerifyVersion(version int64) (err error) {
+	if p.Version != version {
+		return failure.PreconditionFailed("product", "version mismatch, resolve the latest version and retry")
+	}
+	return
+}
+
+func (p Product) NewFromRequestFormat(req ProductRequestFormat, userID uuid.UUID) (newProduct Product, err error) {
+	productID, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
 	newProduct = Product{
 		ProductId:   productID,
 		ProductName: req.ProductName,
 		VariantId:   req.VariantId,
 		CreatedAt:   time.Now(),
-		CreatedBy:   productID,
+		CreatedBy:   userID,
+		Version:     1,
 	}
-	products := make([]Product, 0)
-	products = append(products, newProduct)
 	return
 }
 
-func (p Image) NewFromRequestFormat(format ImageRequestFormat, productId uuid.UUID) (newImage Image) {
+func (p Image) NewFromRequestFormat(format ImageRequestFormat, productId uuid.UUID, userID uuid.UUID) (newImage Image) {
 	imageId, _ := uuid.NewV4()
 	newImage = Image{
 		ImageId:   imageId,
 		ProductId: productId,
 		ImageURL:  format.ImageURL,
 		CreatedAt: time.Now(),
-		CreatedBy: imageId,
+		CreatedBy: userID,
 	}
 
 	return
@@ -139,6 +194,9 @@ func (p *Product) ToResponseFormat() ProductResponseFormat {
 		Created:     p.CreatedAt,
 		CreatedBy:   p.CreatedBy,
 		Updated:     p.UpdatedAt,
+		UpdatedBy:   p.UpdatedBy.Ptr(),
 		Deleted:     p.Deleted,
+		DeletedBy:   p.DeletedBy.Ptr(),
+		Version:     p.Version,
 	}
 }
@@ -3,6 +3,8 @@ package products
 import (
 	"database/sql"
 	"fmt"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/outbox"
 	"github.com/evermos/boilerplate-go/infras"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
@@ -19,7 +21,19 @@ var (
 		insertImagePlaceholder string
 		updateProduct          string
 	}{
-		selectProduct: ``,
+		selectProduct: `
+			SELECT
+				p.productId,
+				p.productName,
+				p.variantId,
+				p.createdAt,
+				p.createdBy,
+				p.updatedAt,
+				p.updatedBy,
+				p.deletedAt,
+				p.deletedBy,
+				p.version
+			FROM products p`,
 		selectProducts: `SELECT
     b.brandName,
     p.productName,
@@ -54,14 +68,16 @@ ORDER BY
                       variantId,
                       createdAt,
                       createdBy,
-			          updatedAt
+			          updatedAt,
+			          version
 			) VALUES (
 			          :productId,
 			          :productName,
 			          :variantId,
 			          :createdAt,
 			          :createdBy,
-			          :updatedAt)`,
+			          :updatedAt,
+			          :version)`,
 		insertImage: `
 			INSERT INTO images (
 			          imageId, 
@@ -77,19 +93,22 @@ ORDER BY
 					:createdAt,
 					:createdBy)`,
 		updateProduct: `
-		UPDATE Products
+		UPDATE products
 		SET 
-		    product_name = :product_name, 
+		    productName = :productName, 
 		    variantId = :variantId,
-		    updatedAt = NOW(),
-		    updatedBy = :updatedBy
-		WHERE productId = :productId`,
+		    updatedAt = :updatedAt,
+		    updatedBy = :updatedBy,
+		    deletedAt = :deletedAt,
+		    deletedBy = :deletedBy,
+		    version = version + 1
+		WHERE productId = :productId AND version = :version`,
 	}
 )
 
 type ProductRepository interface {
-	CreateProduct(product Product) error
-	UpdateProduct(product Product) error
+	CreateProduct(product Product, events ...model.PublishRequest) error
+	UpdateProduct(product Product, events ...model.PublishRequest) error
 	HardDeleteProduct(productID string) error
 	ListProducts() ([]Product, error)
 	SearchProducts(params ProductSearchParams) ([]Product, error)
@@ -100,14 +119,17 @@ type ProductRepository interface {
 }
 
 type ProductRepositoryMySQL struct {
-	DB *infras.MySQLConn
+	DB     *infras.MySQLConn
+	Outbox outbox.Repository
 }
 
-func ProvideProductRepositoryMySQL(db *infras.MySQLConn) *ProductRepositoryMySQL {
-	return &ProductRepositoryMySQL{DB: db}
+func ProvideProductRepositoryMySQL(db *infras.MySQLConn, outboxRepository outbox.Repository) *ProductRepositoryMySQL {
+	return &ProductRepositoryMySQL{DB: db, Outbox: outboxRepository}
 }
 
-func (p *ProductRepositoryMySQL) CreateProduct(product Product) error {
+// CreateProduct creates a new product. The given events are written to the
+// outbox in the same transaction.
+func (p *ProductRepositoryMySQL) CreateProduct(product Product, events ...model.PublishRequest) error {
 	exists, err := p.ExistsByID(product.ProductId)
 	if err != nil {
 		logger.ErrorWithStack(err)
@@ -125,10 +147,18 @@ func (p *ProductRepositoryMySQL) CreateProduct(product Product) error {
 			return
 		}
 
+		if err := p.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
 		e <- nil
 	})
 }
-func (p *ProductRepositoryMySQL) UpdateProduct(product Product) error {
+
+// UpdateProduct updates a product, including its soft deletion. The given
+// events are written to the outbox in the same transaction.
+func (p *ProductRepositoryMySQL) UpdateProduct(product Product, events ...model.PublishRequest) error {
 	exists, err := p.ExistsByID(product.ProductId)
 	if err != nil {
 		logger.ErrorWithStack(err)
@@ -136,7 +166,7 @@ func (p *ProductRepositoryMySQL) UpdateProduct(product Product) error {
 	}
 
 	if !exists {
-		err = failure.NotFound("foo")
+		err = failure.NotFound("product")
 		logger.ErrorWithStack(err)
 		return err
 	}
@@ -146,6 +176,11 @@ func (p *ProductRepositoryMySQL) UpdateProduct(product Product) error {
 			return
 		}
 
+		if err := p.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
 		e <- nil
 	})
 }
@@ -250,7 +285,7 @@ func (p *ProductRepositoryMySQL) PaginateProducts(page, pageSize int) ([]Product
 func (p *ProductRepositoryMySQL) ResolveByID(id uuid.UUID) (product Product, err error) {
 	err = p.DB.Read.Get(
 		&product,
-		productQueries.selectProduct+" WHERE foo.entity_id = ?",
+		productQueries.selectProduct+" WHERE p.productId = ?",
 		id.String())
 	if err != nil && err == sql.ErrNoRows {
 		err = failure.NotFound("product")
@@ -262,7 +297,7 @@ func (p *ProductRepositoryMySQL) ResolveByID(id uuid.UUID) (product Product, err
 func (r *ProductRepositoryMySQL) ExistsByID(id uuid.UUID) (exists bool, err error) {
 	err = r.DB.Read.Get(
 		&exists,
-		"SELECT COUNT(entity_id) FROM product p WHERE p.entity_id = ?",
+		"SELECT COUNT(productId) FROM products p WHERE p.productId = ?",
 		id.String())
 	if err != nil {
 		logger.ErrorWithStack(err)
@@ -298,9 +333,21 @@ func (r *ProductRepositoryMySQL) txUpdate(tx *sqlx.Tx, product Product) (err err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(product)
+	result, err := stmt.Exec(product)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
 	if err != nil {
 		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.PreconditionFailed("product", "modified by another request")
+		logger.ErrorWithStack(err)
 	}
 
 	return
@@ -1,14 +1,21 @@
 package products
 
 import (
+	"context"
+
 	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/transport"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/gofrs/uuid"
 )
 
 type ProductService interface {
-	Create(requestFormat ProductRequestFormat, variantID uuid.UUID) (product Product, err error)
-	Update(id uuid.UUID) (product Product, err error)
+	Create(ctx context.Context, requestFormat ProductRequestFormat) (product Product, err error)
+	ResolveByID(id uuid.UUID) (product Product, err error)
+	Update(ctx context.Context, id uuid.UUID, version int64, requestFormat ProductRequestFormat) (product Product, err error)
+	SoftDelete(ctx context.Context, id uuid.UUID, version int64) (product Product, err error)
 	SearchProducts(params ProductSearchParams) ([]Product, error)
 }
 
@@ -21,8 +28,12 @@ func ProvideProductServiceImpl(productRepository ProductRepository, config *conf
 	return &ProductServiceImpl{ProductRepository: productRepository, Config: config}
 }
 
-func (p *ProductServiceImpl) Create(requestFormat ProductRequestFormat, productID uuid.UUID) (product Product, err error) {
-	product, err = product.NewFromRequestFormat(requestFormat, productID)
+func (p *ProductServiceImpl) Create(ctx context.Context, requestFormat ProductRequestFormat) (product Product, err error) {
+	userID, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	product, err = product.NewFromRequestFormat(requestFormat, userID)
 	if err != nil {
 		return
 	}
@@ -30,23 +41,107 @@ func (p *ProductServiceImpl) Create(requestFormat ProductRequestFormat, productI
 		return product, failure.BadRequest(err)
 	}
 
-	err = p.ProductRepository.CreateProduct(product)
+	events := make([]model.PublishRequest, 0)
+	if topic := p.Config.Event.Producer.SNS.Topics.ProductCreated; topic.Enabled {
+		event, err := product.NewEvent(ProductCreatedEventType)
+		if err != nil {
+			return product, err
+		}
+		events = append(events, model.NewPublishRequest(transport.Topic(p.Config, topic.ARN, p.Config.Event.Kafka.Topics.ProductCreated), event, product.ProductId.String()))
+	}
+
+	err = p.ProductRepository.CreateProduct(product, events...)
 	if err != nil {
 		return
 	}
 	return
 }
 
-func (p *ProductServiceImpl) Update(id uuid.UUID) (product Product, err error) {
+func (p *ProductServiceImpl) ResolveByID(id uuid.UUID) (product Product, err error) {
 	product, err = p.ProductRepository.ResolveByID(id)
 	if err != nil {
 		return
 	}
-	err = product.Update(id)
+	if product.IsDeleted() {
+		return product, failure.NotFound("product")
+	}
+	return
+}
+
+func (p *ProductServiceImpl) Update(ctx context.Context, id uuid.UUID, version int64, requestFormat ProductRequestFormat) (product Product, err error) {
+	userID, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	product, err = p.ResolveByID(id)
+	if err != nil {
+		return
+	}
+	err = product.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+	err = product.Update(requestFormat, userID)
+	if err != nil {
+		return
+	}
+	events, err := p.changedEvents(product, ProductUpdatedEventType)
+	if err != nil {
+		return
+	}
+	err = p.ProductRepository.UpdateProduct(product, events...)
+	if err != nil {
+		return
+	}
+	product.Version++
+	return
+}
+
+func (p *ProductServiceImpl) SoftDelete(ctx context.Context, id uuid.UUID, version int64) (product Product, err error) {
+	userID, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	product, err = p.ResolveByID(id)
+	if err != nil {
+		return
+	}
+	err = product.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+	err = product.SoftDelete(userID)
+	if err != nil {
+		return
+	}
+	events, err := p.changedEvents(product, ProductDeletedEventType)
+	if err != nil {
+		return
+	}
+	err = p.ProductRepository.UpdateProduct(product, events...)
+	if err != nil {
+		return
+	}
+	product.Version++
+	return
+}
+
+// changedEvents builds the events for a change of the product, carrying the
+// version it will have once the change is committed.
+func (p *ProductServiceImpl) changedEvents(product Product, eventType string) (events []model.PublishRequest, err error) {
+	topic, kafkaTopic := p.Config.Event.Producer.SNS.Topics.ProductUpdated, p.Config.Event.Kafka.Topics.ProductUpdated
+	if eventType == ProductDeletedEventType {
+		topic, kafkaTopic = p.Config.Event.Producer.SNS.Topics.ProductDeleted, p.Config.Event.Kafka.Topics.ProductDeleted
+	}
+	if !topic.Enabled {
+		return
+	}
+	product.Version++
+	event, err := product.NewEvent(eventType)
 	if err != nil {
 		return
 	}
-	err = p.ProductRepository.UpdateProduct(product)
+	events = append(events, model.NewPublishRequest(transport.Topic(p.Config, topic.ARN, kafkaTopic), event, product.ProductId.String()))
 	return
 }
 
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
@@ -18,6 +19,7 @@ type User struct {
 	UpdatedBy nuuid.NUUID `db:"updatedBy"`
 	Deleted   null.Time   `db:"deletedAt"`
 	DeletedBy nuuid.NUUID `db:"deletedBy"`
+	Version   int64       `db:"version"`
 }
 
 type UserRequestFormat struct {
@@ -27,16 +29,36 @@ type UserRequestFormat struct {
 }
 
 func (u User) NewFromRequestFormat(req UserRequestFormat, userId uuid.UUID) (newUser User, err error) {
-	userId, _ = uuid.NewV4()
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
 	newUser = User{
-		ID:        userId,
+		ID:        id,
 		Username:  req.Username,
 		Email:     req.Email,
 		UserType:  req.UserType,
 		CreatedAt: time.Now(),
 		CreatedBy: userId,
+		Version:   1,
+	}
+	return
+}
+
+func (u *User) Update(req UserRequestFormat, userId uuid.UUID) (err error) {
+	u.Username = req.Username
+	u.Email = req.Email
+	u.UserType = req.UserType
+	u.UpdatedAt = null.TimeFrom(time.Now())
+	u.UpdatedBy = nuuid.From(userId)
+	return
+}
+
+// VerifyVersion checks that the caller is modifying the latest version of
+// this User.
+func (u *User) VerifyVersion(version int64) (err error) {
+	if u.Version != version {
+		return failure.PreconditionFailed("user", "version mismatch, resolve the latest version and retry")
 	}
-	users := make([]User, 0)
-	users = append(users, newUser)
 	return
 }
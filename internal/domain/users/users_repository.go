@@ -23,24 +23,27 @@ var (
 			    u.username,
 			    u.email,
 			    u.userType,
-			    u.created,
-				u.created_by,
-				u.updated,
-				u.updated_by,
-				u.deleted,
-				u.deleted_by
+			    u.createdAt,
+				u.createdBy,
+				u.updatedAt,
+				u.updatedBy,
+				u.deletedAt,
+				u.deletedBy,
+				u.version
 			FROM user u`,
-		insertUser: `INSERT INTO user (userId,username, email, userType, createdAt, createdBy)
-		VALUE (:userId,:username, :email, :userType, :createdAt, :createdBy)`,
+		insertUser: `INSERT INTO user (userId,username, email, userType, createdAt, createdBy, version)
+		VALUE (:userId,:username, :email, :userType, :createdAt, :createdBy, :version)`,
 
 		updateUser: `
 			UPDATE user
             SET 
                 username = :username,
+                email = :email,
                 userType = :userType, 
                 updatedAt = :updatedAt, 
-                updatedBy = :updatedBy
-            WHERE userId = :userId`,
+                updatedBy = :updatedBy,
+                version = version + 1
+            WHERE userId = :userId AND version = :version`,
 	}
 )
 
@@ -98,10 +101,11 @@ func (u *UserRepositoryMysql) ResolveByID(id uuid.UUID) (user User, err error) {
 		&user.UpdatedBy,
 		&user.Deleted,
 		&user.DeletedBy,
+		&user.Version,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return User{}, fmt.Errorf("user not found: %w", err)
+			return User{}, failure.NotFound("user")
 		}
 		return User{}, fmt.Errorf("failed to retrieve user: %w", err)
 	}
@@ -120,5 +124,40 @@ func (r *UserRepositoryMysql) ExistsByID(id uuid.UUID) (exists bool, err error)
 	return
 }
 func (u *UserRepositoryMysql) Update(user User) (err error) {
-	return err
+	exists, err := u.ExistsByID(user.ID)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if !exists {
+		err = failure.NotFound("user")
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	stmt, err := u.DB.Write.PrepareNamed(userQueries.updateUser)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(user)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.PreconditionFailed("user", "modified by another request")
+		logger.ErrorWithStack(err)
+	}
+	return
 }
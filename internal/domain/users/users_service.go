@@ -1,14 +1,19 @@
 package users
 
 import (
+	"context"
+
 	"github.com/evermos/boilerplate-go/configs"
 	"github.com/evermos/boilerplate-go/event/producer"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/gofrs/uuid"
 )
 
 type UserService interface {
-	Create(requestFormat UserRequestFormat, userId uuid.UUID) (user User, err error)
+	Create(ctx context.Context, requestFormat UserRequestFormat) (user User, err error)
+	ResolveByID(id uuid.UUID) (user User, err error)
+	Update(ctx context.Context, id uuid.UUID, version int64, requestFormat UserRequestFormat) (user User, err error)
 }
 
 type UserSerivceImpl struct {
@@ -21,7 +26,11 @@ func ProvideUserServiceImpl(userRepository UserRepository, producer producer.Pro
 	return &UserSerivceImpl{UserRepository: userRepository, Producer: producer, Config: config}
 }
 
-func (u *UserSerivceImpl) Create(requestFormat UserRequestFormat, userId uuid.UUID) (user User, err error) {
+func (u *UserSerivceImpl) Create(ctx context.Context, requestFormat UserRequestFormat) (user User, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
 	user, err = user.NewFromRequestFormat(requestFormat, userId)
 	if err != nil {
 		return
@@ -35,3 +44,32 @@ func (u *UserSerivceImpl) Create(requestFormat UserRequestFormat, userId uuid.UU
 	}
 	return
 }
+
+func (u *UserSerivceImpl) ResolveByID(id uuid.UUID) (user User, err error) {
+	return u.UserRepository.ResolveByID(id)
+}
+
+func (u *UserSerivceImpl) Update(ctx context.Context, id uuid.UUID, version int64, requestFormat UserRequestFormat) (user User, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	user, err = u.UserRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+	err = user.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+	err = user.Update(requestFormat, userId)
+	if err != nil {
+		return
+	}
+	err = u.UserRepository.Update(user)
+	if err != nil {
+		return
+	}
+	user.Version++
+	return
+}
@@ -1,12 +1,23 @@
 package variants
 
 import (
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
 	"time"
 )
 
+const (
+	// VariantPriceChangedEventType is the event type published when the price
+	// of a variant changes.
+	VariantPriceChangedEventType = "variant.price_changed"
+	// VariantPriceChangedEventVersion is the current version of the
+	// variant.price_changed payload.
+	VariantPriceChangedEventVersion = 1
+)
+
 type Variants struct {
 	VariantId   uuid.UUID   `db:"variantId"`
 	VariantName string      `db:"variantName"`
@@ -18,6 +29,17 @@ type Variants struct {
 	UpdatedBy   nuuid.NUUID `db:"updatedBy"`
 	Deleted     null.Time   `db:"deletedAt"`
 	DeletedBy   nuuid.NUUID `db:"deletedBy"`
+	Version     int64       `db:"version"`
+}
+
+// VariantPriceChangedPayload is the payload of variant.price_changed events.
+type VariantPriceChangedPayload struct {
+	VariantID     uuid.UUID `json:"variantId"`
+	BrandID       uuid.UUID `json:"brandId"`
+	PreviousPrice float64   `json:"previousPrice"`
+	Price         float64   `json:"price"`
+	Version       int64     `json:"version"`
+	OccurredAt    time.Time `json:"occurredAt"`
 }
 
 type VariantRequestFormat struct {
@@ -26,17 +48,50 @@ type VariantRequestFormat struct {
 	Price       float64   `json:"price"`
 }
 
-func (v Variants) NewFromRequestFormat(req VariantRequestFormat, variantId uuid.UUID) (newVariant Variants, err error) {
-	variantId, _ = uuid.NewV4()
+func (v Variants) NewFromRequestFormat(req VariantRequestFormat, userId uuid.UUID) (newVariant Variants, err error) {
+	variantId, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
 	newVariant = Variants{
 		VariantId:   variantId,
 		VariantName: req.VariantName,
 		BrandId:     req.BrandId,
 		Price:       req.Price,
 		CreatedAt:   time.Now(),
-		CreatedBy:   variantId,
+		CreatedBy:   userId,
+		Version:     1,
+	}
+	return
+}
+
+func (v *Variants) Update(req VariantRequestFormat, userId uuid.UUID) (err error) {
+	v.VariantName = req.VariantName
+	v.BrandId = req.BrandId
+	v.Price = req.Price
+	v.UpdatedAt = null.TimeFrom(time.Now())
+	v.UpdatedBy = nuuid.From(userId)
+	return
+}
+
+// VerifyVersion checks that the caller is modifying the latest version of
+// this variant.
+func (v *Variants) VerifyVersion(version int64) (err error) {
+	if v.Version != version {
+		return failure.PreconditionFailed("variant", "version mismatch, resolve the latest version and retry")
 	}
-	variants := make([]Variants, 0)
-	variants = append(variants, newVariant)
 	return
 }
+
+// NewPriceChangedEvent creates a variant.price_changed event from the given
+// previous price to the current price of this variant.
+func (v Variants) NewPriceChangedEvent(previousPrice float64) (model.EventWrapper, error) {
+	return model.NewVersionedEvent(VariantPriceChangedEventType, VariantPriceChangedEventVersion, VariantPriceChangedPayload{
+		VariantID:     v.VariantId,
+		BrandID:       v.BrandId,
+		PreviousPrice: previousPrice,
+		Price:         v.Price,
+		Version:       v.Version,
+		OccurredAt:    time.Now(),
+	})
+}
@@ -1,39 +1,69 @@
 package variants
 
 import (
+	"database/sql"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/outbox"
 	"github.com/evermos/boilerplate-go/infras"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
 	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
 	variantsQueries = struct {
 		selectVariants string
 		insertVariants string
+		updateVariants string
 	}{
 		selectVariants: `
 			SELECT
+				v.variantId,
+				v.variantName,
+				v.brandId,
+				v.price,
+				v.createdAt,
+				v.createdBy,
+				v.updatedAt,
+				v.updatedBy,
+				v.deletedAt,
+				v.deletedBy,
+				v.version
 			FROM variant v`,
 		insertVariants: `INSERT INTO variant 
-				(variantId, variantName, brandId, price, createdAt, createdBy)
+				(variantId, variantName, brandId, price, createdAt, createdBy, version)
 				VALUES
-				(:variantId, :variantName, :brandId, :price, NOW(), :createdBy)`,
+				(:variantId, :variantName, :brandId, :price, NOW(), :createdBy, :version)`,
+		updateVariants: `UPDATE variant
+				SET
+					variantName = :variantName,
+					brandId = :brandId,
+					price = :price,
+					updatedAt = :updatedAt,
+					updatedBy = :updatedBy,
+					version = version + 1
+				WHERE variantId = :variantId AND version = :version`,
 	}
 )
 
 type VariantRepository interface {
 	Create(variants Variants) (err error)
 	ExistsByID(id uuid.UUID) (exists bool, err error)
+	ResolveByID(id uuid.UUID) (variant Variants, err error)
+	Update(variants Variants, events ...model.PublishRequest) (err error)
 }
 
 type VariantRepositoryMySQL struct {
-	DB *infras.MySQLConn
+	DB     *infras.MySQLConn
+	Outbox outbox.Repository
 }
 
-func ProvideVariantRepositoryMySQl(db *infras.MySQLConn) *VariantRepositoryMySQL {
+func ProvideVariantRepositoryMySQl(db *infras.MySQLConn, outboxRepository outbox.Repository) *VariantRepositoryMySQL {
 	return &VariantRepositoryMySQL{
-		DB: db,
+		DB:     db,
+		Outbox: outboxRepository,
 	}
 }
 
@@ -72,3 +102,61 @@ func (v *VariantRepositoryMySQL) ExistsByID(id uuid.UUID) (exists bool, err erro
 
 	return
 }
+
+func (v *VariantRepositoryMySQL) ResolveByID(id uuid.UUID) (variant Variants, err error) {
+	err = v.DB.Read.Get(
+		&variant,
+		variantsQueries.selectVariants+" WHERE v.variantId = ?",
+		id.String())
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("variant")
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+// Update updates a variant. The given events are written to the outbox in the
+// same transaction.
+func (v *VariantRepositoryMySQL) Update(variants Variants, events ...model.PublishRequest) (err error) {
+	return v.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := v.txUpdate(tx, variants); err != nil {
+			e <- err
+			return
+		}
+
+		if err := v.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+func (v *VariantRepositoryMySQL) txUpdate(tx *sqlx.Tx, variants Variants) (err error) {
+	stmt, err := tx.PrepareNamed(variantsQueries.updateVariants)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(variants)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.PreconditionFailed("variant", "modified by another request")
+		logger.ErrorWithStack(err)
+	}
+	return
+}
@@ -1,32 +1,40 @@
 package variants
 
 import (
+	"context"
+
 	"github.com/evermos/boilerplate-go/configs"
-	"github.com/evermos/boilerplate-go/event/producer"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/transport"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/gofrs/uuid"
 )
 
 type VariantService interface {
-	Create(requestFormat VariantRequestFormat, varintId uuid.UUID) (variant Variants, err error)
+	Create(ctx context.Context, requestFormat VariantRequestFormat) (variant Variants, err error)
+	ResolveByID(id uuid.UUID) (variant Variants, err error)
+	Update(ctx context.Context, id uuid.UUID, version int64, requestFormat VariantRequestFormat) (variant Variants, err error)
 }
 
 type VariantServiceImpl struct {
 	VariantRepository VariantRepository
-	Producer          producer.Producer
 	Config            *configs.Config
 }
 
-func ProvideVariantServiceImpl(variantRepository VariantRepository, producer producer.Producer, config *configs.Config) *VariantServiceImpl {
+func ProvideVariantServiceImpl(variantRepository VariantRepository, config *configs.Config) *VariantServiceImpl {
 	return &VariantServiceImpl{
 		VariantRepository: variantRepository,
-		Producer:          producer,
 		Config:            config,
 	}
 }
 
-func (v *VariantServiceImpl) Create(requestFormat VariantRequestFormat, variantId uuid.UUID) (variant Variants, err error) {
-	variant, err = variant.NewFromRequestFormat(requestFormat, variantId)
+func (v *VariantServiceImpl) Create(ctx context.Context, requestFormat VariantRequestFormat) (variant Variants, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	variant, err = variant.NewFromRequestFormat(requestFormat, userId)
 	if err != nil {
 		return
 	}
@@ -39,3 +47,53 @@ func (v *VariantServiceImpl) Create(requestFormat VariantRequestFormat, variantI
 	}
 	return
 }
+
+func (v *VariantServiceImpl) ResolveByID(id uuid.UUID) (variant Variants, err error) {
+	return v.VariantRepository.ResolveByID(id)
+}
+
+func (v *VariantServiceImpl) Update(ctx context.Context, id uuid.UUID, version int64, requestFormat VariantRequestFormat) (variant Variants, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	variant, err = v.VariantRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+	err = variant.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+	previousPrice := variant.Price
+	err = variant.Update(requestFormat, userId)
+	if err != nil {
+		return
+	}
+	events, err := v.priceChangedEvents(variant, previousPrice)
+	if err != nil {
+		return
+	}
+	err = v.VariantRepository.Update(variant, events...)
+	if err != nil {
+		return
+	}
+	variant.Version++
+	return
+}
+
+// priceChangedEvents builds the events for an update that changed the price,
+// carrying the version the variant will have once the update is committed.
+func (v *VariantServiceImpl) priceChangedEvents(variant Variants, previousPrice float64) (events []model.PublishRequest, err error) {
+	topic := v.Config.Event.Producer.SNS.Topics.VariantPriceChanged
+	if !topic.Enabled || variant.Price == previousPrice {
+		return
+	}
+	variant.Version++
+	event, err := variant.NewPriceChangedEvent(previousPrice)
+	if err != nil {
+		return
+	}
+	events = append(events, model.NewPublishRequest(transport.Topic(v.Config, topic.ARN, v.Config.Event.Kafka.Topics.VariantPriceChanged), event, variant.VariantId.String()))
+	return
+}
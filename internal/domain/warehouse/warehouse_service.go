@@ -1,33 +1,47 @@
 package warehouse
 
+//go:generate go run github.com/golang/mock/mockgen -source warehouse_service.go -destination mock/warehouse_service_mock.go -package warehouse_mock
+
 import (
+	"context"
+
 	"github.com/evermos/boilerplate-go/configs"
-	"github.com/evermos/boilerplate-go/event/producer"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/transport"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 type WarehouseService interface {
-	Create(requestFormat WarehouseRequestFormat, warehouseId uuid.UUID) (warehouse Warehouses, err error)
-	CreateQuantity(requestFormat QuantityRequestFormat, quantityId uuid.UUID) (quantity Quantity, err error)
+	Create(ctx context.Context, requestFormat WarehouseRequestFormat) (warehouse Warehouses, err error)
+	CreateQuantity(ctx context.Context, requestFormat QuantityRequestFormat) (quantity Quantity, err error)
+	MoveStock(ctx context.Context, request StockMovementRequest) (quantities []Quantity, err error)
+	ResolveByID(id uuid.UUID) (warehouse Warehouses, err error)
+	ResolveQuantityByID(id uuid.UUID) (quantity Quantity, err error)
+	Update(ctx context.Context, id uuid.UUID, version int64, requestFormat WarehouseRequestFormat) (warehouse Warehouses, err error)
+	UpdateQuantity(ctx context.Context, id uuid.UUID, version int64, requestFormat QuantityRequestFormat) (quantity Quantity, err error)
 }
 
 type WarehouseServiceImpl struct {
 	WarehouseRepository WarehouseRepository
-	Producer            producer.Producer
 	Config              *configs.Config
 }
 
-func ProvideWarehouseServiceImpl(werehouseRepository WarehouseRepository, producer producer.Producer, config *configs.Config) *WarehouseServiceImpl {
+func ProvideWarehouseServiceImpl(werehouseRepository WarehouseRepository, config *configs.Config) *WarehouseServiceImpl {
 	return &WarehouseServiceImpl{
 		WarehouseRepository: werehouseRepository,
-		Producer:            producer,
 		Config:              config,
 	}
 }
 
-func (w *WarehouseServiceImpl) Create(requestFormat WarehouseRequestFormat, warehouseId uuid.UUID) (warehouse Warehouses, err error) {
-	warehouse, err = warehouse.NewFromRequestFormat(requestFormat, warehouseId)
+func (w *WarehouseServiceImpl) Create(ctx context.Context, requestFormat WarehouseRequestFormat) (warehouse Warehouses, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	warehouse, err = warehouse.NewFromRequestFormat(requestFormat, userId)
 	if err != nil {
 		return
 	}
@@ -41,17 +55,186 @@ func (w *WarehouseServiceImpl) Create(requestFormat WarehouseRequestFormat, ware
 	return
 }
 
-func (w *WarehouseServiceImpl) CreateQuantity(requestFormat QuantityRequestFormat, quantityId uuid.UUID) (quantity Quantity, err error) {
-	quantity, err = quantity.NewFromRequestFormat(requestFormat, quantityId)
+func (w *WarehouseServiceImpl) CreateQuantity(ctx context.Context, requestFormat QuantityRequestFormat) (quantity Quantity, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	quantity, err = quantity.NewFromRequestFormat(requestFormat, userId)
 	if err != nil {
 		return
 	}
 	if err != nil {
 		return quantity, failure.BadRequest(err)
 	}
-	err = w.WarehouseRepository.CreateQuantity(quantity)
+	events, err := w.stockChangedEvents(quantity, 0)
+	if err != nil {
+		return
+	}
+	err = w.WarehouseRepository.CreateQuantity(quantity, events...)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MoveStock applies the stock movements requested by an order message. It is
+// idempotent: the movements of a message that was applied already are
+// skipped, and no quantities are returned.
+func (w *WarehouseServiceImpl) MoveStock(ctx context.Context, request StockMovementRequest) (quantities []Quantity, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	applied, err := w.WarehouseRepository.ExistsStockMovementByMessageID(request.MessageID)
+	if err != nil {
+		return
+	}
+	if applied {
+		log.Info().Str("messageId", request.MessageID).Msg("stock movement already applied, skipping")
+		return nil, nil
+	}
+
+	var movements []StockMovement
+	var events []model.PublishRequest
+	for _, item := range mergeStockMovementItems(request.Items) {
+		quantity, err := w.WarehouseRepository.ResolveQuantityByProductID(item.ProductID, request.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+
+		previousQuantity := quantity.Quantity
+		err = quantity.Move(request.Type, item.Quantity, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		movement, err := quantity.NewStockMovement(request, item.Quantity)
+		if err != nil {
+			return nil, err
+		}
+
+		if quantity.Quantity != previousQuantity {
+			// the event carries the version the quantity will have once committed
+			committed := quantity
+			committed.Version++
+			changedEvents, err := w.stockChangedEvents(committed, previousQuantity)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, changedEvents...)
+		}
+
+		movements = append(movements, movement)
+		quantities = append(quantities, quantity)
+	}
+
+	err = w.WarehouseRepository.MoveStock(movements, quantities, events...)
+	if err == ErrStockMovementApplied {
+		log.Info().Str("messageId", request.MessageID).Msg("stock movement applied concurrently, skipping")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range quantities {
+		quantities[i].Version++
+	}
+	return
+}
+
+// mergeStockMovementItems sums the items of the same product, so that each
+// quantity is moved once.
+func mergeStockMovementItems(items []StockMovementItem) (merged []StockMovementItem) {
+	index := make(map[uuid.UUID]int)
+	for _, item := range items {
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+	return
+}
+
+func (w *WarehouseServiceImpl) ResolveByID(id uuid.UUID) (warehouse Warehouses, err error) {
+	return w.WarehouseRepository.ResolveByID(id)
+}
+
+func (w *WarehouseServiceImpl) ResolveQuantityByID(id uuid.UUID) (quantity Quantity, err error) {
+	return w.WarehouseRepository.ResolveQuantityByID(id)
+}
+
+func (w *WarehouseServiceImpl) Update(ctx context.Context, id uuid.UUID, version int64, requestFormat WarehouseRequestFormat) (warehouse Warehouses, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	warehouse, err = w.WarehouseRepository.ResolveByID(id)
+	if err != nil {
+		return
+	}
+	err = warehouse.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+	err = warehouse.Update(requestFormat, userId)
+	if err != nil {
+		return
+	}
+	err = w.WarehouseRepository.Update(warehouse)
+	if err != nil {
+		return
+	}
+	warehouse.Version++
+	return
+}
+
+func (w *WarehouseServiceImpl) UpdateQuantity(ctx context.Context, id uuid.UUID, version int64, requestFormat QuantityRequestFormat) (quantity Quantity, err error) {
+	userId, err := oauth.ActorFromContext(ctx)
+	if err != nil {
+		return
+	}
+	quantity, err = w.WarehouseRepository.ResolveQuantityByID(id)
+	if err != nil {
+		return
+	}
+	err = quantity.VerifyVersion(version)
+	if err != nil {
+		return
+	}
+	previousQuantity := quantity.Quantity
+	err = quantity.Update(requestFormat, userId)
+	if err != nil {
+		return
+	}
+	// the event carries the version the quantity will have once committed
+	committed := quantity
+	committed.Version++
+	events, err := w.stockChangedEvents(committed, previousQuantity)
+	if err != nil {
+		return
+	}
+	err = w.WarehouseRepository.UpdateQuantity(quantity, events...)
+	if err != nil {
+		return
+	}
+	quantity.Version++
+	return
+}
+
+func (w *WarehouseServiceImpl) stockChangedEvents(quantity Quantity, previousQuantity int) (events []model.PublishRequest, err error) {
+	topic := w.Config.Event.Producer.SNS.Topics.StockChanged
+	if !topic.Enabled {
+		return
+	}
+	event, err := quantity.NewStockChangedEvent(previousQuantity)
 	if err != nil {
 		return
 	}
+	events = append(events, model.NewPublishRequest(transport.Topic(w.Config, topic.ARN, w.Config.Event.Kafka.Topics.StockChanged), event, quantity.ProductId.String()))
 	return
 }
@@ -0,0 +1,160 @@
+package warehouse_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/internal/domain/warehouse"
+	warehouse_mock "github.com/evermos/boilerplate-go/internal/domain/warehouse/mock"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/gofrs/uuid"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func getRandomUUID() uuid.UUID {
+	id, _ := uuid.NewV4()
+	return id
+}
+
+func TestWarehouseServiceMoveStock(t *testing.T) {
+	ctx := oauth.WithPrincipal(context.Background(), oauth.SystemPrincipal())
+	warehouseID := getRandomUUID()
+	productID := getRandomUUID()
+
+	newQuantity := func(quantity int, reserved int) warehouse.Quantity {
+		return warehouse.Quantity{
+			QuantityId:  getRandomUUID(),
+			ProductId:   productID,
+			WarehouseId: warehouseID,
+			Quantity:    quantity,
+			Reserved:    reserved,
+			Version:     3,
+		}
+	}
+
+	newRequest := func(movementType warehouse.StockMovementType, amounts ...int) warehouse.StockMovementRequest {
+		request := warehouse.StockMovementRequest{
+			MessageID:   getRandomUUID().String(),
+			OrderID:     getRandomUUID(),
+			WarehouseID: warehouseID,
+			Type:        movementType,
+		}
+		for _, amount := range amounts {
+			request.Items = append(request.Items, warehouse.StockMovementItem{ProductID: productID, Quantity: amount})
+		}
+		return request
+	}
+
+	newService := func(t *testing.T) (*warehouse.WarehouseServiceImpl, *warehouse_mock.MockWarehouseRepository) {
+		config := &configs.Config{}
+		config.Event.Producer.SNS.Topics.StockChanged.Enabled = true
+		config.Event.Producer.SNS.Topics.StockChanged.ARN = "stock-changed"
+
+		mockRepo := warehouse_mock.NewMockWarehouseRepository(gomock.NewController(t))
+		return warehouse.ProvideWarehouseServiceImpl(mockRepo, config), mockRepo
+	}
+
+	t.Run("movements", func(t *testing.T) {
+		tests := []struct {
+			name             string
+			movementType     warehouse.StockMovementType
+			quantity         int
+			reserved         int
+			expectedQuantity int
+			expectedReserved int
+		}{
+			{name: "reserve", movementType: warehouse.StockMovementReserve, quantity: 10, reserved: 2, expectedQuantity: 10, expectedReserved: 5},
+			{name: "release", movementType: warehouse.StockMovementRelease, quantity: 10, reserved: 5, expectedQuantity: 10, expectedReserved: 2},
+			{name: "deduct", movementType: warehouse.StockMovementDeduct, quantity: 10, reserved: 5, expectedQuantity: 7, expectedReserved: 2},
+			{name: "restock", movementType: warehouse.StockMovementRestock, quantity: 10, reserved: 0, expectedQuantity: 13, expectedReserved: 0},
+		}
+
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				service, mockRepo := newService(t)
+				request := newRequest(tc.movementType, 1, 2)
+				quantity := newQuantity(tc.quantity, tc.reserved)
+
+				mockRepo.EXPECT().ExistsStockMovementByMessageID(request.MessageID).Return(false, nil)
+				mockRepo.EXPECT().ResolveQuantityByProductID(productID, warehouseID).Return(quantity, nil)
+				mockRepo.EXPECT().
+					MoveStock(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(movements []warehouse.StockMovement, quantities []warehouse.Quantity, events ...model.PublishRequest) error {
+						// items of the same product are moved once
+						if assert.Len(t, movements, 1) {
+							assert.Equal(t, request.MessageID, movements[0].MessageID)
+							assert.Equal(t, quantity.QuantityId, movements[0].QuantityID)
+							assert.Equal(t, 3, movements[0].Quantity)
+						}
+						// the quantity records who moved it
+						if assert.Len(t, quantities, 1) {
+							assert.Equal(t, nuuid.From(oauth.SystemActorID), quantities[0].UpdatedBy)
+						}
+						// only changes to the quantity in the warehouse are published
+						if tc.expectedQuantity != tc.quantity {
+							assert.Len(t, events, 1)
+						} else {
+							assert.Empty(t, events)
+						}
+						return nil
+					})
+
+				quantities, err := service.MoveStock(ctx, request)
+				assert.NoError(t, err)
+				if assert.Len(t, quantities, 1) {
+					assert.Equal(t, tc.expectedQuantity, quantities[0].Quantity)
+					assert.Equal(t, tc.expectedReserved, quantities[0].Reserved)
+					assert.Equal(t, int64(4), quantities[0].Version)
+				}
+			})
+		}
+	})
+
+	t.Run("skips messages applied already", func(t *testing.T) {
+		service, mockRepo := newService(t)
+		request := newRequest(warehouse.StockMovementDeduct, 1)
+
+		mockRepo.EXPECT().ExistsStockMovementByMessageID(request.MessageID).Return(true, nil)
+
+		quantities, err := service.MoveStock(ctx, request)
+		assert.NoError(t, err)
+		assert.Empty(t, quantities)
+	})
+
+	t.Run("skips messages applied concurrently", func(t *testing.T) {
+		service, mockRepo := newService(t)
+		request := newRequest(warehouse.StockMovementDeduct, 1)
+
+		mockRepo.EXPECT().ExistsStockMovementByMessageID(request.MessageID).Return(false, nil)
+		mockRepo.EXPECT().ResolveQuantityByProductID(productID, warehouseID).Return(newQuantity(10, 1), nil)
+		mockRepo.EXPECT().MoveStock(gomock.Any(), gomock.Any(), gomock.Any()).Return(warehouse.ErrStockMovementApplied)
+
+		quantities, err := service.MoveStock(ctx, request)
+		assert.NoError(t, err)
+		assert.Empty(t, quantities)
+	})
+
+	t.Run("requires an actor", func(t *testing.T) {
+		service, _ := newService(t)
+
+		_, err := service.MoveStock(context.Background(), newRequest(warehouse.StockMovementDeduct, 1))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects reserving more than available", func(t *testing.T) {
+		service, mockRepo := newService(t)
+		request := newRequest(warehouse.StockMovementReserve, 4)
+
+		mockRepo.EXPECT().ExistsStockMovementByMessageID(request.MessageID).Return(false, nil)
+		mockRepo.EXPECT().ResolveQuantityByProductID(productID, warehouseID).Return(newQuantity(10, 7), nil)
+
+		_, err := service.MoveStock(ctx, request)
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+	})
+}
@@ -1,12 +1,41 @@
 package warehouse
 
 import (
+	"fmt"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/nuuid"
 	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
 	"time"
 )
 
+const (
+	// StockChangedEventType is the event type published when the quantity of
+	// a product in a warehouse changes.
+	StockChangedEventType = "stock.changed"
+	// StockChangedEventVersion is the current version of the stock.changed
+	// payload.
+	StockChangedEventVersion = 1
+)
+
+// StockMovementType is the kind of change an order makes to stock.
+type StockMovementType string
+
+const (
+	// StockMovementReserve sets stock aside for a placed order.
+	StockMovementReserve StockMovementType = "reserve"
+	// StockMovementRelease gives the stock of a cancelled order back.
+	StockMovementRelease StockMovementType = "release"
+	// StockMovementDeduct takes the reserved stock of a shipped order out of
+	// the warehouse.
+	StockMovementDeduct StockMovementType = "deduct"
+	// StockMovementRestock puts the stock of a returned order back into the
+	// warehouse.
+	StockMovementRestock StockMovementType = "restock"
+)
+
 type Warehouses struct {
 	WarehouseId   uuid.UUID   `db:"warehouseId"`
 	WarehouseName string      `db:"warehouseName"`
@@ -16,18 +45,63 @@ type Warehouses struct {
 	UpdatedBy     nuuid.NUUID `db:"updatedBy"`
 	Deleted       null.Time   `db:"deletedAt"`
 	DeletedBy     nuuid.NUUID `db:"deletedBy"`
+	Version       int64       `db:"version"`
 }
 
 type Quantity struct {
-	QuantityId  uuid.UUID `db:"quantityId"`
-	ProductId   uuid.UUID `db:"productId"`
-	WarehouseId uuid.UUID `db:"warehouseId"`
-	Quantity    int       `db:"quantity"`
-	Status      string    `db:"status"`
-	CreatedAt   time.Time `db:"createdAt"`
-	CreatedBy   uuid.UUID `db:"createdBy"`
-	UpdatedAt   null.Time `db:"updatedAt"`
-	UpdatedBy   uuid.UUID `db:"updatedBy"`
+	QuantityId  uuid.UUID   `db:"quantityId"`
+	ProductId   uuid.UUID   `db:"productId"`
+	WarehouseId uuid.UUID   `db:"warehouseId"`
+	Quantity    int         `db:"quantity"`
+	Reserved    int         `db:"reserved"`
+	Status      string      `db:"status"`
+	CreatedAt   time.Time   `db:"createdAt"`
+	CreatedBy   uuid.UUID   `db:"createdBy"`
+	UpdatedAt   null.Time   `db:"updatedAt"`
+	UpdatedBy   nuuid.NUUID `db:"updatedBy"`
+	Version     int64       `db:"version"`
+}
+
+// StockChangedPayload is the payload of stock.changed events.
+type StockChangedPayload struct {
+	QuantityID       uuid.UUID `json:"quantityId"`
+	ProductID        uuid.UUID `json:"productId"`
+	WarehouseID      uuid.UUID `json:"warehouseId"`
+	PreviousQuantity int       `json:"previousQuantity"`
+	Quantity         int       `json:"quantity"`
+	Status           string    `json:"status"`
+	Version          int64     `json:"version"`
+	OccurredAt       time.Time `json:"occurredAt"`
+}
+
+// StockMovement records a change an order made to a quantity. Movements are
+// unique per message and quantity, so a redelivered message is applied once.
+type StockMovement struct {
+	MovementID  uuid.UUID         `db:"movementId"`
+	MessageID   string            `db:"messageId"`
+	OrderID     uuid.UUID         `db:"orderId"`
+	QuantityID  uuid.UUID         `db:"quantityId"`
+	ProductID   uuid.UUID         `db:"productId"`
+	WarehouseID uuid.UUID         `db:"warehouseId"`
+	Type        StockMovementType `db:"type"`
+	Quantity    int               `db:"quantity"`
+	CreatedAt   time.Time         `db:"createdAt"`
+}
+
+// StockMovementRequest is a change an order makes to the stock of a
+// warehouse, identified by the message that requested it.
+type StockMovementRequest struct {
+	MessageID   string
+	OrderID     uuid.UUID
+	WarehouseID uuid.UUID
+	Type        StockMovementType
+	Items       []StockMovementItem
+}
+
+// StockMovementItem is the change to the stock of a single product.
+type StockMovementItem struct {
+	ProductID uuid.UUID
+	Quantity  int
 }
 
 type WarehouseRequestFormat struct {
@@ -40,20 +114,42 @@ type QuantityRequestFormat struct {
 	Status      string    `json:"status"`
 }
 
-func (w Warehouses) NewFromRequestFormat(req WarehouseRequestFormat, warehouseId uuid.UUID) (newWarehouse Warehouses, err error) {
-	warehouseId, _ = uuid.NewV4()
+func (w Warehouses) NewFromRequestFormat(req WarehouseRequestFormat, userId uuid.UUID) (newWarehouse Warehouses, err error) {
+	warehouseId, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
 	newWarehouse = Warehouses{
 		WarehouseId:   warehouseId,
 		WarehouseName: req.WarehouseName,
 		CreatedAt:     time.Now(),
+		CreatedBy:     userId,
+		Version:       1,
 	}
-	warehouses := make([]Warehouses, 0)
-	warehouses = append(warehouses, newWarehouse)
 	return
 }
 
-func (q Quantity) NewFromRequestFormat(req QuantityRequestFormat, quantityId uuid.UUID) (newQuantity Quantity, err error) {
-	quantityId, _ = uuid.NewV4()
+func (w *Warehouses) Update(req WarehouseRequestFormat, userId uuid.UUID) (err error) {
+	w.WarehouseName = req.WarehouseName
+	w.UpdatedAt = null.TimeFrom(time.Now())
+	w.UpdatedBy = nuuid.From(userId)
+	return
+}
+
+// VerifyVersion checks that the caller is modifying the latest version of
+// this warehouse.
+func (w *Warehouses) VerifyVersion(version int64) (err error) {
+	if w.Version != version {
+		return failure.PreconditionFailed("warehouse", "version mismatch, resolve the latest version and retry")
+	}
+	return
+}
+
+func (q Quantity) NewFromRequestFormat(req QuantityRequestFormat, userId uuid.UUID) (newQuantity Quantity, err error) {
+	quantityId, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
 	newQuantity = Quantity{
 		QuantityId:  quantityId,
 		ProductId:   req.ProductId,
@@ -61,8 +157,98 @@ func (q Quantity) NewFromRequestFormat(req QuantityRequestFormat, quantityId uui
 		Quantity:    req.Quantity,
 		Status:      req.Status,
 		CreatedAt:   time.Now(),
+		CreatedBy:   userId,
+		Version:     1,
+	}
+	return
+}
+
+func (q *Quantity) Update(req QuantityRequestFormat, userId uuid.UUID) (err error) {
+	q.Quantity = req.Quantity
+	q.Status = req.Status
+	q.UpdatedAt = null.TimeFrom(time.Now())
+	q.UpdatedBy = nuuid.From(userId)
+	return
+}
+
+// VerifyVersion checks that the caller is modifying the latest version of
+// this quantity.
+func (q *Quantity) VerifyVersion(version int64) (err error) {
+	if q.Version != version {
+		return failure.PreconditionFailed("quantity", "version mismatch, resolve the latest version and retry")
+	}
+	return
+}
+
+// NewStockChangedEvent creates a stock.changed event from the given previous
+// quantity to the current quantity.
+func (q Quantity) NewStockChangedEvent(previousQuantity int) (model.EventWrapper, error) {
+	return model.NewVersionedEvent(StockChangedEventType, StockChangedEventVersion, StockChangedPayload{
+		QuantityID:       q.QuantityId,
+		ProductID:        q.ProductId,
+		WarehouseID:      q.WarehouseId,
+		PreviousQuantity: previousQuantity,
+		Quantity:         q.Quantity,
+		Status:           q.Status,
+		Version:          q.Version,
+		OccurredAt:       time.Now(),
+	})
+}
+
+// Move applies a stock movement made by the given user to this quantity.
+// Stock is reserved out of the quantity not reserved yet, and only reserved
+// stock is released or deducted.
+func (q *Quantity) Move(movementType StockMovementType, amount int, userId uuid.UUID) (err error) {
+	if amount < 1 {
+		return failure.BadRequestFromString("stock movements must move at least one item")
+	}
+
+	switch movementType {
+	case StockMovementReserve:
+		if available := q.Quantity - q.Reserved; amount > available {
+			return failure.BadRequestFromString(fmt.Sprintf("cannot reserve %d of product %s, only %d available", amount, q.ProductId, available))
+		}
+		q.Reserved += amount
+	case StockMovementRelease:
+		if amount > q.Reserved {
+			return failure.BadRequestFromString(fmt.Sprintf("cannot release %d of product %s, only %d reserved", amount, q.ProductId, q.Reserved))
+		}
+		q.Reserved -= amount
+	case StockMovementDeduct:
+		if amount > q.Reserved {
+			return failure.BadRequestFromString(fmt.Sprintf("cannot deduct %d of product %s, only %d reserved", amount, q.ProductId, q.Reserved))
+		}
+		q.Reserved -= amount
+		q.Quantity -= amount
+	case StockMovementRestock:
+		q.Quantity += amount
+	default:
+		return failure.BadRequestFromString(fmt.Sprintf("unknown stock movement %s", movementType))
+	}
+
+	q.UpdatedAt = null.TimeFrom(time.Now())
+	q.UpdatedBy = nuuid.From(userId)
+	return
+}
+
+// NewStockMovement records a movement of this quantity made by the given
+// request.
+func (q Quantity) NewStockMovement(request StockMovementRequest, amount int) (movement StockMovement, err error) {
+	movementID, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	movement = StockMovement{
+		MovementID:  movementID,
+		MessageID:   request.MessageID,
+		OrderID:     request.OrderID,
+		QuantityID:  q.QuantityId,
+		ProductID:   q.ProductId,
+		WarehouseID: q.WarehouseId,
+		Type:        request.Type,
+		Quantity:    amount,
+		CreatedAt:   time.Now(),
 	}
-	quantities := make([]Quantity, 0)
-	quantities = append(quantities, newQuantity)
 	return
 }
@@ -1,44 +1,113 @@
 package warehouse
 
+//go:generate go run github.com/golang/mock/mockgen -source werehouse_repository.go -destination mock/werehouse_repository_mock.go -package warehouse_mock
+
 import (
+	"database/sql"
+	"errors"
+
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/outbox"
 	"github.com/evermos/boilerplate-go/infras"
 	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/go-sql-driver/mysql"
 	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
+const mysqlErrorDuplicateEntry = 1062
+
+// ErrStockMovementApplied is returned when a stock movement was applied
+// already by an earlier delivery of the same message.
+var ErrStockMovementApplied = errors.New("stock movement already applied")
+
 var (
 	warehouseQueries = struct {
 		selectWarehouse string
+		selectQuantity  string
 		insertWarehouse string
 		insertQuantity  string
+		updateWarehouse string
+		updateQuantity  string
+
+		insertStockMovement string
 	}{
 		selectWarehouse: `
 SELECT
+	w.warehouseId,
+	w.warehouseName,
+	w.createdAt,
+	w.createdBy,
+	w.updatedAt,
+	w.updatedBy,
+	w.version
 FROM warehouses w`,
-		insertWarehouse: `INSERT INTO warehouse
-				(warehouseId, warehouseName, createdAt)
+		selectQuantity: `
+SELECT
+	q.quantityId,
+	q.productId,
+	q.warehouseId,
+	q.quantity,
+	q.reserved,
+	q.status,
+	q.createdAt,
+	q.createdBy,
+	q.updatedAt,
+	q.updatedBy,
+	q.version
+FROM quantity q`,
+		insertWarehouse: `INSERT INTO warehouses
+				(warehouseId, warehouseName, createdAt, createdBy, version)
 				VALUES
-				(:warehouseId, :warehouseName, NOW())`,
+				(:warehouseId, :warehouseName, NOW(), :createdBy, :version)`,
 		insertQuantity: `INSERT INTO quantity 
-				(quantityId, productId, warehouseId, quantity, status, createdAt)
+				(quantityId, productId, warehouseId, quantity, status, createdAt, createdBy, version)
 				VALUES
-				(:quantityId, :productId, :warehouseId, :quantity, :status, :createdAt)`,
+				(:quantityId, :productId, :warehouseId, :quantity, :status, :createdAt, :createdBy, :version)`,
+		updateWarehouse: `UPDATE warehouses
+				SET
+					warehouseName = :warehouseName,
+					updatedAt = :updatedAt,
+					updatedBy = :updatedBy,
+					version = version + 1
+				WHERE warehouseId = :warehouseId AND version = :version`,
+		updateQuantity: `UPDATE quantity
+				SET
+					quantity = :quantity,
+					reserved = :reserved,
+					status = :status,
+					updatedAt = :updatedAt,
+					updatedBy = :updatedBy,
+					version = version + 1
+				WHERE quantityId = :quantityId AND version = :version`,
+		insertStockMovement: `INSERT INTO stock_movements
+				(movementId, messageId, orderId, quantityId, productId, warehouseId, type, quantity, createdAt)
+				VALUES
+				(:movementId, :messageId, :orderId, :quantityId, :productId, :warehouseId, :type, :quantity, :createdAt)`,
 	}
 )
 
 type WarehouseRepository interface {
 	Create(warehouse Warehouses) (err error)
-	CreateQuantity(quantity Quantity) (err error)
+	CreateQuantity(quantity Quantity, events ...model.PublishRequest) (err error)
 	ExistsByID(id uuid.UUID) (exists bool, err error)
+	ExistsStockMovementByMessageID(messageID string) (exists bool, err error)
+	MoveStock(movements []StockMovement, quantities []Quantity, events ...model.PublishRequest) (err error)
+	ResolveByID(id uuid.UUID) (warehouse Warehouses, err error)
+	ResolveQuantityByID(id uuid.UUID) (quantity Quantity, err error)
+	ResolveQuantityByProductID(productID uuid.UUID, warehouseID uuid.UUID) (quantity Quantity, err error)
+	Update(warehouse Warehouses) (err error)
+	UpdateQuantity(quantity Quantity, events ...model.PublishRequest) (err error)
 }
 
 type WarehouseRepositoryMySQL struct {
-	DB *infras.MySQLConn
+	DB     *infras.MySQLConn
+	Outbox outbox.Repository
 }
 
-func ProvideWarehouseRepositoryMySQL(db *infras.MySQLConn) *WarehouseRepositoryMySQL {
-	return &WarehouseRepositoryMySQL{DB: db}
+func ProvideWarehouseRepositoryMySQL(db *infras.MySQLConn, outboxRepository outbox.Repository) *WarehouseRepositoryMySQL {
+	return &WarehouseRepositoryMySQL{DB: db, Outbox: outboxRepository}
 }
 
 func (w *WarehouseRepositoryMySQL) Create(warehouse Warehouses) (err error) {
@@ -67,8 +136,27 @@ func (w *WarehouseRepositoryMySQL) Create(warehouse Warehouses) (err error) {
 	}
 	return
 }
-func (w *WarehouseRepositoryMySQL) CreateQuantity(quantity Quantity) (err error) {
-	stmt, err := w.DB.Write.PrepareNamed(warehouseQueries.insertQuantity)
+
+// CreateQuantity creates a new quantity. The given events are written to the
+// outbox in the same transaction.
+func (w *WarehouseRepositoryMySQL) CreateQuantity(quantity Quantity, events ...model.PublishRequest) (err error) {
+	return w.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := w.txCreateQuantity(tx, quantity); err != nil {
+			e <- err
+			return
+		}
+
+		if err := w.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+func (w *WarehouseRepositoryMySQL) txCreateQuantity(tx *sqlx.Tx, quantity Quantity) (err error) {
+	stmt, err := tx.PrepareNamed(warehouseQueries.insertQuantity)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		return
@@ -85,7 +173,7 @@ func (w *WarehouseRepositoryMySQL) CreateQuantity(quantity Quantity) (err error)
 func (w *WarehouseRepositoryMySQL) ExistsByID(id uuid.UUID) (exists bool, err error) {
 	err = w.DB.Read.Get(
 		&exists,
-		"SELECT COUNT(warehouseId) FROM warehouse w WHERE w.warehouseId = ?",
+		"SELECT COUNT(warehouseId) FROM warehouses w WHERE w.warehouseId = ?",
 		id.String())
 	if err != nil {
 		logger.ErrorWithStack(err)
@@ -93,3 +181,161 @@ func (w *WarehouseRepositoryMySQL) ExistsByID(id uuid.UUID) (exists bool, err er
 
 	return
 }
+
+// ExistsStockMovementByMessageID checks whether the movements requested by a
+// message were applied already.
+func (w *WarehouseRepositoryMySQL) ExistsStockMovementByMessageID(messageID string) (exists bool, err error) {
+	err = w.DB.Read.Get(
+		&exists,
+		"SELECT COUNT(movementId) > 0 FROM stock_movements sm WHERE sm.messageId = ?",
+		messageID)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// MoveStock records stock movements and updates the quantities they moved.
+// The given events are written to the outbox in the same transaction. When
+// the movements of the message were recorded already, nothing is changed and
+// ErrStockMovementApplied is returned.
+func (w *WarehouseRepositoryMySQL) MoveStock(movements []StockMovement, quantities []Quantity, events ...model.PublishRequest) (err error) {
+	return w.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := w.txCreateStockMovements(tx, movements); err != nil {
+			e <- err
+			return
+		}
+
+		for _, quantity := range quantities {
+			if err := w.txExecVersioned(tx, warehouseQueries.updateQuantity, "quantity", quantity); err != nil {
+				e <- err
+				return
+			}
+		}
+
+		if err := w.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+func (w *WarehouseRepositoryMySQL) txCreateStockMovements(tx *sqlx.Tx, movements []StockMovement) (err error) {
+	stmt, err := tx.PrepareNamed(warehouseQueries.insertStockMovement)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	for _, movement := range movements {
+		_, err = stmt.Exec(movement)
+		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlErrorDuplicateEntry {
+			return ErrStockMovementApplied
+		}
+		if err != nil {
+			logger.ErrorWithStack(err)
+			return
+		}
+	}
+	return
+}
+
+func (w *WarehouseRepositoryMySQL) ResolveByID(id uuid.UUID) (warehouse Warehouses, err error) {
+	err = w.DB.Read.Get(
+		&warehouse,
+		warehouseQueries.selectWarehouse+" WHERE w.warehouseId = ?",
+		id.String())
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("warehouse")
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+func (w *WarehouseRepositoryMySQL) ResolveQuantityByID(id uuid.UUID) (quantity Quantity, err error) {
+	err = w.DB.Read.Get(
+		&quantity,
+		warehouseQueries.selectQuantity+" WHERE q.quantityId = ?",
+		id.String())
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("quantity")
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+// ResolveQuantityByProductID resolves the quantity of a product in a
+// warehouse, reading from the primary so that stock movements see the latest
+// version.
+func (w *WarehouseRepositoryMySQL) ResolveQuantityByProductID(productID uuid.UUID, warehouseID uuid.UUID) (quantity Quantity, err error) {
+	err = w.DB.Write.Get(
+		&quantity,
+		warehouseQueries.selectQuantity+" WHERE q.productId = ? AND q.warehouseId = ? ORDER BY q.createdAt LIMIT 1",
+		productID.String(),
+		warehouseID.String())
+	if err != nil && err == sql.ErrNoRows {
+		err = failure.NotFound("quantity")
+		logger.ErrorWithStack(err)
+		return
+	}
+	return
+}
+
+func (w *WarehouseRepositoryMySQL) Update(warehouse Warehouses) (err error) {
+	return w.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		e <- w.txExecVersioned(tx, warehouseQueries.updateWarehouse, "warehouse", warehouse)
+	})
+}
+
+// UpdateQuantity updates a quantity. The given events are written to the
+// outbox in the same transaction.
+func (w *WarehouseRepositoryMySQL) UpdateQuantity(quantity Quantity, events ...model.PublishRequest) (err error) {
+	return w.DB.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		if err := w.txExecVersioned(tx, warehouseQueries.updateQuantity, "quantity", quantity); err != nil {
+			e <- err
+			return
+		}
+
+		if err := w.Outbox.TxPublish(tx, events...); err != nil {
+			e <- err
+			return
+		}
+
+		e <- nil
+	})
+}
+
+// txExecVersioned runs a versioned update transactionally and reports a stale
+// version as a failed precondition.
+func (w *WarehouseRepositoryMySQL) txExecVersioned(tx *sqlx.Tx, query string, entityName string, arg interface{}) (err error) {
+	stmt, err := tx.PrepareNamed(query)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	result, err := stmt.Exec(arg)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+
+	if affected == 0 {
+		err = failure.PreconditionFailed(entityName, "modified by another request")
+		logger.ErrorWithStack(err)
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package warehouse
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarehouseQueriesBindActor(t *testing.T) {
+	actor, err := uuid.NewV4()
+	require.NoError(t, err)
+
+	t.Run("insert warehouse", func(t *testing.T) {
+		warehouse, err := Warehouses{}.NewFromRequestFormat(WarehouseRequestFormat{WarehouseName: "Jakarta"}, actor)
+		require.NoError(t, err)
+
+		query, args, err := sqlx.Named(warehouseQueries.insertWarehouse, warehouse)
+		require.NoError(t, err)
+		assert.Contains(t, query, "createdBy")
+		assert.Contains(t, args, actor)
+	})
+
+	t.Run("insert quantity", func(t *testing.T) {
+		quantity, err := Quantity{}.NewFromRequestFormat(QuantityRequestFormat{Quantity: 10, Status: "active"}, actor)
+		require.NoError(t, err)
+
+		query, args, err := sqlx.Named(warehouseQueries.insertQuantity, quantity)
+		require.NoError(t, err)
+		assert.Contains(t, query, "createdBy")
+		assert.Contains(t, args, actor)
+	})
+
+	t.Run("update quantity", func(t *testing.T) {
+		quantity := Quantity{Quantity: 10, Reserved: 2, Version: 1}
+		require.NoError(t, quantity.Move(StockMovementDeduct, 1, actor))
+
+		_, args, err := sqlx.Named(warehouseQueries.updateQuantity, quantity)
+		require.NoError(t, err)
+		assert.Contains(t, args, quantity.UpdatedBy)
+		assert.Equal(t, actor, quantity.UpdatedBy.UUID)
+	})
+}
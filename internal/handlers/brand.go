@@ -22,6 +22,8 @@ func ProvideBrandHandler(BrandService brands.BrandService) BrandHandler {
 func (h *BrandHandler) Router(r chi.Router) {
 	r.Route("/brand", func(r chi.Router) {
 		r.Post("/", h.CreateBrand)
+		r.Get("/{id}", h.ResolveBrandByID)
+		r.Put("/{id}", h.UpdateBrand)
 	})
 }
 
@@ -39,10 +41,62 @@ func (h *BrandHandler) CreateBrand(w http.ResponseWriter, r *http.Request) {
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	brandID, _ := uuid.NewV4()
-	brand, err := h.BrandService.Create(requestFormat, brandID)
+	brand, err := h.BrandService.Create(r.Context(), requestFormat)
 	if err != nil {
+		response.WithError(w, err)
 		return
 	}
+	writeETag(w, brand.Version)
 	response.WithJSON(w, http.StatusCreated, brand)
 }
+
+func (h *BrandHandler) ResolveBrandByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	brand, err := h.BrandService.ResolveByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	writeETag(w, brand.Version)
+	response.WithJSON(w, http.StatusOK, brand)
+}
+
+func (h *BrandHandler) UpdateBrand(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat brands.BrandRequestFormat
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	brand, err := h.BrandService.Update(r.Context(), id, version, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+	writeETag(w, brand.Version)
+	response.WithJSON(w, http.StatusOK, brand)
+}
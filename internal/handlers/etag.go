@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+)
+
+const (
+	headerETag    = "ETag"
+	headerIfMatch = "If-Match"
+)
+
+// writeETag exposes an entity's version as the response's ETag.
+func writeETag(w http.ResponseWriter, version int64) {
+	w.Header().Set(headerETag, fmt.Sprintf(`"%d"`, version))
+}
+
+// resolveIfMatch reads the entity version the client expects to overwrite
+// from the If-Match header.
+func resolveIfMatch(r *http.Request) (version int64, err error) {
+	ifMatch := strings.TrimSpace(r.Header.Get(headerIfMatch))
+	if ifMatch == "" {
+		return 0, failure.PreconditionRequired(headerIfMatch)
+	}
+
+	ifMatch = strings.TrimPrefix(ifMatch, "W/")
+	ifMatch = strings.Trim(ifMatch, `"`)
+	version, err = strconv.ParseInt(ifMatch, 10, 64)
+	if err != nil || version < 1 {
+		return 0, failure.BadRequestFromString("If-Match must contain the entity's ETag")
+	}
+
+	return
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/evermos/boilerplate-go/event/deadletter"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+)
+
+const defaultFailedEventPageSize = 20
+
+// FailedEventHandler is the HTTP handler for administering dead-lettered
+// events.
+type FailedEventHandler struct {
+	FailedEventService deadletter.FailedEventService
+}
+
+// ProvideFailedEventHandler is the provider for this handler.
+func ProvideFailedEventHandler(failedEventService deadletter.FailedEventService) FailedEventHandler {
+	return FailedEventHandler{
+		FailedEventService: failedEventService,
+	}
+}
+
+// Router sets up the router for this handler.
+func (h *FailedEventHandler) Router(r chi.Router) {
+	r.Route("/admin/failed-events", func(r chi.Router) {
+		r.Get("/", h.ResolveFailedEvents)
+		r.Post("/{id}/replay", h.ReplayFailedEvent)
+	})
+}
+
+// ResolveFailedEvents lists dead-lettered events.
+// @Summary List dead-lettered events.
+// @Description This endpoint lists events that were dead-lettered after failing processing, newest first.
+// @Tags admin/failed-events
+// @Security EVMOauthToken
+// @Param status query string false "Either pending or replayed; all when omitted."
+// @Param page query int false "The page to resolve, starting at 1."
+// @Param page_size query int false "The number of events per page."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]deadletter.FailedEventResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/failed-events [get]
+func (h *FailedEventHandler) ResolveFailedEvents(w http.ResponseWriter, r *http.Request) {
+	filter := deadletter.FailedEventFilter{
+		Status:   deadletter.FailedEventStatus(r.URL.Query().Get("status")),
+		Page:     1,
+		PageSize: defaultFailedEventPageSize,
+	}
+
+	switch filter.Status {
+	case "", deadletter.FailedEventStatusPending, deadletter.FailedEventStatusReplayed:
+	default:
+		response.WithError(w, failure.BadRequestFromString("status must be pending or replayed"))
+		return
+	}
+
+	var err error
+	if page := r.URL.Query().Get("page"); page != "" {
+		filter.Page, err = strconv.Atoi(page)
+		if err != nil || filter.Page < 1 {
+			response.WithError(w, failure.BadRequestFromString("page must be a positive number"))
+			return
+		}
+	}
+
+	if pageSize := r.URL.Query().Get("page_size"); pageSize != "" {
+		filter.PageSize, err = strconv.Atoi(pageSize)
+		if err != nil || filter.PageSize < 1 {
+			response.WithError(w, failure.BadRequestFromString("page_size must be a positive number"))
+			return
+		}
+	}
+
+	failedEvents, err := h.FailedEventService.ResolveAll(filter)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, failedEvents)
+}
+
+// ReplayFailedEvent sends a dead-lettered event back to its source queue.
+// @Summary Replay a dead-lettered event.
+// @Description This endpoint sends a dead-lettered event back to the queue it was consumed from.
+// @Tags admin/failed-events
+// @Security EVMOauthToken
+// @Param id path string true "The failed event's identifier."
+// @Produce json
+// @Success 200 {object} response.Base{data=deadletter.FailedEventResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/failed-events/{id}/replay [post]
+func (h *FailedEventHandler) ReplayFailedEvent(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	failedEvent, err := h.FailedEventService.Replay(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, failedEvent)
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/evermos/boilerplate-go/internal/domain/foobarbaz"
 	"github.com/evermos/boilerplate-go/shared"
 	"github.com/evermos/boilerplate-go/shared/failure"
-	"github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 	"github.com/go-chi/chi"
 	"github.com/gofrs/uuid"
@@ -16,33 +15,23 @@ import (
 
 // FooBarBazHandler is the HTTP handler for FooBarBaz domain.
 type FooBarBazHandler struct {
-	FooService     foobarbaz.FooService
-	AuthMiddleware *middleware.Authentication
+	FooService foobarbaz.FooService
 }
 
 // ProvideFooBarBazHandler is the provider for this handler.
-func ProvideFooBarBazHandler(fooService foobarbaz.FooService, authMiddleware *middleware.Authentication) FooBarBazHandler {
+func ProvideFooBarBazHandler(fooService foobarbaz.FooService) FooBarBazHandler {
 	return FooBarBazHandler{
-		FooService:     fooService,
-		AuthMiddleware: authMiddleware,
+		FooService: fooService,
 	}
 }
 
 // Router sets up the router for this domain.
 func (h *FooBarBazHandler) Router(r chi.Router) {
 	r.Route("/foobarbaz", func(r chi.Router) {
-		r.Group(func(r chi.Router) {
-			r.Use(h.AuthMiddleware.ClientCredential)
-			r.Get("/foo/{id}", h.ResolveFooByID)
-		})
-
-		r.Group(func(r chi.Router) {
-			r.Use(h.AuthMiddleware.Password)
-			r.Post("/foo", h.CreateFoo)
-			r.Delete("/foo/{id}", h.SoftDeleteFoo)
-			r.Put("/foo/{id}", h.UpdateFoo)
-		})
-
+		r.Get("/foo/{id}", h.ResolveFooByID)
+		r.Post("/foo", h.CreateFoo)
+		r.Delete("/foo/{id}", h.SoftDeleteFoo)
+		r.Put("/foo/{id}", h.UpdateFoo)
 	})
 }
 
@@ -51,6 +40,7 @@ func (h *FooBarBazHandler) Router(r chi.Router) {
 // @Description This endpoint creates a new Foo.
 // @Tags foobarbaz/foo
 // @Security EVMOauthToken
+// @Param Idempotency-Key header string false "Makes retrying this request safe."
 // @Param foo body foobarbaz.FooRequestFormat true "The Foo to be created."
 // @Produce json
 // @Success 201 {object} response.Base{data=foobarbaz.FooResponseFormat}
@@ -73,14 +63,13 @@ func (h *FooBarBazHandler) CreateFoo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
-
-	foo, err := h.FooService.Create(requestFormat, userID)
+	foo, err := h.FooService.Create(r.Context(), requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	writeETag(w, foo.Version)
 	response.WithJSON(w, http.StatusCreated, foo)
 }
 
@@ -113,6 +102,7 @@ func (h *FooBarBazHandler) ResolveFooByID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	writeETag(w, foo.Version)
 	response.WithJSON(w, http.StatusOK, foo)
 }
 
@@ -123,10 +113,13 @@ func (h *FooBarBazHandler) ResolveFooByID(w http.ResponseWriter, r *http.Request
 // @Tags foobarbaz/foo
 // @Security EVMOauthToken
 // @Param id path string true "The Foo's identifier."
+// @Param If-Match header string true "The Foo's current ETag."
 // @Produce json
 // @Success 200 {object} response.Base{data=foobarbaz.FooResponseFormat}
 // @Failure 400 {object} response.Base
 // @Failure 409 {object} response.Base
+// @Failure 412 {object} response.Base
+// @Failure 428 {object} response.Base
 // @Failure 500 {object} response.Base
 // @Router /v1/foobarbaz/foo/{id} [delete]
 func (h *FooBarBazHandler) SoftDeleteFoo(w http.ResponseWriter, r *http.Request) {
@@ -137,14 +130,19 @@ func (h *FooBarBazHandler) SoftDeleteFoo(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
 
-	foo, err := h.FooService.SoftDelete(id, userID)
+	foo, err := h.FooService.SoftDelete(r.Context(), id, version)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	writeETag(w, foo.Version)
 	response.WithJSON(w, http.StatusOK, foo)
 }
 
@@ -154,11 +152,14 @@ func (h *FooBarBazHandler) SoftDeleteFoo(w http.ResponseWriter, r *http.Request)
 // @Tags foobarbaz/foo
 // @Security EVMOauthToken
 // @Param id path string true "The Foo's identifier."
+// @Param If-Match header string true "The Foo's current ETag."
 // @Param foo body foobarbaz.FooRequestFormat true "The Foo to be updated."
 // @Produce json
 // @Success 200 {object} response.Base{data=foobarbaz.FooResponseFormat}
 // @Failure 400 {object} response.Base
 // @Failure 409 {object} response.Base
+// @Failure 412 {object} response.Base
+// @Failure 428 {object} response.Base
 // @Failure 500 {object} response.Base
 // @Router /v1/foobarbaz/foo/{id} [put]
 func (h *FooBarBazHandler) UpdateFoo(w http.ResponseWriter, r *http.Request) {
@@ -169,6 +170,12 @@ func (h *FooBarBazHandler) UpdateFoo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
 	decoder := json.NewDecoder(r.Body)
 	var requestFormat foobarbaz.FooRequestFormat
 	err = decoder.Decode(&requestFormat)
@@ -183,13 +190,12 @@ func (h *FooBarBazHandler) UpdateFoo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
-
-	foo, err := h.FooService.Update(id, requestFormat, userID)
+	foo, err := h.FooService.Update(r.Context(), id, version, requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	writeETag(w, foo.Version)
 	response.WithJSON(w, http.StatusOK, foo)
 }
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/go-chi/chi"
+)
+
+// OAuthHandler is the HTTP handler for the OAuth 2.0 endpoints.
+type OAuthHandler struct {
+	Config oauth.Config
+	DB     *infras.MySQLConn
+}
+
+// ProvideOAuthHandler is the provider for this handler.
+func ProvideOAuthHandler(config oauth.Config, db *infras.MySQLConn) OAuthHandler {
+	return OAuthHandler{
+		Config: config,
+		DB:     db,
+	}
+}
+
+// Router sets up the router for this handler. The endpoints authenticate
+// clients themselves, and are not versioned.
+func (h *OAuthHandler) Router(r chi.Router) {
+	r.Route("/oauth", func(r chi.Router) {
+		r.Get("/authorize", h.ResolveAuthorization)
+		r.Post("/authorize", h.Authorize)
+		r.Post("/token", h.CreateToken)
+		r.Post("/revoke", h.RevokeToken)
+		r.Post("/introspect", h.IntrospectToken)
+	})
+	r.Get("/.well-known/jwks.json", h.ResolveJWKS)
+}
+
+// CreateToken issues an access token.
+// @Summary Issue an access token.
+// @Description This endpoint issues access tokens following RFC 6749. Clients authenticate with HTTP Basic or with the client_id and client_secret parameters. The password and authorization_code grants issue a refresh token along, which is rotated on every use of the refresh_token grant. Failed password logins throttle the user from the IP address progressively, and lock the user and the IP address out for a while once they are too many. Throttled and locked out logins tell when to retry in the Retry-After header. Public clients authenticate with the client_id parameter alone.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param grant_type formData string true "The grant type, client_credentials, password, refresh_token or authorization_code."
+// @Param client_id formData string false "The client's ID, unless authenticating with HTTP Basic."
+// @Param client_secret formData string false "The client's secret, unless authenticating with HTTP Basic."
+// @Param username formData string false "The user's username or email, for the password grant."
+// @Param password formData string false "The user's password, for the password grant."
+// @Param refresh_token formData string false "The refresh token, for the refresh_token grant."
+// @Param code formData string false "The authorization code, for the authorization_code grant."
+// @Param redirect_uri formData string false "The redirect URI of the authorization request, for the authorization_code grant when it was sent."
+// @Param code_verifier formData string false "The code verifier of the authorization request's code challenge, for the authorization_code grant."
+// @Param scope formData string false "The space-separated scopes requested, all of the client's scopes by default. Users are granted those their role permits."
+// @Produce json
+// @Success 200 {object} oauth.TokenResponse
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Failure 500 {object} oauth.Error
+// @Router /oauth/token [post]
+func (h *OAuthHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	credential, err := oauth.ParseTokenRequest(r)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+	credential.IPAddress = oauth.RemoteIP(r.RemoteAddr)
+
+	token, err := h.token(credential)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+
+	tokenResponse, err := token.Create(credential)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, tokenResponse)
+}
+
+// RevokeToken revokes a token.
+// @Summary Revoke a token.
+// @Description This endpoint revokes an access or refresh token issued to the client, following RFC 7009. Revoking a refresh token revokes the tokens rotated from it, and the access tokens issued along them, as well. Unknown tokens are ignored.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "The token to revoke."
+// @Param token_type_hint formData string false "The token's type, access_token or refresh_token."
+// @Param client_id formData string false "The client's ID, unless authenticating with HTTP Basic."
+// @Param client_secret formData string false "The client's secret, unless authenticating with HTTP Basic."
+// @Produce json
+// @Success 200
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Failure 500 {object} oauth.Error
+// @Router /oauth/revoke [post]
+func (h *OAuthHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	reference, err := oauth.ParseTokenReference(r)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+
+	token, err := h.token(reference.Credential)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+
+	err = token.Revoke(reference)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, struct{}{})
+}
+
+// IntrospectToken describes a token.
+// @Summary Introspect a token.
+// @Description This endpoint describes an access or refresh token to resource servers, following RFC 7662. Only confidential clients may introspect tokens, public clients are rejected with unauthorized_client. Tokens that are unknown, expired or revoked are inactive.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "The token to introspect."
+// @Param token_type_hint formData string false "The token's type, access_token or refresh_token."
+// @Param client_id formData string false "The client's ID, unless authenticating with HTTP Basic."
+// @Param client_secret formData string false "The client's secret, unless authenticating with HTTP Basic."
+// @Produce json
+// @Success 200 {object} oauth.Introspection
+// @Failure 400 {object} oauth.Error
+// @Failure 401 {object} oauth.Error
+// @Failure 500 {object} oauth.Error
+// @Router /oauth/introspect [post]
+func (h *OAuthHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	reference, err := oauth.ParseTokenReference(r)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+
+	token, err := h.token(reference.Credential)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+
+	introspection, err := token.Introspect(reference)
+	if err != nil {
+		h.respondWithError(w, r, err)
+		return
+	}
+
+	h.respond(w, http.StatusOK, introspection)
+}
+
+// ResolveJWKS lists the public keys verifying JWT access tokens.
+// @Summary List the keys verifying JWT access tokens.
+// @Description This endpoint publishes the public keys verifying JWT access tokens, identified by the kid header of the tokens. The list is empty unless JWT keys are configured.
+// @Tags oauth
+// @Produce json
+// @Success 200 {object} oauth.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (h *OAuthHandler) ResolveJWKS(w http.ResponseWriter, r *http.Request) {
+	jwks := oauth.JSONWebKeySet{Keys: []oauth.JSONWebKey{}}
+	if h.Config.KeySet != nil {
+		jwks = h.Config.KeySet.JWKS()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	err := json.NewEncoder(w).Encode(jwks)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+// token returns the OAuth token service for a client, failing when the client
+// is not allowed to use it.
+func (h *OAuthHandler) token(credential oauth.Credential) (*oauth.Token, error) {
+	token := oauth.New(h.DB.Write, h.Config)
+	if !token.ClientScopeAllowed(credential.ClientID) {
+		return nil, oauth.NewError(oauth.ErrorCodeUnauthorizedClient, oauth.ErrorInvalidClient)
+	}
+
+	return token, nil
+}
+
+// respondWithError sends an OAuth error response. Errors other than OAuth
+// errors are logged and reported as server errors.
+func (h *OAuthHandler) respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	oauthErr, ok := err.(*oauth.Error)
+	if !ok {
+		logger.ErrorWithStack(err)
+		oauthErr = oauth.NewError(oauth.ErrorCodeServerError, "")
+		h.respond(w, http.StatusInternalServerError, oauthErr)
+		return
+	}
+
+	if oauthErr.Code == oauth.ErrorCodeInvalidClient && r.Header.Get(middleware.HeaderAuthorization) != "" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	if retryAfter := oauthErr.RetryAfterSeconds(); retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	}
+	h.respond(w, oauthErr.StatusCode(), oauthErr)
+}
+
+// respond sends a JSON response that must not be cached, RFC 6749 section 5.1.
+func (h *OAuthHandler) respond(w http.ResponseWriter, code int, payload interface{}) {
+	body, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(code)
+	_, err := w.Write(body)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
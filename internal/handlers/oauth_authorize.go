@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+)
+
+const authorizeDecisionApprove = "approve"
+
+// authorizePage is the login and consent page of the authorization endpoint.
+// It posts the authorization request back along the user's decision.
+var authorizePage = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Authorize</title>
+<style>
+body { font-family: sans-serif; max-width: 24rem; margin: 4rem auto; padding: 0 1rem; }
+label, input, button { display: block; width: 100%; box-sizing: border-box; }
+input { margin: .25rem 0 1rem; padding: .5rem; }
+button { margin-top: .5rem; padding: .5rem; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+{{if .Client}}
+<h1>Sign in to {{.Client}}</h1>
+{{if .Scopes}}<p>{{.Client}} requests access to:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/oauth/authorize">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<label for="username">Username or email</label>
+<input id="username" name="username" autocomplete="username">
+<label for="password">Password</label>
+<input id="password" name="password" type="password" autocomplete="current-password">
+<button type="submit" name="decision" value="approve">Approve</button>
+<button type="submit" name="decision" value="deny">Deny</button>
+</form>
+{{else}}
+<h1>Authorization failed</h1>
+<p class="error">{{.Error}}</p>
+{{end}}
+</body>
+</html>
+`))
+
+// authorizePageData is the data rendered by authorizePage. The form is only
+// rendered for a valid request, which names its client.
+type authorizePageData struct {
+	Client  string
+	Scopes  []string
+	Request oauth.AuthorizationRequest
+	Error   string
+}
+
+// ResolveAuthorization renders the login and consent page of an authorization
+// request.
+// @Summary Render the authorization page.
+// @Description This endpoint validates an authorization request following RFC 6749 and RFC 7636, and renders a page where the user signs in and approves the request. The client must be registered with a redirect URI and the authorization_code grant, and send an S256 code challenge. Errors are redirected to the client, unless the client or the redirect URI is invalid.
+// @Tags oauth
+// @Param response_type query string true "The response type, code."
+// @Param client_id query string true "The client's ID."
+// @Param redirect_uri query string false "The client's registered redirect URI."
+// @Param scope query string false "The space-separated scopes requested, all of the client's scopes by default."
+// @Param state query string false "An opaque value sent back to the client along the code."
+// @Param code_challenge query string true "The Base64URL-encoded SHA-256 digest of the code verifier."
+// @Param code_challenge_method query string true "The code challenge method, S256."
+// @Produce html
+// @Success 200 {string} string
+// @Success 302
+// @Failure 400 {string} string
+// @Failure 500 {string} string
+// @Router /oauth/authorize [get]
+func (h *OAuthHandler) ResolveAuthorization(w http.ResponseWriter, r *http.Request) {
+	request, _, client, ok := h.validateAuthorization(w, r)
+	if !ok {
+		return
+	}
+
+	h.renderAuthorization(w, http.StatusOK, newAuthorizePageData(request, client, ""))
+}
+
+// Authorize issues an authorization code once the user approves a request.
+// @Summary Approve or deny an authorization request.
+// @Description This endpoint signs in the user of the authorization page, and redirects to the client with an authorization code once they approve the request, or with the access_denied error once they deny it. The page is rendered again when the user's credentials are invalid, telling when to retry in the Retry-After header once sign-ins are throttled.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param response_type formData string true "The response type, code."
+// @Param client_id formData string true "The client's ID."
+// @Param redirect_uri formData string false "The client's registered redirect URI."
+// @Param scope formData string false "The space-separated scopes requested, all of the client's scopes by default."
+// @Param state formData string false "An opaque value sent back to the client along the code."
+// @Param code_challenge formData string true "The Base64URL-encoded SHA-256 digest of the code verifier."
+// @Param code_challenge_method formData string true "The code challenge method, S256."
+// @Param username formData string false "The user's username or email."
+// @Param password formData string false "The user's password."
+// @Param decision formData string true "The user's decision, approve or deny."
+// @Produce html
+// @Success 302
+// @Failure 400 {string} string
+// @Failure 401 {string} string
+// @Failure 500 {string} string
+// @Router /oauth/authorize [post]
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	request, token, client, ok := h.validateAuthorization(w, r)
+	if !ok {
+		return
+	}
+
+	redirectURI := client.RedirectURI.String
+
+	if r.PostForm.Get("decision") != authorizeDecisionApprove {
+		denied := oauth.NewError(oauth.ErrorCodeAccessDenied, oauth.ErrorAccessDenied)
+		http.Redirect(w, r, request.RedirectWithError(redirectURI, denied), http.StatusFound)
+		return
+	}
+
+	code, err := token.Authorize(request, oauth.Credential{
+		Username:  r.PostForm.Get("username"),
+		Password:  r.PostForm.Get("password"),
+		IPAddress: oauth.RemoteIP(r.RemoteAddr),
+	})
+	if err != nil {
+		if oauthErr, ok := err.(*oauth.Error); ok {
+			message := "Invalid username or password."
+			if oauthErr.Description == oauth.ErrorLoginLocked {
+				message = "Too many failed sign-ins, try again later."
+			}
+			if retryAfter := oauthErr.RetryAfterSeconds(); retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+			}
+			data := newAuthorizePageData(request, client, message)
+			h.renderAuthorization(w, http.StatusUnauthorized, data)
+			return
+		}
+		h.respondWithAuthorizationError(w, r, request, err)
+		return
+	}
+
+	http.Redirect(w, r, request.RedirectWithCode(redirectURI, code), http.StatusFound)
+}
+
+// validateAuthorization parses and validates the authorization request of a
+// request, responding with its error when it is invalid.
+func (h *OAuthHandler) validateAuthorization(w http.ResponseWriter, r *http.Request) (request oauth.AuthorizationRequest, token *oauth.Token, client oauth.OauthClient, ok bool) {
+	request, err := oauth.ParseAuthorizationRequest(r)
+	if err != nil {
+		h.respondWithAuthorizationError(w, r, request, err)
+		return
+	}
+
+	token, err = h.token(oauth.Credential{ClientID: request.ClientID})
+	if err == nil {
+		client, err = token.ValidateAuthorization(request)
+	}
+	if err != nil {
+		h.respondWithAuthorizationError(w, r, request, err)
+		return
+	}
+
+	return request, token, client, true
+}
+
+// respondWithAuthorizationError redirects the error of an authorization request
+// to the client, or renders it when the client or its redirect URI is invalid,
+// RFC 6749 section 4.1.2.1.
+func (h *OAuthHandler) respondWithAuthorizationError(w http.ResponseWriter, r *http.Request, request oauth.AuthorizationRequest, err error) {
+	switch authErr := err.(type) {
+	case *oauth.AuthorizationError:
+		if authErr.RedirectURI != "" {
+			http.Redirect(w, r, request.RedirectWithError(authErr.RedirectURI, authErr.Err), http.StatusFound)
+			return
+		}
+		h.renderAuthorization(w, http.StatusBadRequest, authorizePageData{Error: authErr.Error()})
+	case *oauth.Error:
+		h.renderAuthorization(w, http.StatusBadRequest, authorizePageData{Error: authErr.Error()})
+	default:
+		logger.ErrorWithStack(err)
+		h.renderAuthorization(w, http.StatusInternalServerError, authorizePageData{Error: "The request could not be processed."})
+	}
+}
+
+// newAuthorizePageData returns the data of the page of a valid request, which
+// lists the scopes requested, all of the client's by default.
+func newAuthorizePageData(request oauth.AuthorizationRequest, client oauth.OauthClient, message string) authorizePageData {
+	scope := request.Scope
+	if scope == "" {
+		scope = client.Scope.String
+	}
+
+	return authorizePageData{
+		Client:  client.ClientID,
+		Scopes:  strings.Fields(scope),
+		Request: request,
+		Error:   message,
+	}
+}
+
+// renderAuthorization renders the authorization page, which must not be
+// cached nor framed.
+func (h *OAuthHandler) renderAuthorization(w http.ResponseWriter, code int, data authorizePageData) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.WriteHeader(code)
+	err := authorizePage.Execute(w, data)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
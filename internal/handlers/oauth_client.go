@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+)
+
+// OAuthClientHandler is the HTTP handler for administering OAuth clients.
+type OAuthClientHandler struct {
+	Config oauth.Config
+	DB     *infras.MySQLConn
+}
+
+// ProvideOAuthClientHandler is the provider for this handler.
+func ProvideOAuthClientHandler(config oauth.Config, db *infras.MySQLConn) OAuthClientHandler {
+	return OAuthClientHandler{
+		Config: config,
+		DB:     db,
+	}
+}
+
+// Router sets up the router for this handler.
+func (h *OAuthClientHandler) Router(r chi.Router) {
+	r.Route("/admin/oauth-clients", func(r chi.Router) {
+		r.Get("/", h.ResolveOAuthClients)
+		r.Post("/", h.RegisterOAuthClient)
+		r.Post("/{id}/rotate-secret", h.RotateOAuthClientSecret)
+		r.Post("/{id}/disable", h.DisableOAuthClient)
+	})
+}
+
+// ResolveOAuthClients lists OAuth clients.
+// @Summary List OAuth clients.
+// @Description This endpoint lists the OAuth clients, without their secrets.
+// @Tags admin/oauth-clients
+// @Security EVMOauthToken
+// @Produce json
+// @Success 200 {object} response.Base{data=[]oauth.ClientResponseFormat}
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth-clients [get]
+func (h *OAuthClientHandler) ResolveOAuthClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := oauth.New(h.DB.Read, h.Config).ResolveClients()
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, clients)
+}
+
+// RegisterOAuthClient registers a new OAuth client.
+// @Summary Register a new OAuth client.
+// @Description This endpoint registers a new OAuth client. Its generated secret is only shown in this response, and is stored hashed.
+// @Tags admin/oauth-clients
+// @Security EVMOauthToken
+// @Param client body oauth.ClientRequestFormat true "The client to be registered."
+// @Produce json
+// @Success 201 {object} response.Base{data=oauth.ClientSecretResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth-clients [post]
+func (h *OAuthClientHandler) RegisterOAuthClient(w http.ResponseWriter, r *http.Request) {
+	var requestFormat oauth.ClientRequestFormat
+	err := json.NewDecoder(r.Body).Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	client, secret, err := oauth.New(h.DB.Write, h.Config).RegisterClient(requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	h.respondWithSecret(w, http.StatusCreated, client.ToSecretResponseFormat(secret))
+}
+
+// RotateOAuthClientSecret replaces the secret of an OAuth client.
+// @Summary Rotate the secret of an OAuth client.
+// @Description This endpoint replaces the secret of an OAuth client, which stops authenticating with its previous secret. The new secret is only shown in this response.
+// @Tags admin/oauth-clients
+// @Security EVMOauthToken
+// @Param id path string true "The client's ID."
+// @Produce json
+// @Success 200 {object} response.Base{data=oauth.ClientSecretResponseFormat}
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth-clients/{id}/rotate-secret [post]
+func (h *OAuthClientHandler) RotateOAuthClientSecret(w http.ResponseWriter, r *http.Request) {
+	client, secret, err := oauth.New(h.DB.Write, h.Config).RotateClientSecret(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	h.respondWithSecret(w, http.StatusOK, client.ToSecretResponseFormat(secret))
+}
+
+// DisableOAuthClient disables an OAuth client.
+// @Summary Disable an OAuth client.
+// @Description This endpoint disables an OAuth client, which stops authenticating. The access tokens issued to the client are deleted and its refresh tokens revoked.
+// @Tags admin/oauth-clients
+// @Security EVMOauthToken
+// @Param id path string true "The client's ID."
+// @Produce json
+// @Success 200 {object} response.Base{data=oauth.ClientResponseFormat}
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/oauth-clients/{id}/disable [post]
+func (h *OAuthClientHandler) DisableOAuthClient(w http.ResponseWriter, r *http.Request) {
+	client, err := oauth.New(h.DB.Write, h.Config).DisableClient(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, client)
+}
+
+// respondWithSecret responds with a client's secret, which must not be cached.
+func (h *OAuthClientHandler) respondWithSecret(w http.ResponseWriter, code int, client oauth.ClientSecretResponseFormat) {
+	w.Header().Set("Cache-Control", "no-store")
+	response.WithJSON(w, code, client)
+}
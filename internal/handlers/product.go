@@ -24,6 +24,9 @@ func (h *ProductHandler) Router(r chi.Router) {
 	r.Route("/product", func(r chi.Router) {
 		r.Post("/", h.CreateProduct)
 		r.Get("/search", h.SearchProducts)
+		r.Get("/{id}", h.ResolveProductByID)
+		r.Put("/{id}", h.UpdateProduct)
+		r.Delete("/{id}", h.SoftDeleteProduct)
 	})
 }
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
@@ -41,15 +44,93 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	variantID, _ := uuid.NewV4() // TODO: read from context
-	product, err := h.ProductService.Create(requestFormat, variantID)
+	product, err := h.ProductService.Create(r.Context(), requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	writeETag(w, product.Version)
 	response.WithJSON(w, http.StatusCreated, product)
 }
+
+func (h *ProductHandler) ResolveProductByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	product, err := h.ProductService.ResolveByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, product.Version)
+	response.WithJSON(w, http.StatusOK, product)
+}
+
+func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat products.ProductRequestFormat
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	product, err := h.ProductService.Update(r.Context(), id, version, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, product.Version)
+	response.WithJSON(w, http.StatusOK, product)
+}
+
+func (h *ProductHandler) SoftDeleteProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	product, err := h.ProductService.SoftDelete(r.Context(), id, version)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, product.Version)
+	response.WithJSON(w, http.StatusOK, product)
+}
+
 func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
 	page, err := strconv.Atoi(r.URL.Query().Get("page"))
 	if err != nil {
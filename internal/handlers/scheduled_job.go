@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/evermos/boilerplate-go/scheduler"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+)
+
+const defaultScheduledJobRunLimit = 20
+
+// ScheduledJobHandler is the HTTP handler for administering scheduled jobs.
+type ScheduledJobHandler struct {
+	Scheduler *scheduler.Scheduler
+}
+
+// ProvideScheduledJobHandler is the provider for this handler.
+func ProvideScheduledJobHandler(scheduler *scheduler.Scheduler) ScheduledJobHandler {
+	return ScheduledJobHandler{
+		Scheduler: scheduler,
+	}
+}
+
+// Router sets up the router for this handler.
+func (h *ScheduledJobHandler) Router(r chi.Router) {
+	r.Route("/admin/scheduled-jobs", func(r chi.Router) {
+		r.Get("/", h.ResolveScheduledJobs)
+		r.Get("/{name}/runs", h.ResolveScheduledJobRuns)
+		r.Post("/{name}/trigger", h.TriggerScheduledJob)
+	})
+}
+
+// ResolveScheduledJobs lists scheduled jobs.
+// @Summary List scheduled jobs.
+// @Description This endpoint lists the scheduled jobs along their next and last runs.
+// @Tags admin/scheduled-jobs
+// @Security EVMOauthToken
+// @Produce json
+// @Success 200 {object} response.Base{data=[]scheduler.JobStatus}
+// @Failure 403 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/scheduled-jobs [get]
+func (h *ScheduledJobHandler) ResolveScheduledJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.Scheduler.Jobs()
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, jobs)
+}
+
+// ResolveScheduledJobRuns lists the runs of a scheduled job.
+// @Summary List the runs of a scheduled job.
+// @Description This endpoint lists the latest runs of a scheduled job, newest first.
+// @Tags admin/scheduled-jobs
+// @Security EVMOauthToken
+// @Param name path string true "The job's name."
+// @Param limit query int false "The number of runs to list."
+// @Produce json
+// @Success 200 {object} response.Base{data=[]scheduler.RunResponseFormat}
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/scheduled-jobs/{name}/runs [get]
+func (h *ScheduledJobHandler) ResolveScheduledJobRuns(w http.ResponseWriter, r *http.Request) {
+	limit := defaultScheduledJobRunLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			response.WithError(w, failure.BadRequestFromString("limit must be a positive number"))
+			return
+		}
+	}
+
+	runs, err := h.Scheduler.Runs(chi.URLParam(r, "name"), limit)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusOK, runs)
+}
+
+// TriggerScheduledJob runs a scheduled job now.
+// @Summary Trigger a scheduled job.
+// @Description This endpoint runs a scheduled job now, in the background. The returned run can be followed through the job's runs.
+// @Tags admin/scheduled-jobs
+// @Security EVMOauthToken
+// @Param name path string true "The job's name."
+// @Produce json
+// @Success 202 {object} response.Base{data=scheduler.RunResponseFormat}
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 409 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/admin/scheduled-jobs/{name}/trigger [post]
+func (h *ScheduledJobHandler) TriggerScheduledJob(w http.ResponseWriter, r *http.Request) {
+	run, err := h.Scheduler.Trigger(chi.URLParam(r, "name"))
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.WithJSON(w, http.StatusAccepted, run)
+}
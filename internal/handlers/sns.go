@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event/sns"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/go-chi/chi"
+)
+
+// maxSNSMessageSize is the largest message SNS delivers, with room for its
+// envelope.
+const maxSNSMessageSize = 512 << 10
+
+// SNSHandler is the HTTP handler for SNS messages pushed to an HTTPS
+// subscription.
+type SNSHandler struct {
+	Config     *configs.Config
+	Subscriber *sns.Subscriber
+}
+
+// ProvideSNSHandler is the provider for this handler.
+func ProvideSNSHandler(config *configs.Config, subscriber *sns.Subscriber) SNSHandler {
+	return SNSHandler{
+		Config:     config,
+		Subscriber: subscriber,
+	}
+}
+
+// Router sets up the router for this handler. Messages are authenticated by
+// their SNS signature rather than a token.
+func (h *SNSHandler) Router(r chi.Router) {
+	if !h.Config.Event.Consumer.SNS.Enabled {
+		return
+	}
+
+	r.Post("/events/sns", h.ReceiveSNSMessage)
+}
+
+// ReceiveSNSMessage receives a message pushed by SNS.
+// @Summary Receive an SNS message.
+// @Description This endpoint confirms SNS subscriptions and dispatches the notifications of subscribed topics to their consumers. Messages must be signed by SNS.
+// @Tags events
+// @Param message body sns.Message true "The SNS message."
+// @Produce json
+// @Success 204
+// @Failure 400 {object} response.Base
+// @Failure 401 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/events/sns [post]
+func (h *SNSHandler) ReceiveSNSMessage(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSNSMessageSize))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = h.Subscriber.Receive(body)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	response.NoContent(w)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/evermos/boilerplate-go/internal/domain/users"
 	"github.com/evermos/boilerplate-go/shared"
 	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 	"github.com/go-chi/chi"
 	"github.com/gofrs/uuid"
@@ -13,15 +14,19 @@ import (
 
 type UserHandler struct {
 	UserService users.UserService
+	OAuth       oauth.Config
 }
 
-func ProvideUserHandler(UserService users.UserService) UserHandler {
-	return UserHandler{UserService: UserService}
+func ProvideUserHandler(UserService users.UserService, OAuth oauth.Config) UserHandler {
+	return UserHandler{UserService: UserService, OAuth: OAuth}
 }
 
 func (h *UserHandler) Router(r chi.Router) {
 	r.Route("/user", func(r chi.Router) {
 		r.Post("/", h.CreateUser)
+		r.Get("/{id}", h.ResolveUserByID)
+		r.Put("/{id}", h.UpdateUser)
+		r.Post("/{id}/unlock", h.UnlockUser)
 	})
 }
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
@@ -39,13 +44,109 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, _ := uuid.NewV4() // TODO: read from context
-
-	foo, err := h.UserService.Create(requestFormat, userID)
+	foo, err := h.UserService.Create(r.Context(), requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	writeETag(w, foo.Version)
 	response.WithJSON(w, http.StatusCreated, foo)
 }
+
+func (h *UserHandler) ResolveUserByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	user, err := h.UserService.ResolveByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, user.Version)
+	response.WithJSON(w, http.StatusOK, user)
+}
+
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat users.UserRequestFormat
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	user, err := h.UserService.Update(r.Context(), id, version, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, user.Version)
+	response.WithJSON(w, http.StatusOK, user)
+}
+
+// UnlockUser clears the failed logins and the lockout of a user.
+// @Summary Unlock a user locked out by failed logins.
+// @Description This endpoint clears the failed logins of a user, and lifts their lockout. IP addresses stay locked out until their lockout expires.
+// @Tags users
+// @Security EVMOauthToken
+// @Param id path string true "The user's ID."
+// @Produce json
+// @Success 200 {object} response.Base
+// @Failure 400 {object} response.Base
+// @Failure 403 {object} response.Base
+// @Failure 404 {object} response.Base
+// @Failure 500 {object} response.Base
+// @Router /v1/user/{id}/unlock [post]
+func (h *UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	actor, err := oauth.ActorFromContext(r.Context())
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	_, err = h.UserService.ResolveByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	if h.OAuth.Lockout != nil {
+		err = h.OAuth.Lockout.Unlock(id.String(), actor.String())
+		if err != nil {
+			response.WithError(w, err)
+			return
+		}
+	}
+
+	response.WithMessage(w, http.StatusOK, "User unlocked")
+}
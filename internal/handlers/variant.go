@@ -22,6 +22,8 @@ func ProvideVariantHandler(VariantService variants.VariantService) VariantHandle
 func (h *VariantHandler) Router(r chi.Router) {
 	r.Route("/variant", func(r chi.Router) {
 		r.Post("/", h.CreateVariant)
+		r.Get("/{id}", h.ResolveVariantByID)
+		r.Put("/{id}", h.UpdateVariant)
 	})
 }
 func (h *VariantHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
@@ -39,12 +41,66 @@ func (h *VariantHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	variantID, _ := uuid.NewV4()
-	variant, err := h.VariantService.Create(requestFormat, variantID)
+	variant, err := h.VariantService.Create(r.Context(), requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	writeETag(w, variant.Version)
 	response.WithJSON(w, http.StatusCreated, variant)
 }
+
+func (h *VariantHandler) ResolveVariantByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	variant, err := h.VariantService.ResolveByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, variant.Version)
+	response.WithJSON(w, http.StatusOK, variant)
+}
+
+func (h *VariantHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat variants.VariantRequestFormat
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	variant, err := h.VariantService.Update(r.Context(), id, version, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, variant.Version)
+	response.WithJSON(w, http.StatusOK, variant)
+}
@@ -22,7 +22,18 @@ func ProvideWarehouseHandler(WarehouseService warehouse.WarehouseService) Wareho
 func (h *WarehouseHandler) Router(r chi.Router) {
 	r.Route("/warehouse", func(r chi.Router) {
 		r.Post("/", h.CreateWarehouse)
-		r.Post("/quantity", h.CreateQuantity)
+		r.Get("/{id}", h.ResolveWarehouseByID)
+		r.Put("/{id}", h.UpdateWarehouse)
+	})
+}
+
+// QuantityRouter sets up the routes of the stock quantities of warehouses,
+// which are adjusted apart from the warehouses themselves.
+func (h *WarehouseHandler) QuantityRouter(r chi.Router) {
+	r.Route("/warehouse/quantity", func(r chi.Router) {
+		r.Post("/", h.CreateQuantity)
+		r.Get("/{id}", h.ResolveQuantityByID)
+		r.Put("/{id}", h.UpdateQuantity)
 	})
 }
 
@@ -40,16 +51,69 @@ func (h *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Reques
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	warehouseID, _ := uuid.NewV4()
-	warehouse, err := h.WarehouseService.Create(requestFormat, warehouseID)
+	warehouse, err := h.WarehouseService.Create(r.Context(), requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	writeETag(w, warehouse.Version)
 	response.WithJSON(w, http.StatusCreated, warehouse)
 }
 
+func (h *WarehouseHandler) ResolveWarehouseByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	warehouse, err := h.WarehouseService.ResolveByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, warehouse.Version)
+	response.WithJSON(w, http.StatusOK, warehouse)
+}
+
+func (h *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat warehouse.WarehouseRequestFormat
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	warehouse, err := h.WarehouseService.Update(r.Context(), id, version, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, warehouse.Version)
+	response.WithJSON(w, http.StatusOK, warehouse)
+}
+
 func (h *WarehouseHandler) CreateQuantity(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	var requestFormat warehouse.QuantityRequestFormat
@@ -64,12 +128,65 @@ func (h *WarehouseHandler) CreateQuantity(w http.ResponseWriter, r *http.Request
 		response.WithError(w, failure.BadRequest(err))
 		return
 	}
-	quantityId, _ := uuid.NewV4()
-	quantity, err := h.WarehouseService.CreateQuantity(requestFormat, quantityId)
+	quantity, err := h.WarehouseService.CreateQuantity(r.Context(), requestFormat)
 	if err != nil {
 		response.WithError(w, err)
 		return
 	}
 
+	writeETag(w, quantity.Version)
 	response.WithJSON(w, http.StatusCreated, quantity)
 }
+
+func (h *WarehouseHandler) ResolveQuantityByID(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	quantity, err := h.WarehouseService.ResolveQuantityByID(id)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, quantity.Version)
+	response.WithJSON(w, http.StatusOK, quantity)
+}
+
+func (h *WarehouseHandler) UpdateQuantity(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.FromString(chi.URLParam(r, "id"))
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	version, err := resolveIfMatch(r)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var requestFormat warehouse.QuantityRequestFormat
+	err = decoder.Decode(&requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+
+	err = shared.GetValidator().Struct(requestFormat)
+	if err != nil {
+		response.WithError(w, failure.BadRequest(err))
+		return
+	}
+	quantity, err := h.WarehouseService.UpdateQuantity(r.Context(), id, version, requestFormat)
+	if err != nil {
+		response.WithError(w, err)
+		return
+	}
+
+	writeETag(w, quantity.Version)
+	response.WithJSON(w, http.StatusOK, quantity)
+}
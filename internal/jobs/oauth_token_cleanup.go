@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/rs/zerolog/log"
+)
+
+// OAuthTokenCleanup deletes expired OAuth access and refresh tokens.
+type OAuthTokenCleanup struct {
+	Config *configs.Config
+	DB     *infras.MySQLConn
+}
+
+// ProvideOAuthTokenCleanup is the provider for this job.
+func ProvideOAuthTokenCleanup(config *configs.Config, db *infras.MySQLConn) OAuthTokenCleanup {
+	return OAuthTokenCleanup{
+		Config: config,
+		DB:     db,
+	}
+}
+
+// Name identifies this job.
+func (j OAuthTokenCleanup) Name() string {
+	return "oauth-token-cleanup"
+}
+
+// Schedule is the configured schedule of this job.
+func (j OAuthTokenCleanup) Schedule() string {
+	return j.Config.Scheduler.Jobs.OAuthTokenCleanup.Schedule
+}
+
+// Run deletes the tokens that expired by now.
+func (j OAuthTokenCleanup) Run(ctx context.Context) error {
+	purged, err := oauth.New(j.DB.Write, oauth.Config{}).PurgeExpired(time.Now())
+	if err != nil {
+		return err
+	}
+
+	log.Info().Int64("purged", purged).Msg("Purged expired tokens.")
+	return nil
+}
@@ -4,6 +4,8 @@ package main
 //go:generate go run github.com/google/wire/cmd/wire
 
 import (
+	"context"
+
 	"github.com/evermos/boilerplate-go/configs"
 	"github.com/evermos/boilerplate-go/shared/logger"
 )
@@ -24,12 +26,40 @@ func main() {
 	logger.SetLogLevel(config)
 
 	// Wire everything up
-	http := InitializeService()
+	scheduler := InitializeScheduler()
+	http := InitializeService(scheduler)
+
+	// Start running scheduled jobs
+	scheduler.Start()
+
+	// Start relaying events from the outbox
+	relay := InitializeOutboxRelay()
+	relay.Start()
 
-	//consumers := InitializeEvent()
+	consumers := InitializeEvent()
+
+	// Start purging expired processed events
+	purger := InitializeProcessedEventPurger()
+	purger.Start()
 
 	// Start consumers
-	//consumers.Start()
+	consumerCtx, stopConsumers := context.WithCancel(context.Background())
+	consumers.Start(consumerCtx)
+
+	// Stop polling on SIGTERM and drain in-flight work before shutting down
+	http.OnShutdown(func(ctx context.Context) {
+		stopConsumers()
+		consumers.Drain(ctx)
+	})
+	http.OnShutdown(func(ctx context.Context) {
+		relay.Stop()
+	})
+	http.OnShutdown(func(ctx context.Context) {
+		purger.Stop()
+	})
+	http.OnShutdown(func(ctx context.Context) {
+		scheduler.Stop()
+	})
 
 	// Run server
 	http.SetupAndServe()
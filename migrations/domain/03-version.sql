@@ -0,0 +1,16 @@
+ALTER TABLE `foo` ADD COLUMN `version` INT NOT NULL DEFAULT 1;
+
+ALTER TABLE `user` ADD COLUMN `version` INT NOT NULL DEFAULT 1;
+
+ALTER TABLE `brand` ADD COLUMN `version` INT NOT NULL DEFAULT 1;
+
+ALTER TABLE `variant` ADD COLUMN `version` INT NOT NULL DEFAULT 1;
+
+ALTER TABLE `products` ADD COLUMN `version` INT NOT NULL DEFAULT 1;
+
+ALTER TABLE `warehouses`
+    ADD COLUMN `updatedAt` TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN `updatedBy` VARCHAR(36) NULL DEFAULT NULL,
+    ADD COLUMN `version` INT NOT NULL DEFAULT 1;
+
+ALTER TABLE `quantity` ADD COLUMN `version` INT NOT NULL DEFAULT 1;
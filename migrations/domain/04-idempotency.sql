@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `idempotency_keys` (
+    `idempotency_key` VARCHAR(255) NOT NULL,
+    `fingerprint` CHAR(64) NOT NULL,
+    `completed` BOOLEAN NOT NULL DEFAULT FALSE,
+    `status_code` INT NULL DEFAULT NULL,
+    `response_header` TEXT NULL DEFAULT NULL,
+    `response_body` MEDIUMBLOB NULL DEFAULT NULL,
+    `created` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `expires` TIMESTAMP NOT NULL,
+    PRIMARY KEY (`idempotency_key`),
+    INDEX `idx_idempotency_keys_1` (`expires`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `outbox` (
+    `entity_id` CHAR(36) NOT NULL,
+    `topic` VARCHAR(255) NOT NULL,
+    `event_type` VARCHAR(255) NOT NULL,
+    `message_group_id` VARCHAR(128) NULL DEFAULT NULL,
+    `payload` MEDIUMBLOB NOT NULL,
+    `status` ENUM('pending', 'sent', 'failed') NOT NULL DEFAULT 'pending',
+    `attempts` INT NOT NULL DEFAULT 0,
+    `last_error` TEXT NULL DEFAULT NULL,
+    `created` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    `next_attempt` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    `sent` TIMESTAMP NULL DEFAULT NULL,
+    PRIMARY KEY (`entity_id`),
+    INDEX `idx_outbox_1` (`status`, `next_attempt`),
+    INDEX `idx_outbox_2` (`status`, `created`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS `failed_events` (
+    `entity_id` CHAR(36) NOT NULL,
+    `source` VARCHAR(255) NOT NULL,
+    `message_id` VARCHAR(128) NOT NULL,
+    `message_group_id` VARCHAR(128) NULL DEFAULT NULL,
+    `body` MEDIUMTEXT NOT NULL,
+    `reason` TEXT NOT NULL,
+    `receive_count` INT NOT NULL DEFAULT 0,
+    `replay_count` INT NOT NULL DEFAULT 0,
+    `created` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    `replayed` TIMESTAMP NULL DEFAULT NULL,
+    PRIMARY KEY (`entity_id`),
+    INDEX `idx_failed_events_1` (`replayed`, `created`),
+    INDEX `idx_failed_events_2` (`message_id`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
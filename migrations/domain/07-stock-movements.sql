@@ -0,0 +1,18 @@
+ALTER TABLE `quantity` ADD COLUMN `reserved` INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS `stock_movements` (
+    `movementId` VARCHAR(36) NOT NULL,
+    `messageId` VARCHAR(128) NOT NULL,
+    `orderId` VARCHAR(36) NOT NULL,
+    `quantityId` VARCHAR(36) NOT NULL,
+    `productId` VARCHAR(36) NOT NULL,
+    `warehouseId` VARCHAR(36) NOT NULL,
+    `type` VARCHAR(16) NOT NULL,
+    `quantity` INT NOT NULL,
+    `createdAt` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`movementId`),
+    UNIQUE KEY `uk_stock_movements_1` (`messageId`, `quantityId`),
+    INDEX `idx_stock_movements_1` (`orderId`),
+    FOREIGN KEY (`quantityId`) REFERENCES `quantity`(`quantityId`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `processed_events` (
+    `handler` VARCHAR(64) NOT NULL,
+    `message_id` VARCHAR(128) NOT NULL,
+    `status` VARCHAR(16) NOT NULL,
+    `reason` TEXT NULL DEFAULT NULL,
+    `attempts` INT NOT NULL DEFAULT 1,
+    `created` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    `expires` TIMESTAMP NOT NULL,
+    PRIMARY KEY (`handler`, `message_id`),
+    INDEX `idx_processed_events_1` (`expires`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
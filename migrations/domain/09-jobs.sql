@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `jobs` (
+    `id` VARCHAR(36) NOT NULL,
+    `topic` VARCHAR(128) NOT NULL,
+    `payload` MEDIUMBLOB NOT NULL,
+    `unique_key` VARCHAR(255) NULL DEFAULT NULL,
+    `status` VARCHAR(16) NOT NULL,
+    `attempts` INT NOT NULL DEFAULT 0,
+    `last_error` TEXT NULL DEFAULT NULL,
+    `run_at` TIMESTAMP NOT NULL,
+    `locked_until` TIMESTAMP NULL DEFAULT NULL,
+    `created` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_jobs_1` (`topic`, `unique_key`),
+    INDEX `idx_jobs_1` (`status`, `run_at`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
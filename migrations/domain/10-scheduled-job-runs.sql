@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `scheduled_job_runs` (
+    `entity_id` VARCHAR(36) NOT NULL,
+    `job` VARCHAR(64) NOT NULL,
+    `triggered_by` VARCHAR(16) NOT NULL,
+    `status` VARCHAR(16) NOT NULL,
+    `error` TEXT NULL DEFAULT NULL,
+    `host` VARCHAR(255) NOT NULL,
+    `started` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `finished` TIMESTAMP NULL DEFAULT NULL,
+    `duration_ms` BIGINT NULL DEFAULT NULL,
+    PRIMARY KEY (`entity_id`),
+    INDEX `idx_scheduled_job_runs_1` (`job`, `started`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `oauth_refresh_tokens` (
+    `refresh_token` VARCHAR(40) NOT NULL,
+    `family_id` VARCHAR(36) NOT NULL,
+    `client_id` VARCHAR(32) NOT NULL,
+    `user_id` VARCHAR(20) NULL,
+    `scope` VARCHAR(2000) NULL,
+    `expires` TIMESTAMP NOT NULL,
+    `revoked` TIMESTAMP NULL DEFAULT NULL,
+    `created` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`refresh_token`),
+    INDEX `idx_oauth_refresh_tokens_1` (`family_id`),
+    INDEX `idx_oauth_refresh_tokens_2` (`expires`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
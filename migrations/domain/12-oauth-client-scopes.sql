@@ -0,0 +1,3 @@
+UPDATE `oauth_clients`
+SET `scope` = 'catalog:read catalog:write inventory:read inventory:adjust warehouses:admin users:admin system:admin'
+WHERE `client_id` = 'client_web';
@@ -0,0 +1,2 @@
+ALTER TABLE `user`
+    ADD COLUMN `password` VARCHAR(60) NULL DEFAULT NULL AFTER `email`;
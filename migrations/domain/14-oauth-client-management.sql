@@ -0,0 +1,11 @@
+-- client secrets are stored as bcrypt hashes
+ALTER TABLE `oauth_clients`
+    MODIFY COLUMN `client_secret` VARCHAR(60) NOT NULL,
+    ADD COLUMN `created` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN `secret_rotated` TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN `disabled` TIMESTAMP NULL DEFAULT NULL;
+
+-- the hash of the seeded secret; other clients' secrets must be rotated
+UPDATE `oauth_clients`
+SET `client_secret` = '$2a$10$1rg7qUFW9QQnaCxfXg.oZeociYzO5c3SU6GVmEQmfdS12Q4pwX0vq'
+WHERE `client_id` = 'client_web' AND `client_secret` = '3v3rm0s';
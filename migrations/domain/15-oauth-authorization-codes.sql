@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `oauth_authorization_codes` (
+    `code` VARCHAR(40) NOT NULL,
+    `client_id` VARCHAR(32) NOT NULL,
+    `user_id` VARCHAR(36) NOT NULL,
+    `redirect_uri` VARCHAR(1000) NULL,
+    `scope` VARCHAR(2000) NULL,
+    `code_challenge` VARCHAR(128) NOT NULL,
+    `expires` TIMESTAMP NOT NULL,
+    `created` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`code`),
+    INDEX `idx_oauth_authorization_codes_1` (`expires`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
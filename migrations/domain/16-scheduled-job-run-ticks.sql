@@ -0,0 +1,4 @@
+-- scheduled runs record the tick they run, which runs once across replicas
+ALTER TABLE `scheduled_job_runs`
+    ADD COLUMN `scheduled_at` TIMESTAMP NULL DEFAULT NULL AFTER `host`,
+    ADD UNIQUE INDEX `idx_scheduled_job_runs_2` (`job`, `scheduled_at`);
@@ -0,0 +1,6 @@
+-- tokens are issued to users by their UUID
+ALTER TABLE `oauth_access_token`
+    MODIFY COLUMN `user_id` VARCHAR(36) NULL;
+
+ALTER TABLE `oauth_refresh_tokens`
+    MODIFY COLUMN `user_id` VARCHAR(36) NULL;
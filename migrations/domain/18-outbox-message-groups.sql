@@ -0,0 +1,3 @@
+-- the relay holds the messages queued behind a group message awaiting a retry
+ALTER TABLE `outbox`
+    ADD INDEX `idx_outbox_3` (`message_group_id`, `status`, `created`);
@@ -0,0 +1,15 @@
+-- access tokens issued along a refresh token are deleted with its family
+ALTER TABLE `oauth_access_token`
+    ADD COLUMN `family_id` VARCHAR(36) NULL DEFAULT NULL,
+    ADD INDEX `idx_oauth_access_token_1` (`family_id`);
+
+-- JWT access tokens are verified without the store, so revoked ones, and
+-- those of revoked refresh token families, are denied until they expire
+CREATE TABLE IF NOT EXISTS `oauth_revoked_tokens` (
+    `token_id` VARCHAR(36) NOT NULL,
+    `expires` TIMESTAMP NOT NULL,
+    `created` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (`token_id`),
+    INDEX `idx_oauth_revoked_tokens_1` (`expires`)
+) ENGINE=InnoDB
+DEFAULT CHARSET=utf8;
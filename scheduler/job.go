@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/evermos/boilerplate-go/internal/jobs"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+// Job is a task run periodically by the Scheduler.
+type Job interface {
+	// Name identifies the job, and its lock across replicas.
+	Name() string
+	// Schedule is the cron expression the job runs on. Jobs without one only
+	// run when triggered.
+	Schedule() string
+	// Run runs the job. ctx is done once the Scheduler stops.
+	Run(ctx context.Context) error
+}
+
+// DomainJobs is a struct that contains all scheduled jobs.
+type DomainJobs struct {
+	OAuthTokenCleanup jobs.OAuthTokenCleanup
+}
+
+// All lists the scheduled jobs.
+func (d DomainJobs) All() []Job {
+	return []Job{
+		d.OAuthTokenCleanup,
+	}
+}
+
+// RunStatus is the outcome of a Run.
+type RunStatus string
+
+const (
+	// RunStatusRunning indicates a Run that has not finished yet.
+	RunStatusRunning RunStatus = "running"
+	// RunStatusSucceeded indicates a Run that finished without error.
+	RunStatusSucceeded RunStatus = "succeeded"
+	// RunStatusFailed indicates a Run that finished with an error.
+	RunStatusFailed RunStatus = "failed"
+)
+
+// RunTrigger is what started a Run.
+type RunTrigger string
+
+const (
+	// RunTriggerSchedule indicates a Run started by the job's schedule.
+	RunTriggerSchedule RunTrigger = "schedule"
+	// RunTriggerManual indicates a Run triggered through the admin API.
+	RunTriggerManual RunTrigger = "manual"
+)
+
+// Run is a recorded run of a Job.
+type Run struct {
+	ID             uuid.UUID   `db:"entity_id"`
+	Job            string      `db:"job"`
+	Trigger        RunTrigger  `db:"triggered_by"`
+	Status         RunStatus   `db:"status"`
+	Error          null.String `db:"error"`
+	Host           string      `db:"host"`
+	ScheduledAt    null.Time   `db:"scheduled_at"`
+	Started        time.Time   `db:"started"`
+	Finished       null.Time   `db:"finished"`
+	DurationMillis null.Int    `db:"duration_ms"`
+}
+
+// RunResponseFormat represents a Run's standard formatting for JSON
+// serializing.
+type RunResponseFormat struct {
+	ID             uuid.UUID  `json:"id"`
+	Job            string     `json:"job"`
+	Trigger        RunTrigger `json:"trigger"`
+	Status         RunStatus  `json:"status"`
+	Error          *string    `json:"error,omitempty"`
+	Host           string     `json:"host"`
+	ScheduledAt    null.Time  `json:"scheduledAt,omitempty"`
+	Started        time.Time  `json:"started"`
+	Finished       null.Time  `json:"finished,omitempty"`
+	DurationMillis *int64     `json:"durationMillis,omitempty"`
+}
+
+// NewRun creates a running Run of a job.
+func (r Run) NewRun(job string, trigger RunTrigger, host string, scheduledAt null.Time) (newRun Run, err error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	newRun = Run{
+		ID:          id,
+		Job:         job,
+		Trigger:     trigger,
+		Status:      RunStatusRunning,
+		Host:        host,
+		ScheduledAt: scheduledAt,
+		Started:     time.Now(),
+	}
+	return
+}
+
+// Finish records the outcome of this Run.
+func (r *Run) Finish(err error) {
+	now := time.Now()
+	r.Finished = null.TimeFrom(now)
+	r.DurationMillis = null.IntFrom(now.Sub(r.Started).Milliseconds())
+
+	r.Status = RunStatusSucceeded
+	if err != nil {
+		r.Status = RunStatusFailed
+		r.Error = null.StringFrom(err.Error())
+	}
+}
+
+// MarshalJSON overrides the standard JSON formatting.
+func (r Run) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.ToResponseFormat())
+}
+
+// ToResponseFormat converts this Run to its response format.
+func (r Run) ToResponseFormat() RunResponseFormat {
+	return RunResponseFormat{
+		ID:             r.ID,
+		Job:            r.Job,
+		Trigger:        r.Trigger,
+		Status:         r.Status,
+		Error:          r.Error.Ptr(),
+		Host:           r.Host,
+		ScheduledAt:    r.ScheduledAt,
+		Started:        r.Started,
+		Finished:       r.Finished,
+		DurationMillis: r.DurationMillis.Ptr(),
+	}
+}
+
+// JobStatus describes a Job and when it runs.
+type JobStatus struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule,omitempty"`
+	NextRun  null.Time `json:"nextRun,omitempty"`
+	LastRun  *Run      `json:"lastRun,omitempty"`
+}
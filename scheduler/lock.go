@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/infras"
+)
+
+const (
+	// LockTypeRedis selects the Redis-backed Locker.
+	LockTypeRedis = "redis"
+
+	lockPrefix          = "scheduler:"
+	defaultRedisLockTTL = time.Hour
+)
+
+// Locker holds the locks making sure that only one replica runs a job at a
+// time.
+type Locker interface {
+	// TryLock acquires the named lock without waiting for it. The lock is held
+	// until unlock is called.
+	TryLock(ctx context.Context, name string) (unlock func(), acquired bool, err error)
+}
+
+// ProvideLocker is the provider for Locker. The backing store is selected
+// through configuration.
+func ProvideLocker(config *configs.Config, db *infras.MySQLConn) Locker {
+	switch config.Scheduler.Lock {
+	case LockTypeRedis:
+		ttl := time.Duration(config.Scheduler.LockTTLSeconds) * time.Second
+		if ttl <= 0 {
+			ttl = defaultRedisLockTTL
+		}
+		return NewLockerRedis(infras.RedisNewClient(*config), ttl)
+	default:
+		return NewLockerMySQL(db)
+	}
+}
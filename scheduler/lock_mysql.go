@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	queryGetLock     = "SELECT COALESCE(GET_LOCK(?, 0), 0)"
+	queryReleaseLock = "SELECT RELEASE_LOCK(?)"
+)
+
+// LockerMySQL is the MySQL-backed implementation of Locker, using named locks.
+type LockerMySQL struct {
+	db *infras.MySQLConn
+}
+
+// NewLockerMySQL creates a new LockerMySQL.
+func NewLockerMySQL(db *infras.MySQLConn) *LockerMySQL {
+	return &LockerMySQL{db: db}
+}
+
+// TryLock acquires a named lock with GET_LOCK. Named locks belong to the
+// session, so a connection is held until the lock is released; a replica
+// dying releases its locks along with its connections.
+func (l *LockerMySQL) TryLock(ctx context.Context, name string) (unlock func(), acquired bool, err error) {
+	conn, err := l.db.Write.Conn(ctx)
+	if err != nil {
+		return
+	}
+
+	err = conn.QueryRowContext(ctx, queryGetLock, lockPrefix+name).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Close()
+		return
+	}
+
+	unlock = func() {
+		// the job's context may be done by now
+		_, err := conn.ExecContext(context.Background(), queryReleaseLock, lockPrefix+name)
+		if err != nil {
+			log.Error().Err(err).Str("lock", name).Msg("failed releasing lock")
+		}
+		conn.Close()
+	}
+	return
+}
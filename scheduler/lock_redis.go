@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// releaseLockScript deletes a lock only when it is still held by the token,
+// so that a lock taken over after expiring is not released.
+var releaseLockScript = redis.NewScript(`
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	end
+	return 0`)
+
+// LockerRedis is the Redis-backed implementation of Locker. Locks expire
+// after their TTL, which must exceed the longest run of a job.
+type LockerRedis struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewLockerRedis creates a new LockerRedis.
+func NewLockerRedis(client *redis.Client, ttl time.Duration) *LockerRedis {
+	return &LockerRedis{client: client, ttl: ttl}
+}
+
+// TryLock acquires a lock using SETNX with a random token.
+func (l *LockerRedis) TryLock(ctx context.Context, name string) (unlock func(), acquired bool, err error) {
+	token, err := uuid.NewV4()
+	if err != nil {
+		return
+	}
+
+	key := lockPrefix + name
+	acquired, err = l.client.SetNX(key, token.String(), l.ttl).Result()
+	if err != nil || !acquired {
+		return
+	}
+
+	unlock = func() {
+		err := releaseLockScript.Run(l.client, []string{key}, token.String()).Err()
+		if err != nil {
+			log.Error().Err(err).Str("lock", name).Msg("failed releasing lock")
+		}
+	}
+	return
+}
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"errors"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/go-sql-driver/mysql"
+)
+
+const mysqlErrorDuplicateEntry = 1062
+
+// ErrRunScheduled is returned when creating a Run of a scheduled tick that
+// another replica runs already.
+var ErrRunScheduled = errors.New("scheduled run exists")
+
+var (
+	runQueries = struct {
+		selectRun string
+		insertRun string
+		updateRun string
+	}{
+		selectRun: `
+			SELECT
+				entity_id,
+				job,
+				triggered_by,
+				status,
+				error,
+				host,
+				scheduled_at,
+				started,
+				finished,
+				duration_ms
+			FROM scheduled_job_runs `,
+
+		insertRun: `
+			INSERT INTO scheduled_job_runs (
+				entity_id,
+				job,
+				triggered_by,
+				status,
+				host,
+				scheduled_at,
+				started
+			) VALUES (
+				:entity_id,
+				:job,
+				:triggered_by,
+				:status,
+				:host,
+				:scheduled_at,
+				:started)`,
+
+		updateRun: `
+			UPDATE scheduled_job_runs
+			SET
+				status = :status,
+				error = :error,
+				finished = :finished,
+				duration_ms = :duration_ms
+			WHERE entity_id = :entity_id`,
+	}
+)
+
+// RunRepository lays out the contract for the run history.
+type RunRepository interface {
+	// Create stores a Run. It fails with ErrRunScheduled when the scheduled
+	// tick of the Run is recorded already.
+	Create(run Run) (err error)
+	ResolveByJob(job string, limit int) (runs []Run, err error)
+	Update(run Run) (err error)
+}
+
+// RunRepositoryMySQL is the MySQL-backed implementation of RunRepository,
+// storing into the scheduled_job_runs table.
+type RunRepositoryMySQL struct {
+	DB *infras.MySQLConn
+}
+
+// ProvideRunRepositoryMySQL is the provider for this repository.
+func ProvideRunRepositoryMySQL(db *infras.MySQLConn) *RunRepositoryMySQL {
+	return &RunRepositoryMySQL{DB: db}
+}
+
+// Create stores a Run. Scheduled ticks are unique per job.
+func (r *RunRepositoryMySQL) Create(run Run) (err error) {
+	stmt, err := r.DB.Write.PrepareNamed(runQueries.insertRun)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(run)
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlErrorDuplicateEntry {
+		return ErrRunScheduled
+	}
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// ResolveByJob resolves the latest Runs of a job, newest first.
+func (r *RunRepositoryMySQL) ResolveByJob(job string, limit int) (runs []Run, err error) {
+	runs = make([]Run, 0)
+	err = r.DB.Read.Select(&runs, runQueries.selectRun+"WHERE job = ? ORDER BY started DESC LIMIT ?", job, limit)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
+
+// Update records the outcome of a Run.
+func (r *RunRepositoryMySQL) Update(run Run) (err error) {
+	stmt, err := r.DB.Write.PrepareNamed(runQueries.updateRun)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		return
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(run)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+
+	return
+}
@@ -0,0 +1,238 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/guregu/null"
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+)
+
+// Scheduler runs jobs on their cron schedules. Each run takes the job's lock,
+// so that only one replica runs a job at a time, and is recorded with its
+// duration and outcome. Scheduled runs record their tick as well, so that a
+// tick runs once even when a replica finishes it before another one fires.
+type Scheduler struct {
+	Config        *configs.Config
+	Locker        Locker
+	RunRepository RunRepository
+
+	jobs     map[string]Job
+	names    []string
+	schedule map[string]cron.Schedule
+	cron     *cron.Cron
+	host     string
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	running  sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// ProvideScheduler is the provider for Scheduler.
+func ProvideScheduler(config *configs.Config, domainJobs DomainJobs, locker Locker, runRepository RunRepository) *Scheduler {
+	s, err := NewScheduler(config, locker, runRepository, domainJobs.All()...)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed scheduling jobs")
+	}
+	return s
+}
+
+// NewScheduler creates a Scheduler of the jobs, failing on invalid schedules.
+func NewScheduler(config *configs.Config, locker Locker, runRepository RunRepository, jobs ...Job) (*Scheduler, error) {
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		Config:        config,
+		Locker:        locker,
+		RunRepository: runRepository,
+		jobs:          make(map[string]Job),
+		schedule:      make(map[string]cron.Schedule),
+		cron:          cron.New(),
+		host:          host,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+
+	for _, job := range jobs {
+		job := job
+		s.jobs[job.Name()] = job
+		s.names = append(s.names, job.Name())
+		if job.Schedule() == "" {
+			continue
+		}
+
+		schedule, err := cron.ParseStandard(job.Schedule())
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("invalid schedule of job %s: %w", job.Name(), err)
+		}
+		s.schedule[job.Name()] = schedule
+		s.cron.Schedule(schedule, cron.FuncJob(func() {
+			// ticks fall on whole seconds, the cron fires shortly after
+			s.runScheduled(job, time.Now().Truncate(time.Second))
+		}))
+	}
+
+	return s, nil
+}
+
+// Start starts running the jobs on their schedules.
+func (s *Scheduler) Start() {
+	if !s.Config.Scheduler.Enabled {
+		log.Info().Msg("Scheduler is disabled.")
+		return
+	}
+
+	log.Info().Strs("jobs", s.names).Msg("Scheduler started.")
+	s.cron.Start()
+}
+
+// Stop stops scheduling runs, cancels the context of the running jobs and
+// waits for them to return.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		stopped := s.cron.Stop()
+		s.cancel()
+		<-stopped.Done()
+		s.running.Wait()
+	})
+}
+
+// Jobs describes the jobs along their next and last runs.
+func (s *Scheduler) Jobs() (statuses []JobStatus, err error) {
+	statuses = make([]JobStatus, 0, len(s.names))
+	for _, name := range s.names {
+		status := JobStatus{
+			Name:     name,
+			Schedule: s.jobs[name].Schedule(),
+		}
+		if schedule, ok := s.schedule[name]; ok && s.Config.Scheduler.Enabled {
+			status.NextRun = null.TimeFrom(schedule.Next(time.Now()))
+		}
+
+		runs, err := s.RunRepository.ResolveByJob(name, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			status.LastRun = &runs[0]
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return
+}
+
+// Runs resolves the latest runs of a job.
+func (s *Scheduler) Runs(name string, limit int) (runs []Run, err error) {
+	if _, ok := s.jobs[name]; !ok {
+		return nil, failure.NotFound("job")
+	}
+
+	return s.RunRepository.ResolveByJob(name, limit)
+}
+
+// Trigger runs a job now, in the background. It fails when the job is
+// running already, here or on another replica.
+func (s *Scheduler) Trigger(name string) (run Run, err error) {
+	job, ok := s.jobs[name]
+	if !ok {
+		return run, failure.NotFound("job")
+	}
+
+	unlock, run, err := s.begin(job, RunTriggerManual, null.Time{})
+	if err != nil {
+		return
+	}
+	if unlock == nil {
+		return run, failure.Conflict("trigger", "job", "it is running already")
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer unlock()
+		s.execute(job, run)
+	}()
+
+	return
+}
+
+func (s *Scheduler) runScheduled(job Job, scheduledAt time.Time) {
+	unlock, run, err := s.begin(job, RunTriggerSchedule, null.TimeFrom(scheduledAt))
+	if err == ErrRunScheduled {
+		log.Debug().Str("job", job.Name()).Time("scheduledAt", scheduledAt).Msg("job ran on another replica, skipping")
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("job", job.Name()).Msg("failed starting job")
+		return
+	}
+	if unlock == nil {
+		log.Debug().Str("job", job.Name()).Msg("job is running on another replica, skipping")
+		return
+	}
+	defer unlock()
+
+	s.execute(job, run)
+}
+
+// begin takes the lock of a job and records its run, of a scheduled tick when
+// scheduledAt is valid. unlock is nil when the lock is held by another run, or
+// on ErrRunScheduled when the tick ran already.
+func (s *Scheduler) begin(job Job, trigger RunTrigger, scheduledAt null.Time) (unlock func(), run Run, err error) {
+	unlock, acquired, err := s.Locker.TryLock(s.ctx, job.Name())
+	if err != nil || !acquired {
+		return nil, run, err
+	}
+
+	run, err = Run{}.NewRun(job.Name(), trigger, s.host, scheduledAt)
+	if err == nil {
+		err = s.RunRepository.Create(run)
+	}
+	if err != nil {
+		unlock()
+		return nil, run, err
+	}
+
+	return
+}
+
+func (s *Scheduler) execute(job Job, run Run) {
+	err := s.safeRun(job)
+	run.Finish(err)
+
+	event := log.Info()
+	if err != nil {
+		event = log.Error().Err(err)
+	}
+	event.
+		Str("job", job.Name()).
+		Str("trigger", string(run.Trigger)).
+		Int64("durationMillis", run.DurationMillis.Int64).
+		Msg("Job finished.")
+
+	err = s.RunRepository.Update(run)
+	if err != nil {
+		log.Error().Err(err).Str("job", job.Name()).Msg("failed recording job run")
+	}
+}
+
+// safeRun runs a job, turning a panic into its error.
+func (s *Scheduler) safeRun(job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return job.Run(s.ctx)
+}
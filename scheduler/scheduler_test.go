@@ -0,0 +1,230 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeJob struct {
+	name     string
+	schedule string
+	run      func(ctx context.Context) error
+}
+
+func (j fakeJob) Name() string                  { return j.name }
+func (j fakeJob) Schedule() string              { return j.schedule }
+func (j fakeJob) Run(ctx context.Context) error { return j.run(ctx) }
+
+type fakeLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+	}, true, nil
+}
+
+type fakeRunRepository struct {
+	mu   sync.Mutex
+	runs []Run
+}
+
+func (r *fakeRunRepository) Create(run Run) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.runs {
+		if run.ScheduledAt.Valid && existing.Job == run.Job && existing.ScheduledAt.Time.Equal(run.ScheduledAt.Time) {
+			return ErrRunScheduled
+		}
+	}
+	r.runs = append([]Run{run}, r.runs...)
+	return nil
+}
+
+func (r *fakeRunRepository) ResolveByJob(job string, limit int) (runs []Run, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, run := range r.runs {
+		if run.Job == job && len(runs) < limit {
+			runs = append(runs, run)
+		}
+	}
+	return
+}
+
+func (r *fakeRunRepository) Update(run Run) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.runs {
+		if r.runs[i].ID == run.ID {
+			r.runs[i] = run
+		}
+	}
+	return nil
+}
+
+func newTestScheduler(t *testing.T, jobs ...Job) (*Scheduler, *fakeLocker, *fakeRunRepository) {
+	locker := &fakeLocker{held: make(map[string]bool)}
+	repository := &fakeRunRepository{}
+	config := &configs.Config{}
+	config.Scheduler.Enabled = true
+
+	s, err := NewScheduler(config, locker, repository, jobs...)
+	require.NoError(t, err)
+	t.Cleanup(s.Stop)
+
+	return s, locker, repository
+}
+
+func TestSchedulerTrigger(t *testing.T) {
+	t.Run("records the outcome of runs", func(t *testing.T) {
+		s, _, repository := newTestScheduler(t,
+			fakeJob{name: "ok", run: func(ctx context.Context) error { return nil }},
+			fakeJob{name: "failing", run: func(ctx context.Context) error { return errors.New("boom") }},
+			fakeJob{name: "panicking", run: func(ctx context.Context) error { panic("boom") }},
+		)
+
+		for _, name := range []string{"ok", "failing", "panicking"} {
+			run, err := s.Trigger(name)
+			require.NoError(t, err)
+			assert.Equal(t, RunTriggerManual, run.Trigger)
+			assert.Equal(t, RunStatusRunning, run.Status)
+		}
+		s.Stop()
+
+		statuses := map[string]RunStatus{}
+		for _, run := range repository.runs {
+			statuses[run.Job] = run.Status
+			assert.True(t, run.Finished.Valid)
+		}
+		assert.Equal(t, map[string]RunStatus{
+			"ok":        RunStatusSucceeded,
+			"failing":   RunStatusFailed,
+			"panicking": RunStatusFailed,
+		}, statuses)
+	})
+
+	t.Run("rejects jobs that are running", func(t *testing.T) {
+		s, locker, _ := newTestScheduler(t, fakeJob{name: "job", run: func(ctx context.Context) error { return nil }})
+		_, _, _ = locker.TryLock(context.Background(), "job")
+
+		_, err := s.Trigger("job")
+		assert.Equal(t, http.StatusConflict, failure.GetCode(err))
+	})
+
+	t.Run("rejects unknown jobs", func(t *testing.T) {
+		s, _, _ := newTestScheduler(t)
+
+		_, err := s.Trigger("job")
+		assert.Equal(t, http.StatusNotFound, failure.GetCode(err))
+
+		_, err = s.Runs("job", 1)
+		assert.Equal(t, http.StatusNotFound, failure.GetCode(err))
+	})
+
+	t.Run("cancels running jobs on stop", func(t *testing.T) {
+		started := make(chan struct{})
+		s, _, repository := newTestScheduler(t, fakeJob{name: "job", run: func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		}})
+
+		_, err := s.Trigger("job")
+		require.NoError(t, err)
+		<-started
+		s.Stop()
+
+		assert.Equal(t, RunStatusFailed, repository.runs[0].Status)
+	})
+}
+
+func TestSchedulerRunScheduled(t *testing.T) {
+	t.Run("runs a tick once across replicas", func(t *testing.T) {
+		var runs int32
+		job := fakeJob{name: "job", schedule: "@hourly", run: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}}
+		first, locker, repository := newTestScheduler(t, job)
+		second, err := NewScheduler(first.Config, locker, repository, job)
+		require.NoError(t, err)
+		t.Cleanup(second.Stop)
+
+		// the first replica finishes the tick before the second one fires
+		scheduledAt := time.Now().Truncate(time.Second)
+		first.runScheduled(job, scheduledAt)
+		second.runScheduled(job, scheduledAt)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+		second.runScheduled(job, scheduledAt.Add(time.Hour))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+		assert.Empty(t, locker.held)
+	})
+
+	t.Run("skips ticks while the job is running", func(t *testing.T) {
+		var runs int32
+		job := fakeJob{name: "job", schedule: "@hourly", run: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}}
+		s, locker, repository := newTestScheduler(t, job)
+		_, _, _ = locker.TryLock(context.Background(), "job")
+
+		s.runScheduled(job, time.Now().Truncate(time.Second))
+		assert.Zero(t, atomic.LoadInt32(&runs))
+		assert.Empty(t, repository.runs)
+	})
+}
+
+func TestSchedulerJobs(t *testing.T) {
+	s, _, _ := newTestScheduler(t,
+		fakeJob{name: "hourly", schedule: "@hourly", run: func(ctx context.Context) error { return nil }},
+		fakeJob{name: "manual", run: func(ctx context.Context) error { return nil }},
+	)
+	_, err := s.Trigger("hourly")
+	require.NoError(t, err)
+	s.Stop()
+
+	statuses, err := s.Jobs()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+
+	assert.Equal(t, "hourly", statuses[0].Name)
+	assert.True(t, statuses[0].NextRun.Time.After(time.Now()))
+	require.NotNil(t, statuses[0].LastRun)
+	assert.Equal(t, RunStatusSucceeded, statuses[0].LastRun.Status)
+
+	assert.Equal(t, "manual", statuses[1].Name)
+	assert.False(t, statuses[1].NextRun.Valid)
+	assert.Nil(t, statuses[1].LastRun)
+}
+
+func TestNewSchedulerInvalidSchedule(t *testing.T) {
+	_, err := NewScheduler(&configs.Config{}, &fakeLocker{}, &fakeRunRepository{},
+		fakeJob{name: "job", schedule: "every minute"})
+
+	assert.Error(t, err)
+}
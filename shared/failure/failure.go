@@ -43,6 +43,15 @@ func Unauthorized(msg string) error {
 	}
 }
 
+// Forbidden returns a new Failure with code for authenticated requests that
+// lack permissions.
+func Forbidden(msg string) error {
+	return &Failure{
+		Code:    http.StatusForbidden,
+		Message: msg,
+	}
+}
+
 // InternalError returns a new Failure with code for internal error and message derived from an error interface.
 func InternalError(err error) error {
 	if err != nil {
@@ -78,6 +87,24 @@ func Conflict(operationName string, entityName string, message string) error {
 	}
 }
 
+// PreconditionFailed returns a new Failure with code for requests whose
+// preconditions, such as an If-Match version, no longer hold.
+func PreconditionFailed(entityName string, message string) error {
+	return &Failure{
+		Code:    http.StatusPreconditionFailed,
+		Message: fmt.Sprintf("%s: %s", entityName, message),
+	}
+}
+
+// PreconditionRequired returns a new Failure with code for requests that are
+// missing a mandatory precondition header.
+func PreconditionRequired(headerName string) error {
+	return &Failure{
+		Code:    http.StatusPreconditionRequired,
+		Message: fmt.Sprintf("missing %s header", headerName),
+	}
+}
+
 // GetCode returns the error code of an error interface.
 func GetCode(err error) int {
 	if f, ok := err.(*Failure); ok {
@@ -1,6 +1,9 @@
 package oauth
 
 import (
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -9,6 +12,8 @@ type GrantType string
 const (
 	ClientCredentials GrantType = "client_credentials"
 	Password          GrantType = "password"
+	RefreshToken      GrantType = "refresh_token"
+	AuthorizationCode GrantType = "authorization_code"
 )
 
 type Token struct {
@@ -23,9 +28,31 @@ func New(db *sqlx.DB, config Config) *Token {
 	}
 }
 
+const (
+	// TokenFormatOpaque issues random access tokens, resolved from the store.
+	TokenFormatOpaque = "opaque"
+	// TokenFormatJWT issues JWT access tokens, signed by the KeySet. They are
+	// verified without resolving them from the store, which only keeps the
+	// revoked ones.
+	TokenFormatJWT = "jwt"
+)
+
 type Config struct {
-	Expiration  int64
-	ClientScope []string
+	Expiration        int64
+	RefreshExpiration int64
+	CodeExpiration    int64
+	ClientScope       []string
+
+	// TokenFormat is the format of issued access tokens, opaque by default.
+	TokenFormat string
+	// KeySet signs JWT access tokens, and verifies them whatever the format
+	// of issued tokens.
+	KeySet *KeySet
+	// Lockout protects the logins of users against brute force, unless nil.
+	Lockout *Lockout
+	// RevocationList checks verified JWT access tokens, instead of the
+	// TokenStore when set.
+	RevocationList RevocationList
 }
 
 // Create is function to store NewToken into database
@@ -38,9 +65,124 @@ func (t *Token) Create(credential Credential) (*TokenResponse, error) {
 	return grant.toCreateTokenResponse(), nil
 }
 
+// ValidateAuthorization is function to validate an authorization request, returning its client
+func (t *Token) ValidateAuthorization(request AuthorizationRequest) (OauthClient, error) {
+	return NewAuthorization(t.tokenRepository, t.config).Validate(request)
+}
+
+// Authorize is function to issue an authorization code to the client of a request approved by the user of a credential
+func (t *Token) Authorize(request AuthorizationRequest, credential Credential) (OauthAuthorizationCode, error) {
+	return NewAuthorization(t.tokenRepository, t.config).Approve(request, credential)
+}
+
 // ParseWithAccessToken is function to exchange valid token into token info
 func (t *Token) ParseWithAccessToken(accessToken string) (OauthAccessToken, error) {
-	return NewParser(t.tokenRepository).Parse(accessToken)
+	parser := NewParser(t.tokenRepository, t.config.KeySet)
+	if t.config.RevocationList != nil {
+		parser.RevocationList = t.config.RevocationList
+	}
+
+	return parser.Parse(accessToken)
+}
+
+// Revoke is function to revoke a token issued to the requesting client
+func (t *Token) Revoke(reference TokenReference) error {
+	return NewRevocation(t.tokenRepository, t.config.KeySet).Revoke(reference)
+}
+
+// Introspect is function to describe a token to the requesting client
+func (t *Token) Introspect(reference TokenReference) (Introspection, error) {
+	return NewRevocation(t.tokenRepository, t.config.KeySet).Introspect(reference)
+}
+
+// PurgeExpired is function to delete the access and refresh tokens, the authorization codes and the revoked tokens, expired before the given time
+func (t *Token) PurgeExpired(before time.Time) (int64, error) {
+	accessTokens, err := t.tokenRepository.deleteExpiredAccessTokens(before)
+	if err != nil {
+		return 0, err
+	}
+
+	refreshTokens, err := t.tokenRepository.deleteExpiredRefreshTokens(before)
+	if err != nil {
+		return accessTokens, err
+	}
+
+	codes, err := t.tokenRepository.deleteExpiredAuthorizationCodes(before)
+	if err != nil {
+		return accessTokens + refreshTokens, err
+	}
+
+	revokedTokens, err := t.tokenRepository.deleteExpiredRevokedTokens(before)
+	if err != nil {
+		return accessTokens + refreshTokens + codes, err
+	}
+
+	return accessTokens + refreshTokens + codes + revokedTokens, nil
+}
+
+// RegisterClient is function to register a new client, returning its secret
+func (t *Token) RegisterClient(req ClientRequestFormat) (OauthClient, string, error) {
+	client, secret, err := OauthClient{}.NewFromRequestFormat(req)
+	if err != nil {
+		return client, "", err
+	}
+
+	err = t.tokenRepository.createClient(client)
+	if err != nil {
+		return client, "", err
+	}
+
+	return client, secret, nil
+}
+
+// RotateClientSecret is function to replace the secret of a client, returning the new one
+func (t *Token) RotateClientSecret(clientID string) (OauthClient, string, error) {
+	client, err := t.resolveClient(clientID)
+	if err != nil {
+		return client, "", err
+	}
+
+	secret, err := client.RotateSecret()
+	if err != nil {
+		return client, "", err
+	}
+
+	err = t.tokenRepository.updateClientSecret(client)
+	if err != nil {
+		return client, "", err
+	}
+
+	return client, secret, nil
+}
+
+// ResolveClients is function to list all clients
+func (t *Token) ResolveClients() ([]OauthClient, error) {
+	return t.tokenRepository.resolveAllClients()
+}
+
+// DisableClient is function to disable a client, revoking the tokens issued to it
+func (t *Token) DisableClient(clientID string) (OauthClient, error) {
+	client, err := t.resolveClient(clientID)
+	if err != nil {
+		return client, err
+	}
+
+	err = client.Disable()
+	if err != nil {
+		return client, err
+	}
+
+	err = t.tokenRepository.disableClient(client)
+	return client, err
+}
+
+func (t *Token) resolveClient(clientID string) (OauthClient, error) {
+	client, err := t.tokenRepository.resolveClientByClientID(clientID)
+	if err != nil && err.Error() == ErrorClientNotFound {
+		err = failure.NotFound("client")
+	}
+
+	return client, err
 }
 
 // ClientScopeAllowed is function that is used to limit the client
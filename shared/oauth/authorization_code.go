@@ -0,0 +1,270 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/guregu/null"
+)
+
+const (
+	// ResponseTypeCode requests an authorization code.
+	ResponseTypeCode = "code"
+	// CodeChallengeMethodS256 is the only code challenge method supported,
+	// RFC 7636 section 4.2.
+	CodeChallengeMethodS256 = "S256"
+)
+
+// codeVerifierPattern matches code verifiers, RFC 7636 section 4.1. S256 code
+// challenges match it as well.
+var codeVerifierPattern = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// AuthorizationRequest is a request for an authorization code, RFC 6749
+// section 4.1.1, along its code challenge, RFC 7636 section 4.3.
+type AuthorizationRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// AuthorizationError is an error of an authorization request. Errors are
+// redirected to the client, unless its redirect URI is unknown.
+type AuthorizationError struct {
+	Err *Error
+	// RedirectURI is the URI to redirect the error to, empty when the client
+	// or the redirect URI of the request is invalid.
+	RedirectURI string
+}
+
+// Error returns the description of the error.
+func (e *AuthorizationError) Error() string {
+	return e.Err.Error()
+}
+
+// OauthAuthorizationCode is an authorization code, exchanged once for an
+// access token by the client it is issued to.
+type OauthAuthorizationCode struct {
+	Code          string      `db:"code"`
+	ClientID      string      `db:"client_id"`
+	UserID        string      `db:"user_id"`
+	RedirectURI   null.String `db:"redirect_uri"`
+	Scope         null.String `db:"scope"`
+	CodeChallenge string      `db:"code_challenge"`
+	Expires       time.Time   `db:"expires"`
+}
+
+// Generate generates the authorization code of an approved request.
+func (o *OauthAuthorizationCode) Generate(request AuthorizationRequest, userID string, scope null.String, config Config) (OauthAuthorizationCode, error) {
+	code, err := generateAccessToken()
+	if err != nil {
+		return OauthAuthorizationCode{}, errors.New(ErrorGenerateAccessToken)
+	}
+
+	o.Code = code
+	o.ClientID = request.ClientID
+	o.UserID = userID
+	// the token request repeats the redirect URI only when it was requested
+	o.RedirectURI = null.NewString(request.RedirectURI, request.RedirectURI != "")
+	o.Scope = scope
+	o.CodeChallenge = request.CodeChallenge
+	o.Expires = time.Now().Add(time.Second * time.Duration(config.CodeExpiration))
+
+	return *o, nil
+}
+
+func (o *OauthAuthorizationCode) VerifyExpireIn() bool {
+	return time.Now().Before(o.Expires)
+}
+
+// VerifyCodeVerifier verifies a code verifier against the S256 code challenge
+// of this code, RFC 7636 section 4.6.
+func (o *OauthAuthorizationCode) VerifyCodeVerifier(codeVerifier string) bool {
+	if !codeVerifierPattern.MatchString(codeVerifier) {
+		return false
+	}
+
+	digest := sha256.Sum256([]byte(codeVerifier))
+	challenge := base64.RawURLEncoding.EncodeToString(digest[:])
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(o.CodeChallenge)) == 1
+}
+
+// Authorization issues authorization codes to the clients users approve.
+type Authorization struct {
+	tokenStore TokenStore
+	config     Config
+}
+
+func NewAuthorization(tokenStore TokenStore, config Config) *Authorization {
+	return &Authorization{
+		tokenStore: tokenStore,
+		config:     config,
+	}
+}
+
+// Validate validates an authorization request, returning its client.
+func (a *Authorization) Validate(request AuthorizationRequest) (client OauthClient, err error) {
+	client, err = a.tokenStore.resolveClientByClientID(request.ClientID)
+	if err != nil {
+		if err.Error() == ErrorClientNotFound {
+			err = &AuthorizationError{Err: NewError(ErrorCodeInvalidRequest, ErrorInvalidClient)}
+		}
+		return
+	}
+
+	if client.IsDisabled() {
+		return client, &AuthorizationError{Err: NewError(ErrorCodeInvalidRequest, ErrorInvalidClient)}
+	}
+
+	// the redirect URI must match the registered one exactly
+	if !client.RedirectURI.Valid || (request.RedirectURI != "" && request.RedirectURI != client.RedirectURI.String) {
+		return client, &AuthorizationError{Err: NewError(ErrorCodeInvalidRequest, ErrorInvalidRedirectURI)}
+	}
+
+	redirect := func(code ErrorCode, description string) error {
+		return &AuthorizationError{Err: NewError(code, description), RedirectURI: client.RedirectURI.String}
+	}
+
+	if request.ResponseType != ResponseTypeCode {
+		return client, redirect(ErrorCodeUnsupportedResponse, "Parameter response_type must be code")
+	}
+
+	if !client.AllowsGrant(AuthorizationCode) {
+		return client, redirect(ErrorCodeUnauthorizedClient, ErrorGrantNotAllowed)
+	}
+
+	if request.CodeChallengeMethod != CodeChallengeMethodS256 || !codeVerifierPattern.MatchString(request.CodeChallenge) {
+		return client, redirect(ErrorCodeInvalidRequest, "Parameters code_challenge and code_challenge_method S256 are required")
+	}
+
+	_, err = grantScope(client, request.Scope, nil)
+	if err != nil {
+		return client, redirect(ErrorCodeInvalidScope, ErrorScopeNotAllowed)
+	}
+
+	return
+}
+
+// Approve authenticates the user approving an authorization request, and
+// issues them an authorization code. It fails with invalid_grant when the
+// user's credential is wrong.
+func (a *Authorization) Approve(request AuthorizationRequest, credential Credential) (code OauthAuthorizationCode, err error) {
+	client, err := a.Validate(request)
+	if err != nil {
+		return
+	}
+
+	user, err := authenticateUser(a.tokenStore, a.config.Lockout, credential)
+	if err != nil {
+		return
+	}
+
+	scope, err := grantScope(client, request.Scope, &user.UserType)
+	if err != nil {
+		return
+	}
+
+	code, err = new(OauthAuthorizationCode).Generate(request, user.ID, scope, a.config)
+	if err != nil {
+		return
+	}
+
+	err = a.tokenStore.createAuthorizationCode(code)
+	return
+}
+
+// RedirectWithCode returns the redirect URI of a client carrying an issued
+// code, RFC 6749 section 4.1.2.
+func (r AuthorizationRequest) RedirectWithCode(redirectURI string, code OauthAuthorizationCode) string {
+	return r.redirect(redirectURI, url.Values{"code": {code.Code}})
+}
+
+// RedirectWithError returns the redirect URI of a client carrying an error,
+// RFC 6749 section 4.1.2.1.
+func (r AuthorizationRequest) RedirectWithError(redirectURI string, err *Error) string {
+	params := url.Values{"error": {string(err.Code)}}
+	if err.Description != "" {
+		params.Set("error_description", err.Description)
+	}
+
+	return r.redirect(redirectURI, params)
+}
+
+func (r AuthorizationRequest) redirect(redirectURI string, params url.Values) string {
+	if r.State != "" {
+		params.Set("state", r.State)
+	}
+
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	// the registered URI may carry a query of its own
+	query := u.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// AuthorizationCodeAuth exchanges an authorization code for an access token,
+// verifying the code verifier of its code challenge.
+type AuthorizationCodeAuth struct {
+	tokenStore TokenStore
+	config     Config
+}
+
+func (c *AuthorizationCodeAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
+	client, err := authenticateClient(c.tokenStore, credential)
+	if err != nil {
+		return
+	}
+
+	if credential.Code == "" || credential.CodeVerifier == "" {
+		err = NewError(ErrorCodeInvalidRequest, "Parameters code and code_verifier are required")
+		return
+	}
+
+	// codes are consumed by the first exchange of their client, successful or
+	// not
+	code, err := c.tokenStore.consumeAuthorizationCode(credential.Code, client.ClientID)
+	if err != nil {
+		return
+	}
+
+	if !code.VerifyExpireIn() ||
+		(code.RedirectURI.Valid && code.RedirectURI.String != credential.RedirectURI) ||
+		!code.VerifyCodeVerifier(credential.CodeVerifier) {
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidCode)
+		return
+	}
+
+	oauthAccessToken = new(OauthAccessToken).Generate("", credential.ClientID, null.StringFrom(code.UserID), code.Scope, c.config)
+
+	// the refresh token is issued first, so that the access token belongs to
+	// its family
+	if client.AllowsGrant(RefreshToken) {
+		err = issueRefreshToken(c.tokenStore, &oauthAccessToken, c.config)
+		if err != nil {
+			return
+		}
+	}
+
+	err = issueAccessToken(c.tokenStore, &oauthAccessToken, c.config)
+	if err != nil {
+		return
+	}
+
+	return
+}
@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOauthAuthorizationCode(t *testing.T) {
+	// the example of RFC 7636 appendix B
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+
+	request := AuthorizationRequest{
+		ResponseType:        ResponseTypeCode,
+		ClientID:            "client_spa",
+		Scope:               PermissionCatalogRead,
+		State:               "xyz",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: CodeChallengeMethodS256,
+	}
+
+	t.Run("generates a code expiring shortly", func(t *testing.T) {
+		code, err := new(OauthAuthorizationCode).Generate(request, "user", null.StringFrom(PermissionCatalogRead), Config{CodeExpiration: 60})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, code.Code)
+		assert.Equal(t, "client_spa", code.ClientID)
+		assert.Equal(t, "user", code.UserID)
+		assert.False(t, code.RedirectURI.Valid)
+		assert.True(t, code.VerifyExpireIn())
+		assert.WithinDuration(t, time.Now().Add(time.Minute), code.Expires, time.Second)
+	})
+
+	t.Run("verifies the code verifier of its challenge", func(t *testing.T) {
+		code := OauthAuthorizationCode{CodeChallenge: challenge}
+
+		assert.True(t, code.VerifyCodeVerifier(verifier))
+		assert.False(t, code.VerifyCodeVerifier(verifier[1:]+"a"))
+		assert.False(t, code.VerifyCodeVerifier(challenge))
+		assert.False(t, code.VerifyCodeVerifier(""))
+	})
+
+	t.Run("rejects malformed code verifiers", func(t *testing.T) {
+		code := OauthAuthorizationCode{CodeChallenge: challenge}
+
+		assert.False(t, code.VerifyCodeVerifier("short"))
+		assert.False(t, code.VerifyCodeVerifier(verifier+"!"))
+	})
+
+	t.Run("redirects with the code and state", func(t *testing.T) {
+		redirect := request.RedirectWithCode("https://app.example.com/callback?tenant=1", OauthAuthorizationCode{Code: "abc"})
+
+		u, err := url.Parse(redirect)
+		require.NoError(t, err)
+		assert.Equal(t, "app.example.com", u.Host)
+		assert.Equal(t, url.Values{"code": {"abc"}, "state": {"xyz"}, "tenant": {"1"}}, u.Query())
+	})
+
+	t.Run("redirects with the error and state", func(t *testing.T) {
+		redirect := request.RedirectWithError("https://app.example.com/callback", NewError(ErrorCodeAccessDenied, ErrorAccessDenied))
+
+		u, err := url.Parse(redirect)
+		require.NoError(t, err)
+		assert.Equal(t, "access_denied", u.Query().Get("error"))
+		assert.Equal(t, ErrorAccessDenied, u.Query().Get("error_description"))
+		assert.Equal(t, "xyz", u.Query().Get("state"))
+	})
+}
+
+func TestParseAuthorizationRequest(t *testing.T) {
+	t.Run("reads the request from the query", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/oauth/authorize?response_type=code&client_id=client_spa&state=xyz&code_challenge=abc&code_challenge_method=S256", nil)
+
+		request, err := ParseAuthorizationRequest(r)
+
+		require.NoError(t, err)
+		assert.Equal(t, AuthorizationRequest{
+			ResponseType:        "code",
+			ClientID:            "client_spa",
+			State:               "xyz",
+			CodeChallenge:       "abc",
+			CodeChallengeMethod: "S256",
+		}, request)
+	})
+
+	t.Run("rejects repeated parameters", func(t *testing.T) {
+		r := newTokenRequest(url.Values{"client_id": {"client_spa"}})
+		r.URL.RawQuery = "client_id=client_web"
+
+		_, err := ParseAuthorizationRequest(r)
+
+		assert.Equal(t, ErrorCodeInvalidRequest, errorCode(t, err))
+	})
+}
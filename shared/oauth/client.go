@@ -0,0 +1,215 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/guregu/null"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const clientSecretBytes = 32
+
+// SupportedGrantTypes are the grant types clients may be allowed to use.
+var SupportedGrantTypes = []GrantType{ClientCredentials, Password, RefreshToken, AuthorizationCode}
+
+// PublicGrantTypes are the grant types public clients may be allowed to use,
+// as they cannot keep a secret.
+var PublicGrantTypes = []GrantType{AuthorizationCode, RefreshToken}
+
+// ClientRequestFormat represents the request format of registering an
+// OauthClient. Public clients, such as single-page apps, are registered
+// without a secret.
+type ClientRequestFormat struct {
+	ClientID    string      `json:"clientId" validate:"required,max=32"`
+	RedirectURI string      `json:"redirectUri" validate:"omitempty,url,max=1000"`
+	GrantTypes  []GrantType `json:"grantTypes" validate:"required,min=1"`
+	Scope       []string    `json:"scope"`
+	Public      bool        `json:"public"`
+}
+
+// ClientResponseFormat represents an OauthClient's standard formatting for
+// JSON serializing, which never carries its secret.
+type ClientResponseFormat struct {
+	ClientID      string     `json:"clientId"`
+	RedirectURI   *string    `json:"redirectUri,omitempty"`
+	GrantTypes    []string   `json:"grantTypes"`
+	Scope         []string   `json:"scope"`
+	Public        bool       `json:"public"`
+	Created       time.Time  `json:"created"`
+	SecretRotated *time.Time `json:"secretRotated,omitempty"`
+	Disabled      *time.Time `json:"disabled,omitempty"`
+}
+
+// ClientSecretResponseFormat represents an OauthClient along its secret, which
+// is only shown once it is registered or its secret is rotated. Public
+// clients have no secret.
+type ClientSecretResponseFormat struct {
+	ClientResponseFormat
+	ClientSecret string `json:"clientSecret,omitempty"`
+}
+
+// NewFromRequestFormat creates a new OauthClient from its request format,
+// along its generated secret, empty for public clients.
+func (o OauthClient) NewFromRequestFormat(req ClientRequestFormat) (client OauthClient, secret string, err error) {
+	grantTypes := make([]string, 0, len(req.GrantTypes))
+	for _, grantType := range req.GrantTypes {
+		if !containsGrantType(SupportedGrantTypes, grantType) {
+			return client, "", failure.BadRequestFromString(fmt.Sprintf("grant type %s is not supported", grantType))
+		}
+		if req.Public && !containsGrantType(PublicGrantTypes, grantType) {
+			return client, "", failure.BadRequestFromString(fmt.Sprintf("grant type %s is not allowed for public clients", grantType))
+		}
+		grantTypes = append(grantTypes, string(grantType))
+	}
+
+	// authorization codes are only redirected to a registered URI
+	if containsGrantType(req.GrantTypes, AuthorizationCode) && req.RedirectURI == "" {
+		return client, "", failure.BadRequestFromString("grant type authorization_code requires a redirect URI")
+	}
+
+	for _, scope := range req.Scope {
+		if !containsScope(Permissions, scope) {
+			return client, "", failure.BadRequestFromString(fmt.Sprintf("scope %s is not a permission", scope))
+		}
+	}
+
+	client = OauthClient{
+		ClientID:    req.ClientID,
+		RedirectURI: null.NewString(req.RedirectURI, req.RedirectURI != ""),
+		GrantTypes:  strings.Join(grantTypes, " "),
+		Scope:       null.NewString(strings.Join(req.Scope, " "), len(req.Scope) > 0),
+		Created:     time.Now(),
+	}
+	if req.Public {
+		return
+	}
+
+	client.ClientSecret, secret, err = generateClientSecret()
+	return
+}
+
+// RotateSecret replaces the secret of this client, returning the new one.
+func (o *OauthClient) RotateSecret() (secret string, err error) {
+	if o.IsDisabled() {
+		return "", failure.Conflict("rotateSecret", "client", "client is disabled")
+	}
+	if o.IsPublic() {
+		return "", failure.Conflict("rotateSecret", "client", "client is public")
+	}
+
+	o.ClientSecret, secret, err = generateClientSecret()
+	if err != nil {
+		return
+	}
+
+	o.SecretRotated = null.TimeFrom(time.Now())
+	return
+}
+
+// Disable marks this client as disabled, so that it fails authentication.
+func (o *OauthClient) Disable() (err error) {
+	if o.IsDisabled() {
+		return failure.Conflict("disable", "client", "already disabled")
+	}
+
+	o.Disabled = null.TimeFrom(time.Now())
+	return
+}
+
+// IsDisabled reports whether this client is disabled.
+func (o *OauthClient) IsDisabled() bool {
+	return o.Disabled.Valid
+}
+
+// IsPublic reports whether this client is public, which has no secret.
+func (o *OauthClient) IsPublic() bool {
+	return o.ClientSecret == ""
+}
+
+// AllowsIntrospection reports whether this client may introspect tokens.
+// Only confidential clients may, as anyone knowing the ID of a public client
+// can authenticate as it.
+func (o *OauthClient) AllowsIntrospection() bool {
+	return !o.IsPublic()
+}
+
+// MarshalJSON overrides the standard JSON formatting.
+func (o OauthClient) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.ToResponseFormat())
+}
+
+// ToResponseFormat converts this OauthClient to its response format.
+func (o OauthClient) ToResponseFormat() ClientResponseFormat {
+	return ClientResponseFormat{
+		ClientID:      o.ClientID,
+		RedirectURI:   o.RedirectURI.Ptr(),
+		GrantTypes:    strings.Fields(o.GrantTypes),
+		Scope:         strings.Fields(o.Scope.String),
+		Public:        o.IsPublic(),
+		Created:       o.Created,
+		SecretRotated: o.SecretRotated.Ptr(),
+		Disabled:      o.Disabled.Ptr(),
+	}
+}
+
+// ToSecretResponseFormat converts this OauthClient to its response format
+// along its secret.
+func (o OauthClient) ToSecretResponseFormat(secret string) ClientSecretResponseFormat {
+	return ClientSecretResponseFormat{
+		ClientResponseFormat: o.ToResponseFormat(),
+		ClientSecret:         secret,
+	}
+}
+
+func containsGrantType(grantTypes []GrantType, grantType GrantType) bool {
+	for _, contained := range grantTypes {
+		if contained == grantType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// generateClientSecret generates a random secret along its hash.
+func generateClientSecret() (hash string, secret string, err error) {
+	b := make([]byte, clientSecretBytes)
+	_, err = rand.Read(b)
+	if err != nil {
+		return
+	}
+	secret = fmt.Sprintf("%x", b)
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return
+	}
+
+	return string(hashed), secret, nil
+}
+
+var (
+	dummyHash     []byte
+	dummyHashOnce sync.Once
+)
+
+// compareSecret reports whether a secret matches its bcrypt hash. Without a
+// hash, it compares against a dummy one, so that unknown clients take as long
+// to reject as wrong secrets do.
+func compareSecret(hash string, secret string) bool {
+	if hash == "" {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+		})
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(secret))
+		return false
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret)) == nil
+}
@@ -1,7 +1,7 @@
 package oauth
 
 import (
-	"errors"
+	"github.com/guregu/null"
 )
 
 type ClientCredentialsAuth struct {
@@ -10,24 +10,18 @@ type ClientCredentialsAuth struct {
 }
 
 func (c *ClientCredentialsAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
-	client, err := c.tokenStore.resolveClientByClientID(credential.ClientID)
+	client, err := authenticateClient(c.tokenStore, credential)
 	if err != nil {
 		return
 	}
 
-	if !client.VerifyClient(credential) {
-		err = errors.New(ErrorInvalidClient)
-		return
-	}
-
-	accessToken, err := generateAccessToken()
+	scope, err := grantScope(client, credential.Scope, nil)
 	if err != nil {
-		err = errors.New(ErrorGenerateAccessToken)
 		return
 	}
 
-	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, credential.ClientID, nil, true, c.config)
-	err = c.tokenStore.createAccessToken(oauthAccessToken)
+	oauthAccessToken = new(OauthAccessToken).Generate("", credential.ClientID, null.String{}, scope, c.config)
+	err = issueAccessToken(c.tokenStore, &oauthAccessToken, c.config)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,135 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOauthClientManagement(t *testing.T) {
+	t.Run("registers with a hashed secret", func(t *testing.T) {
+		client, secret, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:   "client_app",
+			GrantTypes: []GrantType{ClientCredentials},
+			Scope:      []string{PermissionCatalogRead},
+		})
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, secret)
+		assert.NotEqual(t, secret, client.ClientSecret)
+		assert.Equal(t, "client_credentials", client.GrantTypes)
+		assert.Equal(t, "catalog:read", client.Scope.String)
+		assert.False(t, client.RedirectURI.Valid)
+		assert.True(t, client.VerifyClient(Credential{ClientID: "client_app", ClientSecret: secret}))
+	})
+
+	t.Run("rejects unsupported grant types and unknown scopes", func(t *testing.T) {
+		_, _, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:   "client_app",
+			GrantTypes: []GrantType{"implicit"},
+		})
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+
+		_, _, err = OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:   "client_app",
+			GrantTypes: []GrantType{ClientCredentials},
+			Scope:      []string{"everything"},
+		})
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+	})
+
+	t.Run("rotates the secret", func(t *testing.T) {
+		client, previous, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:   "client_app",
+			GrantTypes: []GrantType{ClientCredentials},
+		})
+		require.NoError(t, err)
+
+		secret, err := client.RotateSecret()
+
+		require.NoError(t, err)
+		assert.NotEqual(t, previous, secret)
+		assert.True(t, client.SecretRotated.Valid)
+		assert.True(t, client.VerifyClient(Credential{ClientID: "client_app", ClientSecret: secret}))
+		assert.False(t, client.VerifyClient(Credential{ClientID: "client_app", ClientSecret: previous}))
+	})
+
+	t.Run("disabled clients fail verification", func(t *testing.T) {
+		client, secret, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:   "client_app",
+			GrantTypes: []GrantType{ClientCredentials},
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, client.Disable())
+
+		assert.False(t, client.VerifyClient(Credential{ClientID: "client_app", ClientSecret: secret}))
+		assert.Equal(t, http.StatusConflict, failure.GetCode(client.Disable()))
+		_, err = client.RotateSecret()
+		assert.Equal(t, http.StatusConflict, failure.GetCode(err))
+	})
+
+	t.Run("never serializes the secret", func(t *testing.T) {
+		client, secret, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:   "client_app",
+			GrantTypes: []GrantType{ClientCredentials},
+		})
+		require.NoError(t, err)
+
+		b, err := json.Marshal(client)
+
+		require.NoError(t, err)
+		assert.NotContains(t, string(b), secret)
+		assert.NotContains(t, string(b), client.ClientSecret)
+	})
+	t.Run("registers public clients without a secret", func(t *testing.T) {
+		client, secret, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:    "client_spa",
+			RedirectURI: "https://app.example.com/callback",
+			GrantTypes:  []GrantType{AuthorizationCode, RefreshToken},
+			Public:      true,
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, secret)
+		assert.True(t, client.IsPublic())
+		assert.True(t, client.ToResponseFormat().Public)
+		assert.True(t, client.VerifyClient(Credential{ClientID: "client_spa"}))
+		assert.False(t, client.VerifyClient(Credential{ClientID: "client_spa", ClientSecret: "secret"}))
+		_, err = client.RotateSecret()
+		assert.Equal(t, http.StatusConflict, failure.GetCode(err))
+	})
+
+	t.Run("restricts the grant types of public clients", func(t *testing.T) {
+		_, _, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:    "client_spa",
+			RedirectURI: "https://app.example.com/callback",
+			GrantTypes:  []GrantType{AuthorizationCode, ClientCredentials},
+			Public:      true,
+		})
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+	})
+
+	t.Run("requires a redirect URI for the authorization_code grant", func(t *testing.T) {
+		_, _, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:   "client_app",
+			GrantTypes: []GrantType{AuthorizationCode},
+		})
+		assert.Equal(t, http.StatusBadRequest, failure.GetCode(err))
+	})
+
+	t.Run("confidential clients require their secret", func(t *testing.T) {
+		client, _, err := OauthClient{}.NewFromRequestFormat(ClientRequestFormat{
+			ClientID:   "client_app",
+			GrantTypes: []GrantType{ClientCredentials},
+		})
+		require.NoError(t, err)
+
+		assert.False(t, client.IsPublic())
+		assert.False(t, client.VerifyClient(Credential{ClientID: "client_app"}))
+	})
+}
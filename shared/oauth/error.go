@@ -1,11 +1,83 @@
 package oauth
 
+import (
+	"math"
+	"net/http"
+	"time"
+)
+
 const (
 	ErrorEmptyCredential     string = "Credential can't be empty"
 	ErrorClientNotFound      string = "Client does not exist"
+	ErrorUserNotFound        string = "User does not exist"
 	ErrorInvalidPassword     string = "Invalid password credential"
 	ErrorInvalidClient       string = "Invalid client credentials"
 	ErrorInvalidToken        string = "Invalid Token"
 	ErrorTokenTypeMismatch   string = "Token type mismatch"
 	ErrorGenerateAccessToken string = "Error generating access token"
+	ErrorGrantNotAllowed     string = "Grant type is not allowed for this client"
+	ErrorUnsupportedGrant    string = "Grant type is not supported"
+	ErrorInvalidRefreshToken string = "Invalid refresh token"
+	ErrorRefreshTokenReused  string = "Refresh token was used already"
+	ErrorTokenNotOwned       string = "Token was not issued to this client"
+	ErrorCannotIntrospect    string = "Public clients cannot introspect tokens"
+	ErrorScopeNotAllowed     string = "Scope is not granted to this client"
+	ErrorInvalidCode         string = "Invalid authorization code"
+	ErrorInvalidRedirectURI  string = "Redirect URI is not registered for this client"
+	ErrorAccessDenied        string = "The user denied the request"
+	ErrorLoginLocked         string = "Too many failed logins, try again later"
+)
+
+// ErrorCode is an error code of RFC 6749 section 4.1.2.1 or 5.2, or RFC 7009
+// section 2.2.1.
+type ErrorCode string
+
+const (
+	ErrorCodeInvalidRequest       ErrorCode = "invalid_request"
+	ErrorCodeInvalidClient        ErrorCode = "invalid_client"
+	ErrorCodeInvalidGrant         ErrorCode = "invalid_grant"
+	ErrorCodeUnauthorizedClient   ErrorCode = "unauthorized_client"
+	ErrorCodeUnsupportedGrantType ErrorCode = "unsupported_grant_type"
+	ErrorCodeInvalidScope         ErrorCode = "invalid_scope"
+	ErrorCodeServerError          ErrorCode = "server_error"
+	ErrorCodeUnsupportedTokenType ErrorCode = "unsupported_token_type"
+	ErrorCodeAccessDenied         ErrorCode = "access_denied"
+	ErrorCodeUnsupportedResponse  ErrorCode = "unsupported_response_type"
 )
+
+// Error is an OAuth error response, RFC 6749 section 5.2.
+type Error struct {
+	Code        ErrorCode `json:"error"`
+	Description string    `json:"error_description,omitempty"`
+	// RetryAfter is how long to wait before retrying, zero unless the request
+	// is throttled.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// NewError returns a new Error with the given code and description.
+func NewError(code ErrorCode, description string) *Error {
+	return &Error{
+		Code:        code,
+		Description: description,
+	}
+}
+
+// Error returns the description of the error.
+func (e *Error) Error() string {
+	return e.Description
+}
+
+// StatusCode is the HTTP status of the error response. Failed client
+// authentication is unauthorized, every other error is a bad request.
+func (e *Error) StatusCode() int {
+	if e.Code == ErrorCodeInvalidClient {
+		return http.StatusUnauthorized
+	}
+	return http.StatusBadRequest
+}
+
+// RetryAfterSeconds is the Retry-After header of the error response, in whole
+// seconds rounded up, zero unless the request is throttled.
+func (e *Error) RetryAfterSeconds() int64 {
+	return int64(math.Ceil(e.RetryAfter.Seconds()))
+}
@@ -20,6 +20,49 @@ func (g *Grant) Create(credential Credential) (OauthAccessToken, error) {
 	authMap := make(map[GrantType]AuthorizationMethod)
 	authMap[ClientCredentials] = &ClientCredentialsAuth{tokenStore: g.TokenStore, config: g.Config}
 	authMap[Password] = &PasswordAuth{tokenStore: g.TokenStore, config: g.Config}
+	authMap[RefreshToken] = &RefreshTokenAuth{tokenStore: g.TokenStore, config: g.Config}
+	authMap[AuthorizationCode] = &AuthorizationCodeAuth{tokenStore: g.TokenStore, config: g.Config}
 
-	return authMap[credential.GrantType].Create(credential)
+	auth, ok := authMap[credential.GrantType]
+	if !ok {
+		return OauthAccessToken{}, NewError(ErrorCodeUnsupportedGrantType, ErrorUnsupportedGrant)
+	}
+
+	return auth.Create(credential)
+}
+
+// authenticateClient resolves the client of a credential, verifying its
+// secret and that it may use the grant type of the credential.
+func authenticateClient(tokenStore TokenStore, credential Credential) (client OauthClient, err error) {
+	client, err = verifyClient(tokenStore, credential)
+	if err != nil {
+		return
+	}
+
+	if !client.AllowsGrant(credential.GrantType) {
+		err = NewError(ErrorCodeUnauthorizedClient, ErrorGrantNotAllowed)
+		return
+	}
+
+	return
+}
+
+// verifyClient resolves the client of a credential, verifying its secret.
+func verifyClient(tokenStore TokenStore, credential Credential) (client OauthClient, err error) {
+	client, err = tokenStore.resolveClientByClientID(credential.ClientID)
+	if err != nil {
+		if err.Error() == ErrorClientNotFound {
+			// unknown clients take as long to reject as wrong secrets
+			compareSecret("", credential.ClientSecret)
+			err = NewError(ErrorCodeInvalidClient, ErrorInvalidClient)
+		}
+		return
+	}
+
+	if !client.VerifyClient(credential) {
+		err = NewError(ErrorCodeInvalidClient, ErrorInvalidClient)
+		return
+	}
+
+	return
 }
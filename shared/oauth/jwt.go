@@ -0,0 +1,373 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+)
+
+var errInvalidJWT = errors.New(ErrorInvalidToken)
+
+// Claims are the claims of a JWT access token.
+type Claims struct {
+	Issuer   string `json:"iss,omitempty"`
+	Subject  string `json:"sub,omitempty"`
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope,omitempty"`
+	Expires  int64  `json:"exp"`
+	IssuedAt int64  `json:"iat"`
+	ID       string `json:"jti"`
+	// SessionID is the family of the refresh token issued along.
+	SessionID string `json:"sid,omitempty"`
+}
+
+// toAccessToken converts the claims of a JWT to its access token.
+func (c Claims) toAccessToken(token string) OauthAccessToken {
+	return OauthAccessToken{
+		AccessToken: token,
+		ClientID:    c.ClientID,
+		UserID:      null.NewString(c.Subject, c.Subject != ""),
+		Expires:     time.Unix(c.Expires, 0),
+		Scope:       null.NewString(c.Scope, c.Scope != ""),
+		FamilyID:    null.NewString(c.SessionID, c.SessionID != ""),
+		TokenID:     c.ID,
+	}
+}
+
+// JSONWebKey is a public key, RFC 7517.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a set of public keys, RFC 7517 section 5.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ,omitempty"`
+}
+
+type verificationKey struct {
+	jwk    JSONWebKey
+	public crypto.PublicKey
+}
+
+// KeySet signs JWT access tokens with its current key, and verifies them with
+// any of its keys. Keys are identified by the kid header, which is the RFC
+// 7638 thumbprint of the key, so that keys are rotated by signing with a new
+// key while keeping the previous ones for verification until their tokens
+// expire.
+type KeySet struct {
+	issuer  string
+	signer  crypto.Signer
+	current *verificationKey
+	keys    map[string]*verificationKey
+	order   []string
+}
+
+// NewKeySet creates a KeySet signing with signer, an RSA or P-256 ECDSA
+// private key, and verifying with the public keys of previous signers too.
+func NewKeySet(issuer string, signer crypto.Signer, previous ...crypto.PublicKey) (*KeySet, error) {
+	k := &KeySet{
+		issuer: issuer,
+		signer: signer,
+		keys:   make(map[string]*verificationKey),
+	}
+
+	current, err := k.add(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	k.current = current
+
+	for _, public := range previous {
+		_, err = k.add(public)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// LoadKeySet loads a KeySet from PEM files. The first file holds the private
+// key signing tokens, and the others the public or private keys of previous
+// signers.
+func LoadKeySet(issuer string, files []string) (*KeySet, error) {
+	if len(files) == 0 {
+		return nil, errors.New("a signing key is required")
+	}
+
+	var signer crypto.Signer
+	var previous []crypto.PublicKey
+	for i, file := range files {
+		key, err := loadKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed loading key %s: %w", file, err)
+		}
+
+		if i == 0 {
+			var ok bool
+			signer, ok = key.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("key %s is not a private key", file)
+			}
+			continue
+		}
+
+		if s, ok := key.(crypto.Signer); ok {
+			key = s.Public()
+		}
+		previous = append(previous, key)
+	}
+
+	return NewKeySet(issuer, signer, previous...)
+}
+
+func loadKey(file string) (interface{}, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+
+	return nil, fmt.Errorf("unsupported PEM block %s", block.Type)
+}
+
+func (k *KeySet) add(public crypto.PublicKey) (*verificationKey, error) {
+	jwk, err := newJSONWebKey(public)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := k.keys[jwk.KeyID]; ok {
+		return key, nil
+	}
+
+	key := &verificationKey{jwk: jwk, public: public}
+	k.keys[jwk.KeyID] = key
+	k.order = append(k.order, jwk.KeyID)
+	return key, nil
+}
+
+// newJSONWebKey describes a public key, identified by its thumbprint.
+func newJSONWebKey(public crypto.PublicKey) (jwk JSONWebKey, err error) {
+	var thumbprint string
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		jwk = JSONWebKey{
+			KeyType:   "RSA",
+			Algorithm: AlgorithmRS256,
+			N:         encodeSegment(key.N.Bytes()),
+			E:         encodeSegment(big.NewInt(int64(key.E)).Bytes()),
+		}
+		thumbprint = fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.E, jwk.N)
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return jwk, errors.New("only P-256 ECDSA keys are supported")
+		}
+		jwk = JSONWebKey{
+			KeyType:   "EC",
+			Algorithm: AlgorithmES256,
+			Curve:     "P-256",
+			X:         encodeSegment(padBytes(key.X.Bytes(), 32)),
+			Y:         encodeSegment(padBytes(key.Y.Bytes(), 32)),
+		}
+		thumbprint = fmt.Sprintf(`{"crv":"P-256","kty":"EC","x":"%s","y":"%s"}`, jwk.X, jwk.Y)
+	default:
+		return jwk, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	sum := sha256.Sum256([]byte(thumbprint))
+	jwk.KeyID = encodeSegment(sum[:])
+	jwk.Use = "sig"
+	return
+}
+
+// KeyID identifies the key signing tokens.
+func (k *KeySet) KeyID() string {
+	return k.current.jwk.KeyID
+}
+
+// JWKS lists the public keys of this KeySet, the current key first.
+func (k *KeySet) JWKS() JSONWebKeySet {
+	keys := make([]JSONWebKey, 0, len(k.order))
+	for _, id := range k.order {
+		keys = append(keys, k.keys[id].jwk)
+	}
+
+	return JSONWebKeySet{Keys: keys}
+}
+
+// Sign signs the claims of an access token, setting its issuer, issue time
+// and ID.
+func (k *KeySet) Sign(claims Claims) (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	claims.Issuer = k.issuer
+	claims.IssuedAt = time.Now().Unix()
+	claims.ID = id.String()
+
+	header, err := json.Marshal(jwtHeader{
+		Algorithm: k.current.jwk.Algorithm,
+		KeyID:     k.current.jwk.KeyID,
+		Type:      "JWT",
+	})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodeSegment(header) + "." + encodeSegment(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch signer := k.signer.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, signer, digest[:])
+		if err == nil {
+			signature = append(padBytes(r.Bytes(), 32), padBytes(s.Bytes(), 32)...)
+		}
+	default:
+		err = fmt.Errorf("unsupported key type %T", k.signer)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + encodeSegment(signature), nil
+}
+
+// Verify verifies the signature of a JWT by the key of its kid header, and
+// that it is neither expired nor issued by another issuer.
+func (k *KeySet) Verify(token string) (claims Claims, err error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return claims, errInvalidJWT
+	}
+
+	var header jwtHeader
+	err = decodeJSONSegment(segments[0], &header)
+	if err != nil {
+		return claims, errInvalidJWT
+	}
+
+	key, ok := k.keys[header.KeyID]
+	// the algorithm of the key is enforced, not the one claimed by the header
+	if !ok || header.Algorithm != key.jwk.Algorithm {
+		return claims, errInvalidJWT
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return claims, errInvalidJWT
+	}
+	digest := sha256.Sum256([]byte(segments[0] + "." + segments[1]))
+
+	switch public := key.public.(type) {
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(public, crypto.SHA256, digest[:], signature) == nil
+	case *ecdsa.PublicKey:
+		ok = len(signature) == 64 &&
+			ecdsa.Verify(public, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:]))
+	default:
+		ok = false
+	}
+	if !ok {
+		return claims, errInvalidJWT
+	}
+
+	err = decodeJSONSegment(segments[1], &claims)
+	if err != nil {
+		return claims, errInvalidJWT
+	}
+
+	if claims.Issuer != k.issuer || time.Now().Unix() >= claims.Expires {
+		return claims, errInvalidJWT
+	}
+
+	return claims, nil
+}
+
+// isJWT tells a JWT apart from an opaque token, which is hexadecimal.
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeJSONSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+
+	padded := make([]byte, size)
+	copy(padded[size-len(b):], b)
+	return padded
+}
@@ -0,0 +1,244 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func newECKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func newClaims() Claims {
+	return Claims{
+		Subject:  "1",
+		ClientID: "client_web",
+		Expires:  time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestKeySet(t *testing.T) {
+	for name, signer := range map[string]crypto.Signer{
+		AlgorithmRS256: newRSAKey(t),
+		AlgorithmES256: newECKey(t),
+	} {
+		t.Run(name, func(t *testing.T) {
+			keySet, err := NewKeySet("https://auth.example.com", signer)
+			require.NoError(t, err)
+
+			token, err := keySet.Sign(newClaims())
+			require.NoError(t, err)
+
+			var header jwtHeader
+			require.NoError(t, decodeJSONSegment(strings.Split(token, ".")[0], &header))
+			assert.Equal(t, name, header.Algorithm)
+			assert.Equal(t, keySet.KeyID(), header.KeyID)
+
+			claims, err := keySet.Verify(token)
+			require.NoError(t, err)
+			assert.Equal(t, "https://auth.example.com", claims.Issuer)
+			assert.Equal(t, "1", claims.Subject)
+			assert.Equal(t, "client_web", claims.ClientID)
+			assert.NotEmpty(t, claims.ID)
+		})
+	}
+
+	keySet, err := NewKeySet("", newRSAKey(t))
+	require.NoError(t, err)
+
+	t.Run("rejects tampered tokens", func(t *testing.T) {
+		token, err := keySet.Sign(newClaims())
+		require.NoError(t, err)
+		segments := strings.Split(token, ".")
+		segments[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"2","client_id":"client_web","exp":9999999999}`))
+
+		_, err = keySet.Verify(strings.Join(segments, "."))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects tokens of another algorithm", func(t *testing.T) {
+		header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"` + keySet.KeyID() + `"}`))
+		payload := base64.RawURLEncoding.EncodeToString([]byte(`{"client_id":"client_web","exp":9999999999}`))
+
+		_, err := keySet.Verify(header + "." + payload + ".")
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects expired tokens", func(t *testing.T) {
+		claims := newClaims()
+		claims.Expires = time.Now().Add(-time.Second).Unix()
+		token, err := keySet.Sign(claims)
+		require.NoError(t, err)
+
+		_, err = keySet.Verify(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects tokens of other issuers", func(t *testing.T) {
+		other, err := NewKeySet("https://other.example.com", keySet.signer)
+		require.NoError(t, err)
+		token, err := other.Sign(newClaims())
+		require.NoError(t, err)
+
+		_, err = keySet.Verify(token)
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects unsupported keys", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+
+		_, err = NewKeySet("", key)
+		assert.Error(t, err)
+	})
+}
+
+func TestKeySetRotation(t *testing.T) {
+	previousKey := newRSAKey(t)
+	previous, err := NewKeySet("", previousKey)
+	require.NoError(t, err)
+	token, err := previous.Sign(newClaims())
+	require.NoError(t, err)
+
+	rotated, err := NewKeySet("", newECKey(t), previousKey.Public())
+	require.NoError(t, err)
+
+	_, err = rotated.Verify(token)
+	assert.NoError(t, err)
+
+	jwks := rotated.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, rotated.KeyID(), jwks.Keys[0].KeyID)
+	assert.Equal(t, "EC", jwks.Keys[0].KeyType)
+	assert.Equal(t, previous.KeyID(), jwks.Keys[1].KeyID)
+	assert.Equal(t, "RSA", jwks.Keys[1].KeyType)
+	assert.Equal(t, "AQAB", jwks.Keys[1].E)
+
+	unknown, err := NewKeySet("", newRSAKey(t))
+	require.NoError(t, err)
+	_, err = unknown.Verify(token)
+	assert.Error(t, err)
+}
+
+func TestLoadKeySet(t *testing.T) {
+	dir := t.TempDir()
+
+	writeKey := func(name string, blockType string, der []byte) string {
+		file := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+		return file
+	}
+
+	current := newECKey(t)
+	currentDER, err := x509.MarshalECPrivateKey(current)
+	require.NoError(t, err)
+	previous := newRSAKey(t)
+	previousDER, err := x509.MarshalPKIXPublicKey(previous.Public())
+	require.NoError(t, err)
+
+	keySet, err := LoadKeySet("", []string{
+		writeKey("current.pem", "EC PRIVATE KEY", currentDER),
+		writeKey("previous.pem", "PUBLIC KEY", previousDER),
+	})
+	require.NoError(t, err)
+	assert.Len(t, keySet.JWKS().Keys, 2)
+
+	_, err = LoadKeySet("", []string{filepath.Join(dir, "previous.pem")})
+	assert.Error(t, err)
+	_, err = LoadKeySet("", nil)
+	assert.Error(t, err)
+}
+
+// fakeRevocationList checks JWTs with a function.
+type fakeRevocationList func(claims Claims) (bool, error)
+
+func (f fakeRevocationList) IsRevoked(claims Claims) (bool, error) {
+	return f(claims)
+}
+
+func TestParseJWT(t *testing.T) {
+	keySet, err := NewKeySet("", newRSAKey(t))
+	require.NoError(t, err)
+	config := Config{Expiration: 60, TokenFormat: TokenFormatJWT, KeySet: keySet}
+
+	// the store has no database, so that resolving the token fails the test
+	accessToken := new(OauthAccessToken).Generate("", "client_web", null.String{}, null.StringFrom(PermissionCatalogRead), config)
+	accessToken.FamilyID = null.StringFrom("family-1")
+	require.NoError(t, issueAccessToken(TokenStore{}, &accessToken, config))
+
+	revoked := make(map[string]bool)
+	var checked Claims
+	parser := NewParser(TokenStore{}, keySet)
+	parser.RevocationList = fakeRevocationList(func(claims Claims) (bool, error) {
+		checked = claims
+		return revoked[claims.ID] || revoked[claims.SessionID] || revoked[claims.ClientID], nil
+	})
+
+	t.Run("verifies the token without resolving it", func(t *testing.T) {
+		parsed, err := parser.Parse("Bearer " + accessToken.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "client_web", parsed.ClientID)
+		assert.Equal(t, null.StringFrom(PermissionCatalogRead), parsed.Scope)
+		assert.Equal(t, null.StringFrom("family-1"), parsed.FamilyID)
+		assert.NotEmpty(t, parsed.TokenID)
+		assert.False(t, parsed.UserID.Valid)
+		assert.True(t, parsed.VerifyExpireIn())
+
+		assert.Equal(t, parsed.TokenID, checked.ID)
+		assert.Equal(t, "family-1", checked.SessionID)
+		assert.Equal(t, "client_web", checked.ClientID)
+
+		_, err = parser.Parse("Bearer " + accessToken.AccessToken + "x")
+		assert.Error(t, err)
+	})
+
+	claims, err := keySet.Verify(accessToken.AccessToken)
+	require.NoError(t, err)
+	for name, id := range map[string]string{
+		"rejects a revoked token":              claims.ID,
+		"rejects a token of a revoked family":  "family-1",
+		"rejects a token of a disabled client": "client_web",
+	} {
+		t.Run(name, func(t *testing.T) {
+			revoked[id] = true
+			defer delete(revoked, id)
+
+			_, err := parser.Parse("Bearer " + accessToken.AccessToken)
+			assert.EqualError(t, err, ErrorInvalidToken)
+		})
+	}
+
+	t.Run("fails when the revoked tokens cannot be checked", func(t *testing.T) {
+		parser := NewParser(TokenStore{}, keySet)
+		parser.RevocationList = fakeRevocationList(func(claims Claims) (bool, error) {
+			return false, errors.New("connection refused")
+		})
+
+		_, err := parser.Parse("Bearer " + accessToken.AccessToken)
+		assert.EqualError(t, err, "connection refused")
+	})
+}
@@ -0,0 +1,213 @@
+package oauth
+
+import (
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	lockoutKeyUser     = "user:"
+	lockoutKeyIP       = "ip:"
+	lockoutKeyThrottle = "throttle:"
+)
+
+// LoginAttemptStore counts failed logins and holds lockouts, which both
+// expire.
+type LoginAttemptStore interface {
+	// Fail counts a failed login of a key, returning the failures counted
+	// since the first failure of the window.
+	Fail(key string, window time.Duration) (int64, error)
+	// Lock locks a key out for a duration.
+	Lock(key string, duration time.Duration) error
+	// Locked returns how long a key remains locked out, zero when it is not.
+	Locked(key string) (time.Duration, error)
+	// Reset clears the failures and the lockout of a key.
+	Reset(key string) error
+}
+
+// LockoutConfig is the configuration of Lockout.
+type LockoutConfig struct {
+	// MaxUserFailures are the failures locking an account out.
+	MaxUserFailures int64
+	// MaxIPFailures are the failures locking an IP address out, across
+	// accounts.
+	MaxIPFailures int64
+	// Window is how long failures are counted for.
+	Window time.Duration
+	// Duration is how long an account or IP address is locked out for.
+	Duration time.Duration
+	// Delay is how long an account is throttled from an IP address after a
+	// first failure, which doubles on every failure up to MaxDelay.
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+// Lockout protects logins against brute force. Failed logins are counted per
+// account and per IP address, throttled progressively, and locked out
+// temporarily once they are too many. Rejected logins tell when to retry,
+// rather than holding their response.
+type Lockout struct {
+	store  LoginAttemptStore
+	config LockoutConfig
+}
+
+// NewLockout creates a new Lockout.
+func NewLockout(store LoginAttemptStore, config LockoutConfig) *Lockout {
+	return &Lockout{
+		store:  store,
+		config: config,
+	}
+}
+
+// Check fails with invalid_grant when an account or an IP address is locked
+// out, or when the account is throttled from the IP address. The error tells
+// when to retry.
+func (l *Lockout) Check(account string, ip string) error {
+	var retryAfter time.Duration
+	for _, key := range append(l.keys(account, ip), l.throttleKey(account, ip)) {
+		locked, err := l.store.Locked(key)
+		if err != nil {
+			return err
+		}
+		if locked > retryAfter {
+			retryAfter = locked
+		}
+	}
+
+	if retryAfter > 0 {
+		err := NewError(ErrorCodeInvalidGrant, ErrorLoginLocked)
+		err.RetryAfter = retryAfter
+		return err
+	}
+
+	return nil
+}
+
+// Fail counts a failed login of an account from an IP address, locking either
+// out once it fails too often, and throttling the account from the IP address
+// otherwise. It returns how long to wait before retrying.
+func (l *Lockout) Fail(account string, ip string) (retryAfter time.Duration, err error) {
+	userFailures, userLocked, err := l.fail(lockoutKeyUser+account, l.config.MaxUserFailures)
+	if err != nil {
+		return
+	}
+
+	failures, locked := userFailures, userLocked
+	if ip != "" {
+		ipFailures, ipLocked, err := l.fail(lockoutKeyIP+ip, l.config.MaxIPFailures)
+		if err != nil {
+			return 0, err
+		}
+		if ipFailures > failures {
+			failures = ipFailures
+		}
+		locked = locked || ipLocked
+	}
+
+	if locked {
+		return l.config.Duration, nil
+	}
+
+	retryAfter = l.delay(failures)
+	if retryAfter > 0 {
+		err = l.store.Lock(l.throttleKey(account, ip), retryAfter)
+	}
+	return
+}
+
+// Succeed clears the failures of an account.
+func (l *Lockout) Succeed(account string) error {
+	return l.store.Reset(lockoutKeyUser + account)
+}
+
+// Unlock clears the failures and the lockout of an account, on behalf of an
+// actor.
+func (l *Lockout) Unlock(account string, actor string) error {
+	err := l.store.Reset(lockoutKeyUser + account)
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("audit", "login_unlocked").
+		Str("account", account).
+		Str("actor", actor).
+		Msg("Login unlocked.")
+	return nil
+}
+
+func (l *Lockout) fail(key string, maxFailures int64) (failures int64, locked bool, err error) {
+	failures, err = l.store.Fail(key, l.config.Window)
+	if err != nil || maxFailures <= 0 || failures < maxFailures {
+		return
+	}
+
+	err = l.store.Lock(key, l.config.Duration)
+	if err != nil {
+		return
+	}
+	locked = true
+
+	log.Warn().
+		Str("audit", "login_locked").
+		Str("key", key).
+		Int64("failures", failures).
+		Dur("duration", l.config.Duration).
+		Msg("Login locked out.")
+	return
+}
+
+// delay returns how long failures throttle a login, doubling on every failure
+// up to the maximum delay.
+func (l *Lockout) delay(failures int64) time.Duration {
+	if failures <= 0 || l.config.Delay <= 0 {
+		return 0
+	}
+
+	delay := float64(l.config.Delay) * math.Pow(2, float64(failures-1))
+	if l.config.MaxDelay > 0 && delay > float64(l.config.MaxDelay) {
+		return l.config.MaxDelay
+	}
+
+	return time.Duration(delay)
+}
+
+func (l *Lockout) keys(account string, ip string) []string {
+	keys := []string{lockoutKeyUser + account}
+	if ip != "" {
+		keys = append(keys, lockoutKeyIP+ip)
+	}
+
+	return keys
+}
+
+// throttleKey is the key throttling an account from an IP address, so that
+// the account remains available from other addresses.
+func (l *Lockout) throttleKey(account string, ip string) string {
+	return lockoutKeyThrottle + account + "|" + ip
+}
+
+// lockoutAccount is the account of a login, the ID of its user, or the
+// identifier logged in with when the user is unknown, so that unknown users
+// are locked out like known ones.
+func lockoutAccount(user User, credential Credential) string {
+	if user.ID != "" {
+		return user.ID
+	}
+
+	return strings.ToLower(strings.TrimSpace(credential.Username))
+}
+
+// RemoteIP returns the IP address of the remote address of a request.
+func RemoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+
+	return host
+}
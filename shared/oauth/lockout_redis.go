@@ -0,0 +1,61 @@
+package oauth
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	loginFailuresRedisKeyPrefix = "login:failures:"
+	loginLockoutRedisKeyPrefix  = "login:lockout:"
+)
+
+// failLoginScript counts a failure, starting its window on the first one.
+var failLoginScript = redis.NewScript(`
+	local failures = redis.call("INCR", KEYS[1])
+	if failures == 1 then
+		redis.call("PEXPIRE", KEYS[1], ARGV[1])
+	end
+	return failures`)
+
+// LoginAttemptStoreRedis is the Redis-backed implementation of
+// LoginAttemptStore.
+type LoginAttemptStoreRedis struct {
+	client *redis.Client
+}
+
+// NewLoginAttemptStoreRedis creates a new LoginAttemptStoreRedis.
+func NewLoginAttemptStoreRedis(client *redis.Client) *LoginAttemptStoreRedis {
+	return &LoginAttemptStoreRedis{client: client}
+}
+
+// Fail counts a failure using INCR, which expires with its window.
+func (s *LoginAttemptStoreRedis) Fail(key string, window time.Duration) (int64, error) {
+	return failLoginScript.Run(s.client, []string{loginFailuresRedisKeyPrefix + key}, window.Milliseconds()).Int64()
+}
+
+// Lock locks a key out using SET with its duration.
+func (s *LoginAttemptStoreRedis) Lock(key string, duration time.Duration) error {
+	return s.client.Set(loginLockoutRedisKeyPrefix+key, time.Now().Unix(), duration).Err()
+}
+
+// Locked returns the TTL of the lockout of a key.
+func (s *LoginAttemptStoreRedis) Locked(key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(loginLockoutRedisKeyPrefix + key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// negative TTLs report missing keys
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+// Reset deletes the failures and the lockout of a key.
+func (s *LoginAttemptStoreRedis) Reset(key string) error {
+	return s.client.Del(loginFailuresRedisKeyPrefix+key, loginLockoutRedisKeyPrefix+key).Err()
+}
@@ -0,0 +1,149 @@
+package oauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryLoginAttemptStore struct {
+	failures map[string]int64
+	locks    map[string]time.Duration
+}
+
+func (s *memoryLoginAttemptStore) Fail(key string, window time.Duration) (int64, error) {
+	s.failures[key]++
+	return s.failures[key], nil
+}
+
+func (s *memoryLoginAttemptStore) Lock(key string, duration time.Duration) error {
+	s.locks[key] = duration
+	return nil
+}
+
+func (s *memoryLoginAttemptStore) Locked(key string) (time.Duration, error) {
+	return s.locks[key], nil
+}
+
+func (s *memoryLoginAttemptStore) Reset(key string) error {
+	delete(s.failures, key)
+	delete(s.locks, key)
+	return nil
+}
+
+func TestLockout(t *testing.T) {
+	newLockout := func() (*Lockout, *memoryLoginAttemptStore) {
+		store := &memoryLoginAttemptStore{failures: map[string]int64{}, locks: map[string]time.Duration{}}
+		lockout := NewLockout(store, LockoutConfig{
+			MaxUserFailures: 3,
+			MaxIPFailures:   5,
+			Window:          time.Minute,
+			Duration:        time.Minute,
+			Delay:           100 * time.Millisecond,
+			MaxDelay:        300 * time.Millisecond,
+		})
+		return lockout, store
+	}
+
+	fail := func(t *testing.T, lockout *Lockout, account string, ip string) time.Duration {
+		retryAfter, err := lockout.Fail(account, ip)
+		require.NoError(t, err)
+		return retryAfter
+	}
+
+	retryAfter := func(t *testing.T, err error) time.Duration {
+		oauthErr, ok := err.(*Error)
+		require.True(t, ok, "not an OAuth error: %v", err)
+		return oauthErr.RetryAfter
+	}
+
+	t.Run("throttles failures progressively", func(t *testing.T) {
+		lockout, _ := newLockout()
+
+		delays := make([]time.Duration, 0)
+		for _, account := range []string{"a", "a", "b", "c", "d"} {
+			delays = append(delays, fail(t, lockout, account, "192.0.2.1"))
+		}
+
+		assert.Equal(t, []time.Duration{
+			100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond, time.Minute,
+		}, delays)
+	})
+
+	t.Run("tells throttled logins when to retry", func(t *testing.T) {
+		lockout, store := newLockout()
+
+		assert.Equal(t, 100*time.Millisecond, fail(t, lockout, "user", "192.0.2.1"))
+
+		err := lockout.Check("user", "192.0.2.1")
+		assert.Equal(t, ErrorCodeInvalidGrant, errorCode(t, err))
+		assert.Equal(t, 100*time.Millisecond, retryAfter(t, err))
+		assert.Equal(t, int64(1), err.(*Error).RetryAfterSeconds())
+		// the account remains available from other addresses
+		assert.NoError(t, lockout.Check("user", "192.0.2.2"))
+
+		delete(store.locks, lockout.throttleKey("user", "192.0.2.1"))
+		assert.NoError(t, lockout.Check("user", "192.0.2.1"))
+	})
+
+	t.Run("locks an account out once it fails too often", func(t *testing.T) {
+		lockout, _ := newLockout()
+
+		fail(t, lockout, "user", "192.0.2.1")
+		fail(t, lockout, "user", "192.0.2.2")
+		require.NoError(t, lockout.Check("user", "192.0.2.3"))
+
+		assert.Equal(t, time.Minute, fail(t, lockout, "user", "192.0.2.3"))
+
+		err := lockout.Check("user", "192.0.2.4")
+		assert.Equal(t, ErrorCodeInvalidGrant, errorCode(t, err))
+		assert.Equal(t, ErrorLoginLocked, err.Error())
+		assert.Equal(t, time.Minute, retryAfter(t, err))
+		assert.NoError(t, lockout.Check("other", "192.0.2.4"))
+	})
+
+	t.Run("locks an IP address out across accounts", func(t *testing.T) {
+		lockout, _ := newLockout()
+
+		for _, account := range []string{"a", "b", "c", "d", "e"} {
+			fail(t, lockout, account, "192.0.2.1")
+		}
+
+		assert.Error(t, lockout.Check("f", "192.0.2.1"))
+		assert.NoError(t, lockout.Check("f", "192.0.2.2"))
+	})
+
+	t.Run("clears failures on success", func(t *testing.T) {
+		lockout, _ := newLockout()
+
+		fail(t, lockout, "user", "")
+		require.NoError(t, lockout.Succeed("user"))
+
+		assert.Equal(t, 100*time.Millisecond, fail(t, lockout, "user", ""))
+	})
+
+	t.Run("unlocks an account", func(t *testing.T) {
+		lockout, _ := newLockout()
+		for _, ip := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+			fail(t, lockout, "user", ip)
+		}
+		require.Error(t, lockout.Check("user", "192.0.2.4"))
+
+		require.NoError(t, lockout.Unlock("user", "admin"))
+
+		assert.NoError(t, lockout.Check("user", "192.0.2.4"))
+	})
+}
+
+func TestLockoutAccount(t *testing.T) {
+	assert.Equal(t, "user-id", lockoutAccount(User{ID: "user-id"}, Credential{Username: "User@Example.com"}))
+	assert.Equal(t, "user@example.com", lockoutAccount(User{}, Credential{Username: " User@Example.com "}))
+}
+
+func TestRemoteIP(t *testing.T) {
+	assert.Equal(t, "192.0.2.1", RemoteIP("192.0.2.1:1234"))
+	assert.Equal(t, "2001:db8::1", RemoteIP("[2001:db8::1]:1234"))
+	assert.Equal(t, "192.0.2.1", RemoteIP("192.0.2.1"))
+}
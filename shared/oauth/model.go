@@ -1,11 +1,14 @@
 package oauth
 
 import (
-	"golang.org/x/crypto/bcrypt"
-	"strconv"
+	"errors"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/guregu/null"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type TokenType string
@@ -14,12 +17,6 @@ const (
 	Bearer TokenType = "Bearer"
 )
 
-var scope = struct {
-	User string
-}{
-	"user",
-}
-
 // Credential is
 type Credential struct {
 	GrantType    GrantType
@@ -27,6 +24,14 @@ type Credential struct {
 	ClientSecret string
 	Username     string
 	Password     string
+	RefreshToken string
+	Scope        string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+
+	// IPAddress is the IP address logging in, counted by the Lockout.
+	IPAddress string
 }
 
 type OauthAccessToken struct {
@@ -35,16 +40,20 @@ type OauthAccessToken struct {
 	UserID      null.String `json:"userId" db:"user_id"`
 	Expires     time.Time   `json:"expires" db:"expires"`
 	Scope       null.String `json:"scope" db:"scope"`
+	// FamilyID is the family of the refresh token issued along, revoking
+	// which revokes this access token.
+	FamilyID null.String `json:"-" db:"family_id"`
+
+	// RefreshToken is the refresh token issued along, which is not stored
+	// with the access token.
+	RefreshToken string `json:"-" db:"-"`
+	// TokenID is the ID of a JWT access token, by which it is revoked.
+	TokenID string `json:"-" db:"-"`
 }
 
-func (o *OauthAccessToken) Generate(accessToken string, clientID string, userID *int, withScope bool, config Config) OauthAccessToken {
-	if userID != nil {
-		o.UserID = null.StringFrom(strconv.Itoa(*userID))
-	}
-
-	if withScope {
-		o.Scope = null.StringFrom(scope.User)
-	}
+func (o *OauthAccessToken) Generate(accessToken string, clientID string, userID null.String, scope null.String, config Config) OauthAccessToken {
+	o.UserID = userID
+	o.Scope = scope
 
 	o.ClientID = clientID
 	o.AccessToken = accessToken
@@ -63,51 +72,137 @@ func (o *OauthAccessToken) VerifyExpireIn() bool {
 }
 
 func (o *OauthAccessToken) VerifyUserLoggedIn() bool {
-	if o.UserID.Valid && !o.Scope.Valid {
-		return true
+	return o.UserID.Valid
+}
+
+// HasScope reports whether the token is granted all of the scopes.
+func (o *OauthAccessToken) HasScope(scopes ...string) bool {
+	granted := strings.Fields(o.Scope.String)
+	for _, scope := range scopes {
+		if !containsScope(granted, scope) {
+			return false
+		}
 	}
-	return false
+
+	return true
 }
 
 func (o *OauthAccessToken) toCreateTokenResponse() *TokenResponse {
 	return &TokenResponse{
-		AccessToken: o.AccessToken,
-		ExpiresIn:   o.Expires,
-		TokenType:   string(Bearer),
-		Scope:       scope.User,
+		AccessToken:  o.AccessToken,
+		ExpiresIn:    int64(math.Round(time.Until(o.Expires).Seconds())),
+		TokenType:    string(Bearer),
+		RefreshToken: o.RefreshToken,
+		Scope:        o.Scope.String,
+	}
+}
+
+// OauthRefreshToken is a refresh token. The tokens rotated from one another
+// share a family.
+type OauthRefreshToken struct {
+	RefreshToken string      `json:"refreshToken" db:"refresh_token"`
+	FamilyID     string      `json:"familyId" db:"family_id"`
+	ClientID     string      `json:"clientId" db:"client_id"`
+	UserID       null.String `json:"userId" db:"user_id"`
+	Scope        null.String `json:"scope" db:"scope"`
+	Expires      time.Time   `json:"expires" db:"expires"`
+	Revoked      null.Time   `json:"revoked" db:"revoked"`
+}
+
+// Generate generates the refresh token of a new family for an access token.
+func (o *OauthRefreshToken) Generate(accessToken OauthAccessToken, config Config) (OauthRefreshToken, error) {
+	familyID, err := uuid.NewV4()
+	if err != nil {
+		return OauthRefreshToken{}, err
+	}
+
+	o.FamilyID = familyID.String()
+	o.ClientID = accessToken.ClientID
+	o.UserID = accessToken.UserID
+	o.Scope = accessToken.Scope
+
+	return o.generate(config)
+}
+
+// Rotate generates the refresh token replacing another of its family.
+func (o *OauthRefreshToken) Rotate(previous OauthRefreshToken, config Config) (OauthRefreshToken, error) {
+	o.FamilyID = previous.FamilyID
+	o.ClientID = previous.ClientID
+	o.UserID = previous.UserID
+	o.Scope = previous.Scope
+
+	return o.generate(config)
+}
+
+func (o *OauthRefreshToken) generate(config Config) (OauthRefreshToken, error) {
+	refreshToken, err := generateAccessToken()
+	if err != nil {
+		return OauthRefreshToken{}, errors.New(ErrorGenerateAccessToken)
 	}
+
+	o.RefreshToken = refreshToken
+	o.Expires = time.Now().Add(time.Second * time.Duration(config.RefreshExpiration))
+	o.Revoked = null.Time{}
+
+	return *o, nil
+}
+
+func (o *OauthRefreshToken) VerifyExpireIn() bool {
+	return time.Now().Before(o.Expires)
 }
 
 type OauthClient struct {
-	ClientID     string `json:"clientId" db:"client_id"`
-	ClientSecret string `json:"clientSecret" db:"client_secret"`
-	RedirectURI  string `json:"redirectUri" db:"redirect_uri"`
-	GrantTypes   string `json:"grantTypes" db:"grant_types"`
+	ClientID      string      `json:"clientId" db:"client_id"`
+	ClientSecret  string      `json:"-" db:"client_secret"`
+	RedirectURI   null.String `json:"redirectUri" db:"redirect_uri"`
+	GrantTypes    string      `json:"grantTypes" db:"grant_types"`
+	Scope         null.String `json:"scope" db:"scope"`
+	Created       time.Time   `json:"created" db:"created"`
+	SecretRotated null.Time   `json:"secretRotated" db:"secret_rotated"`
+	Disabled      null.Time   `json:"disabled" db:"disabled"`
 }
 
+// VerifyClient verifies the secret of a credential against the hash of this
+// client's secret. Public clients verify without a secret. Disabled clients
+// fail verification.
 func (o *OauthClient) VerifyClient(credential Credential) bool {
-	if o.ClientID != credential.ClientID {
+	if o.ClientID != credential.ClientID || o.IsDisabled() {
 		return false
 	}
 
-	if o.ClientSecret != credential.ClientSecret {
-		return false
+	if o.IsPublic() {
+		return credential.ClientSecret == ""
 	}
 
-	return true
+	return compareSecret(o.ClientSecret, credential.ClientSecret)
+}
+
+// AllowsGrant reports whether the client may use a grant type, listed in its
+// space-separated grant types.
+func (o *OauthClient) AllowsGrant(grantType GrantType) bool {
+	for _, allowed := range strings.Fields(o.GrantTypes) {
+		if GrantType(allowed) == grantType {
+			return true
+		}
+	}
+
+	return false
 }
 
+// TokenResponse is a successful token response, RFC 6749 section 5.1.
 type TokenResponse struct {
-	AccessToken string
-	ExpiresIn   time.Time
-	TokenType   string
-	Scope       string
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
 }
 
 type User struct {
-	ID       int    `json:"id" db:"id"`
+	ID       string `json:"id" db:"userId"`
 	Username string `json:"username" db:"username"`
 	Password string `json:"password" db:"password"`
+	UserType string `json:"userType" db:"userType"`
 }
 
 func (u *User) ValidCredential(credential Credential) bool {
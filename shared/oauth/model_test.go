@@ -0,0 +1,125 @@
+package oauth
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestOauthClient(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	client := OauthClient{
+		ClientID:     "client_web",
+		ClientSecret: string(hash),
+		GrantTypes:   "client_credentials password",
+	}
+
+	assert.True(t, client.VerifyClient(Credential{ClientID: "client_web", ClientSecret: "secret"}))
+	assert.False(t, client.VerifyClient(Credential{ClientID: "client_web", ClientSecret: "secrets"}))
+	assert.False(t, client.VerifyClient(Credential{ClientID: "client_app", ClientSecret: "secret"}))
+
+	assert.True(t, client.AllowsGrant(ClientCredentials))
+	assert.True(t, client.AllowsGrant(Password))
+	assert.False(t, client.AllowsGrant("refresh_token"))
+	assert.False(t, client.AllowsGrant("client"))
+
+	assert.True(t, client.AllowsIntrospection())
+	public := OauthClient{ClientID: "client_spa", GrantTypes: "authorization_code"}
+	assert.True(t, public.VerifyClient(Credential{ClientID: "client_spa"}))
+	assert.False(t, public.AllowsIntrospection())
+}
+
+func TestUserValidCredential(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := User{ID: "user", Username: "jane", Password: string(hash)}
+
+	assert.True(t, user.ValidCredential(Credential{Username: "jane", Password: "secret"}))
+	assert.False(t, user.ValidCredential(Credential{Username: "jane", Password: "secrets"}))
+	assert.False(t, (&User{ID: "user"}).ValidCredential(Credential{Password: ""}))
+}
+
+func TestOauthRefreshToken(t *testing.T) {
+	config := Config{RefreshExpiration: 60}
+	accessToken := OauthAccessToken{
+		ClientID: "client_web",
+		UserID:   null.StringFrom("1"),
+	}
+
+	first, err := new(OauthRefreshToken).Generate(accessToken, config)
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.RefreshToken)
+	assert.NotEmpty(t, first.FamilyID)
+	assert.Equal(t, "client_web", first.ClientID)
+	assert.Equal(t, null.StringFrom("1"), first.UserID)
+	assert.True(t, first.VerifyExpireIn())
+	assert.WithinDuration(t, time.Now().Add(time.Minute), first.Expires, time.Second)
+
+	another, err := new(OauthRefreshToken).Generate(accessToken, config)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.FamilyID, another.FamilyID)
+
+	first.Revoked = null.TimeFrom(time.Now())
+	rotated, err := new(OauthRefreshToken).Rotate(first, config)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, rotated.RefreshToken)
+	assert.Equal(t, first.FamilyID, rotated.FamilyID)
+	assert.Equal(t, first.UserID, rotated.UserID)
+	assert.False(t, rotated.Revoked.Valid)
+
+	rotated.Expires = time.Now().Add(-time.Second)
+	assert.False(t, rotated.VerifyExpireIn())
+}
+
+func TestTokenResponse(t *testing.T) {
+	accessToken := new(OauthAccessToken).Generate("access", "client_web", null.String{}, null.String{}, Config{Expiration: 3600})
+	accessToken.RefreshToken = "refresh"
+
+	body, err := json.Marshal(accessToken.toCreateTokenResponse())
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"access_token": "access",
+		"token_type": "Bearer",
+		"expires_in": 3600,
+		"refresh_token": "refresh"
+	}`, string(body))
+}
+
+func TestIntrospection(t *testing.T) {
+	body, err := json.Marshal(Introspection{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"active": false}`, string(body))
+
+	body, err = json.Marshal(Introspection{
+		Active:    true,
+		ClientID:  "client_web",
+		Subject:   "1",
+		Expires:   1600000000,
+		TokenType: "Bearer",
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"active": true,
+		"client_id": "client_web",
+		"sub": "1",
+		"exp": 1600000000,
+		"token_type": "Bearer"
+	}`, string(body))
+}
+
+func TestOauthAccessTokenHasScope(t *testing.T) {
+	token := OauthAccessToken{Scope: null.StringFrom("catalog:read catalog:write")}
+
+	assert.True(t, token.HasScope())
+	assert.True(t, token.HasScope(PermissionCatalogRead))
+	assert.True(t, token.HasScope(PermissionCatalogRead, PermissionCatalogWrite))
+	assert.False(t, token.HasScope(PermissionCatalogRead, PermissionUsersAdmin))
+	assert.False(t, (&OauthAccessToken{}).HasScope(PermissionCatalogRead))
+}
@@ -5,13 +5,33 @@ import (
 	"strings"
 )
 
+// RevocationList tells whether a verified JWT access token is revoked, along
+// with the refresh token family it was issued with, or issued to a client that
+// is disabled since.
+type RevocationList interface {
+	IsRevoked(claims Claims) (bool, error)
+}
+
+// storeRevocationList is the RevocationList kept by the TokenStore.
+type storeRevocationList struct {
+	tokenStore TokenStore
+}
+
+func (l storeRevocationList) IsRevoked(claims Claims) (bool, error) {
+	return l.tokenStore.isJWTRevoked(claims)
+}
+
 type Parser struct {
-	TokenStore TokenStore
+	TokenStore     TokenStore
+	KeySet         *KeySet
+	RevocationList RevocationList
 }
 
-func NewParser(tokenStore TokenStore) *Parser {
+func NewParser(tokenStore TokenStore, keySet *KeySet) *Parser {
 	return &Parser{
-		TokenStore: tokenStore,
+		TokenStore:     tokenStore,
+		KeySet:         keySet,
+		RevocationList: storeRevocationList{tokenStore: tokenStore},
 	}
 }
 
@@ -29,6 +49,28 @@ func (p *Parser) Parse(accessToken string) (accessTokenClient OauthAccessToken,
 		return
 	}
 
+	// JWTs are verified locally, and only looked up among the revoked tokens
+	if p.KeySet != nil && isJWT(token[1]) {
+		claims, verifyErr := p.KeySet.Verify(token[1])
+		if verifyErr != nil {
+			err = verifyErr
+			return
+		}
+
+		revoked, revokedErr := p.RevocationList.IsRevoked(claims)
+		if revokedErr != nil {
+			err = revokedErr
+			return
+		}
+		if revoked {
+			err = errInvalidJWT
+			return
+		}
+
+		accessTokenClient = claims.toAccessToken(token[1])
+		return
+	}
+
 	accessTokenClient, err = p.TokenStore.resolveAccessTokenByAccessToken(token[1])
 	if err != nil {
 		return
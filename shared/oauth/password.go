@@ -1,7 +1,7 @@
 package oauth
 
 import (
-	"errors"
+	"github.com/guregu/null"
 )
 
 type PasswordAuth struct {
@@ -10,38 +10,79 @@ type PasswordAuth struct {
 }
 
 func (c *PasswordAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
-	client, err := c.tokenStore.resolveClientByClientID(credential.ClientID)
+	client, err := authenticateClient(c.tokenStore, credential)
 	if err != nil {
 		return
 	}
 
-	if !client.VerifyClient(credential) {
-		err = errors.New(ErrorInvalidClient)
+	if credential.Username == "" || credential.Password == "" {
+		err = NewError(ErrorCodeInvalidRequest, "Parameters username and password are required")
 		return
 	}
 
-	user, err := c.tokenStore.resolveByTelephoneOrEmail(credential.Username)
+	user, err := authenticateUser(c.tokenStore, c.config.Lockout, credential)
 	if err != nil {
 		return
 	}
 
-	if !user.ValidCredential(credential) {
-		err = errors.New(ErrorInvalidPassword)
+	scope, err := grantScope(client, credential.Scope, &user.UserType)
+	if err != nil {
 		return
 	}
 
-	accessToken, err := generateAccessToken()
+	oauthAccessToken = new(OauthAccessToken).Generate("", credential.ClientID, null.StringFrom(user.ID), scope, c.config)
+
+	// the refresh token is issued first, so that the access token belongs to
+	// its family
+	if client.AllowsGrant(RefreshToken) {
+		err = issueRefreshToken(c.tokenStore, &oauthAccessToken, c.config)
+		if err != nil {
+			return
+		}
+	}
+
+	err = issueAccessToken(c.tokenStore, &oauthAccessToken, c.config)
 	if err != nil {
-		err = errors.New(ErrorGenerateAccessToken)
 		return
 	}
 
-	oauthAccessToken = new(OauthAccessToken).Generate(accessToken, credential.ClientID, &user.ID, false, c.config)
+	return
+}
 
-	err = c.tokenStore.createAccessToken(oauthAccessToken)
-	if err != nil {
+// authenticateUser resolves the user of a credential, verifying their
+// password. Unknown users are reported like wrong passwords, and take as long
+// to reject. Failures are counted by the Lockout, unless nil.
+func authenticateUser(tokenStore TokenStore, lockout *Lockout, credential Credential) (user User, err error) {
+	user, err = tokenStore.resolveByUsernameOrEmail(credential.Username)
+	if err != nil && err.Error() != ErrorUserNotFound {
 		return
 	}
+	found := err == nil
 
+	account := lockoutAccount(user, credential)
+	if lockout != nil {
+		err = lockout.Check(account, credential.IPAddress)
+		if err != nil {
+			return User{}, err
+		}
+	}
+
+	if !found {
+		compareSecret("", credential.Password)
+	}
+	if !found || !user.ValidCredential(credential) {
+		invalid := NewError(ErrorCodeInvalidGrant, ErrorInvalidPassword)
+		if lockout != nil {
+			invalid.RetryAfter, err = lockout.Fail(account, credential.IPAddress)
+			if err != nil {
+				return User{}, err
+			}
+		}
+		return User{}, invalid
+	}
+
+	if lockout != nil {
+		err = lockout.Succeed(account)
+	}
 	return
 }
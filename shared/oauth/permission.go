@@ -0,0 +1,95 @@
+package oauth
+
+import (
+	"strings"
+
+	"github.com/guregu/null"
+)
+
+// Permissions are granted to access tokens as scopes, and required by routes.
+const (
+	PermissionCatalogRead     = "catalog:read"
+	PermissionCatalogWrite    = "catalog:write"
+	PermissionInventoryRead   = "inventory:read"
+	PermissionInventoryAdjust = "inventory:adjust"
+	PermissionWarehousesAdmin = "warehouses:admin"
+	PermissionUsersAdmin      = "users:admin"
+	PermissionSystemAdmin     = "system:admin"
+)
+
+// Permissions are all of the permissions.
+var Permissions = []string{
+	PermissionCatalogRead,
+	PermissionCatalogWrite,
+	PermissionInventoryRead,
+	PermissionInventoryAdjust,
+	PermissionWarehousesAdmin,
+	PermissionUsersAdmin,
+	PermissionSystemAdmin,
+}
+
+// Roles are the user types.
+const (
+	RoleAdmin   = "admin"
+	RoleRegular = "regular"
+)
+
+// RolePermissions maps the roles to the permissions their users may be
+// granted. Tokens issued to users are granted the scopes of their client
+// that their role permits.
+var RolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermissionCatalogRead,
+		PermissionCatalogWrite,
+		PermissionInventoryRead,
+		PermissionInventoryAdjust,
+		PermissionWarehousesAdmin,
+		PermissionUsersAdmin,
+		PermissionSystemAdmin,
+	},
+	RoleRegular: {
+		PermissionCatalogRead,
+		PermissionCatalogWrite,
+		PermissionInventoryRead,
+	},
+}
+
+// grantScope grants the requested scopes, or all of the client's scopes when
+// none are requested. Requesting scopes the client is not granted fails with
+// invalid_scope. The scopes of users are restricted to their role.
+func grantScope(client OauthClient, requested string, role *string) (null.String, error) {
+	allowed := strings.Fields(client.Scope.String)
+
+	granted := allowed
+	if requested != "" {
+		granted = strings.Fields(requested)
+		for _, scope := range granted {
+			if !containsScope(allowed, scope) {
+				return null.String{}, NewError(ErrorCodeInvalidScope, ErrorScopeNotAllowed)
+			}
+		}
+	}
+
+	if role != nil {
+		permitted := RolePermissions[*role]
+		restricted := make([]string, 0, len(granted))
+		for _, scope := range granted {
+			if containsScope(permitted, scope) {
+				restricted = append(restricted, scope)
+			}
+		}
+		granted = restricted
+	}
+
+	return null.NewString(strings.Join(granted, " "), len(granted) > 0), nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
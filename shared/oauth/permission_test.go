@@ -0,0 +1,55 @@
+package oauth
+
+import (
+	"testing"
+
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrantScope(t *testing.T) {
+	client := OauthClient{Scope: null.StringFrom("catalog:read catalog:write users:admin")}
+	admin, regular := RoleAdmin, RoleRegular
+
+	t.Run("grants the client's scopes by default", func(t *testing.T) {
+		scope, err := grantScope(client, "", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, null.StringFrom("catalog:read catalog:write users:admin"), scope)
+	})
+
+	t.Run("grants the requested scopes", func(t *testing.T) {
+		scope, err := grantScope(client, "catalog:read", nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, null.StringFrom("catalog:read"), scope)
+	})
+
+	t.Run("rejects scopes the client is not granted", func(t *testing.T) {
+		_, err := grantScope(client, "catalog:read inventory:adjust", nil)
+
+		assert.Equal(t, ErrorCodeInvalidScope, errorCode(t, err))
+	})
+
+	t.Run("restricts the scopes of users to their role", func(t *testing.T) {
+		scope, err := grantScope(client, "", &regular)
+		require.NoError(t, err)
+		assert.Equal(t, null.StringFrom("catalog:read catalog:write"), scope)
+
+		scope, err = grantScope(client, "users:admin", &regular)
+		require.NoError(t, err)
+		assert.False(t, scope.Valid)
+
+		scope, err = grantScope(client, "users:admin", &admin)
+		require.NoError(t, err)
+		assert.Equal(t, null.StringFrom("users:admin"), scope)
+	})
+
+	t.Run("grants nothing to clients without scopes", func(t *testing.T) {
+		scope, err := grantScope(OauthClient{}, "", nil)
+
+		require.NoError(t, err)
+		assert.False(t, scope.Valid)
+	})
+}
@@ -0,0 +1,66 @@
+package oauth
+
+import (
+	"context"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/gofrs/uuid"
+)
+
+// SystemActorID is the actor recorded in the audit fields of the changes the
+// system makes on its own, such as those of consumed events.
+var SystemActorID = uuid.Must(uuid.FromString("00000000-0000-0000-0000-000000000001"))
+
+// Principal is the authenticated caller of a request: a client, acting on
+// behalf of a user unless it authenticated with its own credentials.
+type Principal struct {
+	UserID   nuuid.NUUID
+	ClientID string
+	Scopes   []string
+}
+
+// HasScope reports whether the principal is granted a scope.
+func (p Principal) HasScope(scope string) bool {
+	return containsScope(p.Scopes, scope)
+}
+
+// SystemPrincipal returns the principal of the changes the system makes on its
+// own, acting as SystemActorID.
+func SystemPrincipal() Principal {
+	return Principal{UserID: nuuid.From(SystemActorID)}
+}
+
+// Principal returns the principal authenticated by this token.
+func (o *OauthAccessToken) Principal() Principal {
+	return Principal{
+		UserID:   nuuid.FromString(o.UserID.String),
+		ClientID: o.ClientID,
+		Scopes:   strings.Fields(o.Scope.String),
+	}
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal carried by ctx, if any.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
+
+// ActorFromContext returns the user acting in ctx, to be recorded in audit
+// fields. It fails with Unauthorized unless a user is authenticated.
+func ActorFromContext(ctx context.Context) (uuid.UUID, error) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || !principal.UserID.Valid {
+		return uuid.Nil, failure.Unauthorized("an authenticated user is required")
+	}
+
+	return principal.UserID.UUID, nil
+}
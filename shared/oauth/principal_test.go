@@ -0,0 +1,60 @@
+package oauth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/gofrs/uuid"
+	"github.com/guregu/null"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorFromContext(t *testing.T) {
+	userID := uuid.Must(uuid.NewV4())
+
+	t.Run("returns the user of the token", func(t *testing.T) {
+		token := OauthAccessToken{
+			ClientID: "client",
+			UserID:   null.StringFrom(userID.String()),
+			Scope:    null.StringFrom("catalog:read catalog:write"),
+		}
+		ctx := WithPrincipal(context.Background(), token.Principal())
+
+		actor, err := ActorFromContext(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, userID, actor)
+
+		principal, ok := PrincipalFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "client", principal.ClientID)
+		assert.True(t, principal.HasScope(PermissionCatalogWrite))
+	})
+
+	t.Run("requires a user", func(t *testing.T) {
+		token := OauthAccessToken{ClientID: "client"}
+		ctx := WithPrincipal(context.Background(), token.Principal())
+
+		_, err := ActorFromContext(ctx)
+
+		assert.Equal(t, http.StatusUnauthorized, failure.GetCode(err))
+	})
+
+	t.Run("returns the system actor", func(t *testing.T) {
+		ctx := WithPrincipal(context.Background(), SystemPrincipal())
+
+		actor, err := ActorFromContext(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, SystemActorID, actor)
+	})
+
+	t.Run("requires a principal", func(t *testing.T) {
+		_, err := ActorFromContext(context.Background())
+
+		assert.Equal(t, http.StatusUnauthorized, failure.GetCode(err))
+	})
+}
@@ -0,0 +1,104 @@
+package oauth
+
+import (
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultAccessTokenExpirySeconds  = 3600
+	defaultJWTExpirySeconds          = 5 * 60
+	defaultRefreshTokenExpirySeconds = 30 * 24 * 3600
+	defaultCodeExpirySeconds         = 60
+
+	defaultLockoutMaxUserFailures = 5
+	defaultLockoutMaxIPFailures   = 50
+	defaultLockoutWindowSeconds   = 15 * 60
+	defaultLockoutDurationSeconds = 15 * 60
+)
+
+// ProvideConfig is the provider for Config. It loads the KeySet when keys are
+// configured, which JWT access tokens require, and protects logins with a
+// Lockout backed by Redis when enabled. JWT access tokens expire after
+// OAUTH.JWT.EXPIRY_SECONDS, shortly by default, so that few revoked ones are
+// kept at a time.
+func ProvideConfig(config *configs.Config) Config {
+	oauthConfig := Config{
+		Expiration:        config.OAuth.AccessTokenExpirySeconds,
+		RefreshExpiration: config.OAuth.RefreshTokenExpirySeconds,
+		CodeExpiration:    config.OAuth.AuthorizationCodeExpirySeconds,
+		ClientScope:       config.OAuth.ClientScope,
+		TokenFormat:       config.OAuth.TokenFormat,
+	}
+	if oauthConfig.Expiration <= 0 {
+		oauthConfig.Expiration = defaultAccessTokenExpirySeconds
+	}
+	if oauthConfig.RefreshExpiration <= 0 {
+		oauthConfig.RefreshExpiration = defaultRefreshTokenExpirySeconds
+	}
+	if oauthConfig.CodeExpiration <= 0 {
+		oauthConfig.CodeExpiration = defaultCodeExpirySeconds
+	}
+	if oauthConfig.TokenFormat == "" {
+		oauthConfig.TokenFormat = TokenFormatOpaque
+	}
+	if oauthConfig.TokenFormat == TokenFormatJWT {
+		oauthConfig.Expiration = config.OAuth.JWT.ExpirySeconds
+		if oauthConfig.Expiration <= 0 {
+			oauthConfig.Expiration = defaultJWTExpirySeconds
+		}
+	}
+
+	if len(config.OAuth.JWT.KeyFiles) > 0 {
+		keySet, err := LoadKeySet(config.OAuth.JWT.Issuer, config.OAuth.JWT.KeyFiles)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed loading JWT keys")
+		}
+		oauthConfig.KeySet = keySet
+		log.Info().Str("kid", keySet.KeyID()).Msg("JWT keys loaded.")
+	}
+
+	if oauthConfig.TokenFormat == TokenFormatJWT && oauthConfig.KeySet == nil {
+		log.Fatal().Msg("JWT access tokens require JWT keys")
+	}
+
+	if config.OAuth.Lockout.Enabled {
+		oauthConfig.Lockout = provideLockout(config)
+	}
+
+	return oauthConfig
+}
+
+func provideLockout(config *configs.Config) *Lockout {
+	lockoutConfig := config.OAuth.Lockout
+	if lockoutConfig.MaxUserFailures <= 0 {
+		lockoutConfig.MaxUserFailures = defaultLockoutMaxUserFailures
+	}
+	if lockoutConfig.MaxIPFailures <= 0 {
+		lockoutConfig.MaxIPFailures = defaultLockoutMaxIPFailures
+	}
+	if lockoutConfig.WindowSeconds <= 0 {
+		lockoutConfig.WindowSeconds = defaultLockoutWindowSeconds
+	}
+	if lockoutConfig.DurationSeconds <= 0 {
+		lockoutConfig.DurationSeconds = defaultLockoutDurationSeconds
+	}
+
+	log.Info().
+		Int64("maxUserFailures", lockoutConfig.MaxUserFailures).
+		Int64("maxIPFailures", lockoutConfig.MaxIPFailures).
+		Int64("durationSeconds", lockoutConfig.DurationSeconds).
+		Msg("Login lockout enabled.")
+
+	return NewLockout(NewLoginAttemptStoreRedis(infras.RedisNewClient(*config)), LockoutConfig{
+		MaxUserFailures: lockoutConfig.MaxUserFailures,
+		MaxIPFailures:   lockoutConfig.MaxIPFailures,
+		Window:          time.Duration(lockoutConfig.WindowSeconds) * time.Second,
+		Duration:        time.Duration(lockoutConfig.DurationSeconds) * time.Second,
+		Delay:           time.Duration(lockoutConfig.DelayMilliseconds) * time.Millisecond,
+		MaxDelay:        time.Duration(lockoutConfig.MaxDelaySeconds) * time.Second,
+	})
+}
@@ -0,0 +1,43 @@
+package oauth
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProvideConfig(t *testing.T) {
+	t.Run("defaults the expiration of tokens", func(t *testing.T) {
+		oauthConfig := ProvideConfig(&configs.Config{})
+
+		assert.Equal(t, TokenFormatOpaque, oauthConfig.TokenFormat)
+		assert.Equal(t, int64(defaultAccessTokenExpirySeconds), oauthConfig.Expiration)
+		assert.Equal(t, int64(defaultRefreshTokenExpirySeconds), oauthConfig.RefreshExpiration)
+		assert.Equal(t, int64(defaultCodeExpirySeconds), oauthConfig.CodeExpiration)
+	})
+
+	t.Run("expires JWT access tokens shortly", func(t *testing.T) {
+		der, err := x509.MarshalECPrivateKey(newECKey(t))
+		require.NoError(t, err)
+		file := filepath.Join(t.TempDir(), "key.pem")
+		require.NoError(t, ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+
+		config := &configs.Config{}
+		config.OAuth.AccessTokenExpirySeconds = 3600
+		config.OAuth.TokenFormat = TokenFormatJWT
+		config.OAuth.JWT.KeyFiles = []string{file}
+
+		oauthConfig := ProvideConfig(config)
+		assert.Equal(t, int64(defaultJWTExpirySeconds), oauthConfig.Expiration)
+
+		config.OAuth.JWT.ExpirySeconds = 60
+		oauthConfig = ProvideConfig(config)
+		assert.Equal(t, int64(60), oauthConfig.Expiration)
+	})
+}
@@ -0,0 +1,92 @@
+package oauth
+
+import "github.com/guregu/null"
+
+// RefreshTokenAuth exchanges a refresh token for a new access token. Refresh
+// tokens are rotated on every use: the used token is revoked and a new one of
+// the same family is issued. Reusing a revoked token revokes its whole family,
+// as either the client or an attacker holds a stolen token.
+type RefreshTokenAuth struct {
+	tokenStore TokenStore
+	config     Config
+}
+
+func (c *RefreshTokenAuth) Create(credential Credential) (oauthAccessToken OauthAccessToken, err error) {
+	_, err = authenticateClient(c.tokenStore, credential)
+	if err != nil {
+		return
+	}
+
+	if credential.RefreshToken == "" {
+		err = NewError(ErrorCodeInvalidRequest, "Parameter refresh_token is required")
+		return
+	}
+
+	refreshToken, err := c.tokenStore.resolveRefreshToken(credential.RefreshToken)
+	if err != nil {
+		return
+	}
+
+	if refreshToken.ClientID != credential.ClientID || !refreshToken.VerifyExpireIn() {
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidRefreshToken)
+		return
+	}
+
+	if refreshToken.Revoked.Valid {
+		err = c.revokeFamily(refreshToken)
+		return
+	}
+
+	rotated, err := new(OauthRefreshToken).Rotate(refreshToken, c.config)
+	if err != nil {
+		return
+	}
+
+	err = c.tokenStore.rotateRefreshToken(refreshToken, rotated)
+	if err == errRefreshTokenReused {
+		// another request rotated the token meanwhile
+		err = c.revokeFamily(refreshToken)
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	oauthAccessToken = new(OauthAccessToken).Generate("", credential.ClientID, refreshToken.UserID, refreshToken.Scope, c.config)
+	oauthAccessToken.RefreshToken = rotated.RefreshToken
+	oauthAccessToken.FamilyID = null.StringFrom(rotated.FamilyID)
+
+	err = issueAccessToken(c.tokenStore, &oauthAccessToken, c.config)
+	if err != nil {
+		return
+	}
+
+	return
+}
+
+func (c *RefreshTokenAuth) revokeFamily(refreshToken OauthRefreshToken) error {
+	err := c.tokenStore.revokeRefreshTokenFamily(refreshToken.FamilyID)
+	if err != nil {
+		return err
+	}
+
+	return NewError(ErrorCodeInvalidGrant, ErrorRefreshTokenReused)
+}
+
+// issueRefreshToken issues the refresh token of a new family alongside an
+// access token, which belongs to the family from then on.
+func issueRefreshToken(tokenStore TokenStore, oauthAccessToken *OauthAccessToken, config Config) error {
+	refreshToken, err := new(OauthRefreshToken).Generate(*oauthAccessToken, config)
+	if err != nil {
+		return err
+	}
+
+	err = tokenStore.createRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	oauthAccessToken.RefreshToken = refreshToken.RefreshToken
+	oauthAccessToken.FamilyID = null.StringFrom(refreshToken.FamilyID)
+	return nil
+}
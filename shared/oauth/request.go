@@ -0,0 +1,115 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ParseTokenRequest reads the Credential of a form-encoded token request,
+// RFC 6749 section 4.
+func ParseTokenRequest(r *http.Request) (credential Credential, err error) {
+	err = r.ParseForm()
+	if err != nil {
+		return credential, NewError(ErrorCodeInvalidRequest, "Request body must be form-encoded")
+	}
+
+	for key, values := range r.PostForm {
+		if len(values) > 1 {
+			return credential, NewError(ErrorCodeInvalidRequest, fmt.Sprintf("Parameter %s must not be repeated", key))
+		}
+	}
+
+	credential = Credential{
+		GrantType:    GrantType(r.PostForm.Get("grant_type")),
+		Username:     r.PostForm.Get("username"),
+		Password:     r.PostForm.Get("password"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+	}
+	if credential.GrantType == "" {
+		return credential, NewError(ErrorCodeInvalidRequest, "Parameter grant_type is required")
+	}
+
+	credential.ClientID, credential.ClientSecret, err = ParseClientAuthentication(r)
+	return
+}
+
+// ParseClientAuthentication reads the client credentials of a request, sent
+// either with HTTP Basic or as the client_id and client_secret parameters of
+// a parsed form, RFC 6749 section 2.3.1.
+func ParseClientAuthentication(r *http.Request) (clientID string, clientSecret string, err error) {
+	username, password, basic := r.BasicAuth()
+	if basic {
+		if r.PostForm.Get("client_id") != "" || r.PostForm.Get("client_secret") != "" {
+			err = NewError(ErrorCodeInvalidRequest, "Client must authenticate with a single method")
+			return
+		}
+
+		// the credentials are form-encoded before being Base64-encoded
+		clientID, err = url.QueryUnescape(username)
+		if err == nil {
+			clientSecret, err = url.QueryUnescape(password)
+		}
+		if err != nil {
+			err = NewError(ErrorCodeInvalidClient, ErrorInvalidClient)
+		}
+		return
+	}
+
+	clientID = r.PostForm.Get("client_id")
+	clientSecret = r.PostForm.Get("client_secret")
+	if clientID == "" {
+		err = NewError(ErrorCodeInvalidClient, ErrorInvalidClient)
+	}
+	return
+}
+
+// ParseTokenReference reads a form-encoded revocation or introspection
+// request, RFC 7009 section 2.1 and RFC 7662 section 2.1.
+func ParseTokenReference(r *http.Request) (reference TokenReference, err error) {
+	err = r.ParseForm()
+	if err != nil {
+		return reference, NewError(ErrorCodeInvalidRequest, "Request body must be form-encoded")
+	}
+
+	reference = TokenReference{
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: TokenTypeHint(r.PostForm.Get("token_type_hint")),
+	}
+	if reference.Token == "" {
+		return reference, NewError(ErrorCodeInvalidRequest, "Parameter token is required")
+	}
+
+	reference.Credential.ClientID, reference.Credential.ClientSecret, err = ParseClientAuthentication(r)
+	return
+}
+
+// ParseAuthorizationRequest reads an authorization request, from the query of
+// a GET request or the form of a POST one, RFC 6749 section 4.1.1.
+func ParseAuthorizationRequest(r *http.Request) (request AuthorizationRequest, err error) {
+	err = r.ParseForm()
+	if err != nil {
+		return request, NewError(ErrorCodeInvalidRequest, "Request must be form-encoded")
+	}
+
+	for key, values := range r.Form {
+		if len(values) > 1 {
+			return request, NewError(ErrorCodeInvalidRequest, fmt.Sprintf("Parameter %s must not be repeated", key))
+		}
+	}
+
+	request = AuthorizationRequest{
+		ResponseType:        r.Form.Get("response_type"),
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		Scope:               r.Form.Get("scope"),
+		State:               r.Form.Get("state"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+	}
+	return
+}
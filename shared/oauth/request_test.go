@@ -0,0 +1,124 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTokenRequest(form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func errorCode(t *testing.T, err error) ErrorCode {
+	oauthErr, ok := err.(*Error)
+	require.True(t, ok, "%v is not an OAuth error", err)
+	return oauthErr.Code
+}
+
+func TestParseTokenRequest(t *testing.T) {
+	t.Run("reads client credentials from the body", func(t *testing.T) {
+		credential, err := ParseTokenRequest(newTokenRequest(url.Values{
+			"grant_type":    {"password"},
+			"client_id":     {"client_web"},
+			"client_secret": {"secret"},
+			"username":      {"user@example.com"},
+			"password":      {"password"},
+			"refresh_token": {"refresh"},
+		}))
+
+		require.NoError(t, err)
+		assert.Equal(t, Credential{
+			GrantType:    Password,
+			ClientID:     "client_web",
+			ClientSecret: "secret",
+			Username:     "user@example.com",
+			Password:     "password",
+			RefreshToken: "refresh",
+		}, credential)
+	})
+
+	t.Run("reads form-encoded client credentials from HTTP Basic", func(t *testing.T) {
+		r := newTokenRequest(url.Values{"grant_type": {"client_credentials"}})
+		r.SetBasicAuth("client%3Aweb", "s%2Bcret")
+
+		credential, err := ParseTokenRequest(r)
+
+		require.NoError(t, err)
+		assert.Equal(t, "client:web", credential.ClientID)
+		assert.Equal(t, "s+cret", credential.ClientSecret)
+	})
+
+	t.Run("rejects several client authentication methods", func(t *testing.T) {
+		r := newTokenRequest(url.Values{"grant_type": {"client_credentials"}, "client_id": {"client_web"}})
+		r.SetBasicAuth("client_web", "secret")
+
+		_, err := ParseTokenRequest(r)
+
+		assert.Equal(t, ErrorCodeInvalidRequest, errorCode(t, err))
+	})
+
+	t.Run("rejects requests without client", func(t *testing.T) {
+		_, err := ParseTokenRequest(newTokenRequest(url.Values{"grant_type": {"client_credentials"}}))
+
+		assert.Equal(t, ErrorCodeInvalidClient, errorCode(t, err))
+		assert.Equal(t, http.StatusUnauthorized, err.(*Error).StatusCode())
+	})
+
+	t.Run("rejects requests without grant type", func(t *testing.T) {
+		_, err := ParseTokenRequest(newTokenRequest(url.Values{"client_id": {"client_web"}}))
+
+		assert.Equal(t, ErrorCodeInvalidRequest, errorCode(t, err))
+		assert.Equal(t, http.StatusBadRequest, err.(*Error).StatusCode())
+	})
+
+	t.Run("rejects repeated parameters", func(t *testing.T) {
+		_, err := ParseTokenRequest(newTokenRequest(url.Values{
+			"grant_type": {"client_credentials", "password"},
+			"client_id":  {"client_web"},
+		}))
+
+		assert.Equal(t, ErrorCodeInvalidRequest, errorCode(t, err))
+	})
+}
+
+func TestGrantCreateUnsupportedGrantType(t *testing.T) {
+	_, err := NewGrant(TokenStore{}, Config{}).Create(Credential{GrantType: "implicit"})
+
+	assert.Equal(t, ErrorCodeUnsupportedGrantType, errorCode(t, err))
+}
+
+func TestParseTokenReference(t *testing.T) {
+	t.Run("reads the token and its client", func(t *testing.T) {
+		r := newTokenRequest(url.Values{"token": {"token"}, "token_type_hint": {"refresh_token"}})
+		r.SetBasicAuth("client_web", "secret")
+
+		reference, err := ParseTokenReference(r)
+
+		require.NoError(t, err)
+		assert.Equal(t, TokenReference{
+			Credential:    Credential{ClientID: "client_web", ClientSecret: "secret"},
+			Token:         "token",
+			TokenTypeHint: RefreshTokenHint,
+		}, reference)
+	})
+
+	t.Run("rejects requests without token", func(t *testing.T) {
+		_, err := ParseTokenReference(newTokenRequest(url.Values{"client_id": {"client_web"}}))
+
+		assert.Equal(t, ErrorCodeInvalidRequest, errorCode(t, err))
+	})
+
+	t.Run("rejects requests without client", func(t *testing.T) {
+		_, err := ParseTokenReference(newTokenRequest(url.Values{"token": {"token"}}))
+
+		assert.Equal(t, ErrorCodeInvalidClient, errorCode(t, err))
+	})
+}
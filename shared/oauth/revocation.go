@@ -0,0 +1,176 @@
+package oauth
+
+// TokenTypeHint hints at the type of a revoked or introspected token.
+type TokenTypeHint string
+
+const (
+	AccessTokenHint  TokenTypeHint = "access_token"
+	RefreshTokenHint TokenTypeHint = "refresh_token"
+)
+
+// TokenReference is a revocation or introspection request about a token, RFC
+// 7009 section 2.1 and RFC 7662 section 2.1.
+type TokenReference struct {
+	Credential    Credential
+	Token         string
+	TokenTypeHint TokenTypeHint
+}
+
+// Introspection describes a token, RFC 7662 section 2.2. Inactive tokens are
+// not described further.
+type Introspection struct {
+	Active    bool   `json:"active"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	Expires   int64  `json:"exp,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+}
+
+// Revocation revokes and introspects the tokens issued to clients.
+type Revocation struct {
+	TokenStore TokenStore
+	KeySet     *KeySet
+}
+
+func NewRevocation(tokenStore TokenStore, keySet *KeySet) *Revocation {
+	return &Revocation{
+		TokenStore: tokenStore,
+		KeySet:     keySet,
+	}
+}
+
+// Revoke revokes a token issued to the requesting client. Access tokens are
+// deleted, or denied until they expire when they are JWTs, and refresh tokens
+// are revoked along their family and the access tokens issued along them.
+// Unknown tokens are ignored, as they are of no use anyway.
+func (r *Revocation) Revoke(reference TokenReference) error {
+	_, err := verifyClient(r.TokenStore, reference.Credential)
+	if err != nil {
+		return err
+	}
+
+	accessToken, refreshToken, err := r.resolve(reference)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case accessToken != nil:
+		if accessToken.ClientID != reference.Credential.ClientID {
+			return NewError(ErrorCodeUnauthorizedClient, ErrorTokenNotOwned)
+		}
+		if isJWT(accessToken.AccessToken) {
+			return r.TokenStore.revokeJWT(*accessToken)
+		}
+		return r.TokenStore.deleteAccessToken(accessToken.AccessToken)
+	case refreshToken != nil:
+		if refreshToken.ClientID != reference.Credential.ClientID {
+			return NewError(ErrorCodeUnauthorizedClient, ErrorTokenNotOwned)
+		}
+		return r.TokenStore.revokeRefreshTokenFamily(refreshToken.FamilyID)
+	}
+
+	return nil
+}
+
+// Introspect describes a token to an authenticated confidential client, which
+// may be a resource server introspecting the tokens of other clients.
+func (r *Revocation) Introspect(reference TokenReference) (introspection Introspection, err error) {
+	client, err := verifyClient(r.TokenStore, reference.Credential)
+	if err != nil {
+		return
+	}
+
+	if !client.AllowsIntrospection() {
+		err = NewError(ErrorCodeUnauthorizedClient, ErrorCannotIntrospect)
+		return
+	}
+
+	accessToken, refreshToken, err := r.resolve(reference)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case accessToken != nil && accessToken.VerifyExpireIn():
+		introspection = Introspection{
+			Active:    true,
+			ClientID:  accessToken.ClientID,
+			Subject:   accessToken.UserID.String,
+			Scope:     accessToken.Scope.String,
+			Expires:   accessToken.Expires.Unix(),
+			TokenType: string(Bearer),
+		}
+	case refreshToken != nil && refreshToken.VerifyExpireIn() && !refreshToken.Revoked.Valid:
+		introspection = Introspection{
+			Active:   true,
+			ClientID: refreshToken.ClientID,
+			Subject:  refreshToken.UserID.String,
+			Scope:    refreshToken.Scope.String,
+			Expires:  refreshToken.Expires.Unix(),
+		}
+	}
+
+	return
+}
+
+// resolve resolves the referenced token, looking for the hinted type of token
+// first. Both tokens are nil when the token is unknown.
+func (r *Revocation) resolve(reference TokenReference) (accessToken *OauthAccessToken, refreshToken *OauthRefreshToken, err error) {
+	if reference.TokenTypeHint == RefreshTokenHint {
+		refreshToken, err = r.resolveRefreshToken(reference.Token)
+		if refreshToken != nil || err != nil {
+			return
+		}
+		accessToken, err = r.resolveAccessToken(reference.Token)
+		return
+	}
+
+	accessToken, err = r.resolveAccessToken(reference.Token)
+	if accessToken != nil || err != nil {
+		return
+	}
+	refreshToken, err = r.resolveRefreshToken(reference.Token)
+	return
+}
+
+func (r *Revocation) resolveAccessToken(token string) (*OauthAccessToken, error) {
+	if r.KeySet != nil && isJWT(token) {
+		claims, err := r.KeySet.Verify(token)
+		if err != nil {
+			// invalid and expired tokens are unknown
+			return nil, nil
+		}
+
+		revoked, err := r.TokenStore.isJWTRevoked(claims)
+		if err != nil || revoked {
+			return nil, err
+		}
+
+		accessToken := claims.toAccessToken(token)
+		return &accessToken, nil
+	}
+
+	accessToken, err := r.TokenStore.resolveAccessTokenByAccessToken(token)
+	if err != nil {
+		if err.Error() == ErrorClientNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &accessToken, nil
+}
+
+func (r *Revocation) resolveRefreshToken(token string) (*OauthRefreshToken, error) {
+	refreshToken, err := r.TokenStore.resolveRefreshToken(token)
+	if err != nil {
+		if _, ok := err.(*Error); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &refreshToken, nil
+}
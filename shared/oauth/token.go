@@ -3,6 +3,7 @@ package oauth
 import (
 	"crypto/rand"
 	"crypto/sha512"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -32,3 +33,32 @@ func generateAccessToken() (string, error) {
 
 	return string(accessToken[0:40]), nil
 }
+
+// issueAccessToken sets the token of a generated access token. Opaque tokens
+// are stored, while JWTs are signed and only checked against the revoked
+// tokens when verified.
+func issueAccessToken(tokenStore TokenStore, oauthAccessToken *OauthAccessToken, config Config) error {
+	if config.TokenFormat == TokenFormatJWT {
+		token, err := config.KeySet.Sign(Claims{
+			Subject:   oauthAccessToken.UserID.String,
+			ClientID:  oauthAccessToken.ClientID,
+			Scope:     oauthAccessToken.Scope.String,
+			Expires:   oauthAccessToken.Expires.Unix(),
+			SessionID: oauthAccessToken.FamilyID.String,
+		})
+		if err != nil {
+			return err
+		}
+
+		oauthAccessToken.AccessToken = token
+		return nil
+	}
+
+	accessToken, err := generateAccessToken()
+	if err != nil {
+		return errors.New(ErrorGenerateAccessToken)
+	}
+
+	oauthAccessToken.AccessToken = accessToken
+	return tokenStore.createAccessToken(*oauthAccessToken)
+}
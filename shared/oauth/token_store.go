@@ -3,7 +3,10 @@ package oauth
 import (
 	"database/sql"
 	"errors"
+	"time"
 
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -11,19 +14,25 @@ type TokenStore struct {
 	db *sqlx.DB
 }
 
+var errRefreshTokenReused = errors.New(ErrorRefreshTokenReused)
+
+const mysqlErrorDuplicateEntry = 1062
+
 const (
 	queryInsertAccessToken = `INSERT INTO oauth_access_tokens (
 			access_token,
 			client_id,
 			user_id,
 			expires,
-			scope
+			scope,
+			family_id
 		) VALUES (
 			:access_token,
 			:client_id,
 			:user_id,
 			:expires,
-			:scope
+			:scope,
+			:family_id
 		)`
 
 	querySelectAccessToken = `SELECT 
@@ -31,7 +40,8 @@ const (
 			client_id,
 			user_id,
 			expires,
-			scope
+			scope,
+			family_id
 		FROM
 			oauth_access_tokens`
 
@@ -39,15 +49,129 @@ const (
 			client_id,
 			client_secret,
 			redirect_uri,
-			grant_types
+			grant_types,
+			scope,
+			created,
+			secret_rotated,
+			disabled
 		FROM 
 			oauth_clients`
 
+	queryInsertClient = `INSERT INTO oauth_clients (
+			client_id,
+			client_secret,
+			redirect_uri,
+			grant_types,
+			scope,
+			created
+		) VALUES (
+			:client_id,
+			:client_secret,
+			:redirect_uri,
+			:grant_types,
+			:scope,
+			:created
+		)`
+
+	queryUpdateClientSecret = `UPDATE oauth_clients SET client_secret = ?, secret_rotated = ? WHERE client_id = ?`
+
+	queryDisableClient = `UPDATE oauth_clients SET disabled = ? WHERE client_id = ? AND disabled IS NULL`
+
+	queryDeleteClientAccessTokens = `DELETE FROM oauth_access_tokens WHERE client_id = ?`
+
+	queryRevokeClientRefreshTokens = `UPDATE oauth_refresh_tokens SET revoked = ? WHERE client_id = ? AND revoked IS NULL`
+
+	queryDeleteAccessToken = `DELETE FROM oauth_access_tokens WHERE access_token = ?`
+
+	queryDeleteExpiredAccessTokens = `DELETE FROM oauth_access_tokens WHERE expires < ?`
+
+	queryInsertRefreshToken = `INSERT INTO oauth_refresh_tokens (
+			refresh_token,
+			family_id,
+			client_id,
+			user_id,
+			scope,
+			expires
+		) VALUES (
+			:refresh_token,
+			:family_id,
+			:client_id,
+			:user_id,
+			:scope,
+			:expires
+		)`
+
+	querySelectRefreshToken = `SELECT
+			refresh_token,
+			family_id,
+			client_id,
+			user_id,
+			scope,
+			expires,
+			revoked
+		FROM
+			oauth_refresh_tokens`
+
+	queryRevokeRefreshToken = `UPDATE oauth_refresh_tokens SET revoked = ? WHERE refresh_token = ? AND revoked IS NULL`
+
+	queryRevokeRefreshTokenFamily = `UPDATE oauth_refresh_tokens SET revoked = ? WHERE family_id = ? AND revoked IS NULL`
+
+	queryDeleteFamilyAccessTokens = `DELETE FROM oauth_access_tokens WHERE family_id = ?`
+
+	// a family is denied for as long as the JWTs issued along its refresh
+	// tokens may live
+	queryInsertRevokedFamily = `INSERT IGNORE INTO oauth_revoked_tokens (token_id, expires)
+		SELECT family_id, MAX(expires) FROM oauth_refresh_tokens WHERE family_id = ? GROUP BY family_id`
+
+	queryInsertRevokedToken = `INSERT IGNORE INTO oauth_revoked_tokens (token_id, expires) VALUES (?, ?)`
+
+	querySelectTokenRevoked = `SELECT
+			EXISTS (SELECT 1 FROM oauth_revoked_tokens WHERE token_id IN (?, ?))
+			OR NOT EXISTS (SELECT 1 FROM oauth_clients WHERE client_id = ? AND disabled IS NULL)`
+
+	queryDeleteExpiredRevokedTokens = `DELETE FROM oauth_revoked_tokens WHERE expires < ?`
+
+	queryDeleteExpiredRefreshTokens = `DELETE FROM oauth_refresh_tokens WHERE expires < ?`
+
+	queryInsertAuthorizationCode = `INSERT INTO oauth_authorization_codes (
+			code,
+			client_id,
+			user_id,
+			redirect_uri,
+			scope,
+			code_challenge,
+			expires
+		) VALUES (
+			:code,
+			:client_id,
+			:user_id,
+			:redirect_uri,
+			:scope,
+			:code_challenge,
+			:expires
+		)`
+
+	querySelectAuthorizationCode = `SELECT
+			code,
+			client_id,
+			user_id,
+			redirect_uri,
+			scope,
+			code_challenge,
+			expires
+		FROM
+			oauth_authorization_codes`
+
+	queryDeleteAuthorizationCode = `DELETE FROM oauth_authorization_codes WHERE code = ? AND client_id = ?`
+
+	queryDeleteExpiredAuthorizationCodes = `DELETE FROM oauth_authorization_codes WHERE expires < ?`
+
 	querySelectUser = `
 			SELECT
-				id,
+				userId,
 				username,
-				password
+				password,
+				userType
 			FROM
 				user`
 )
@@ -73,6 +197,189 @@ func (a *TokenStore) createAccessToken(accessToken OauthAccessToken) error {
 	return nil
 }
 
+func (a *TokenStore) deleteAccessToken(accessToken string) error {
+	_, err := a.db.Exec(queryDeleteAccessToken, accessToken)
+	return err
+}
+
+func (a *TokenStore) deleteExpiredAccessTokens(before time.Time) (int64, error) {
+	result, err := a.db.Exec(queryDeleteExpiredAccessTokens, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (a *TokenStore) createRefreshToken(refreshToken OauthRefreshToken) error {
+	stmt, err := a.db.PrepareNamed(queryInsertRefreshToken)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(refreshToken)
+	return err
+}
+
+func (a *TokenStore) resolveRefreshToken(refreshToken string) (oauthRefreshToken OauthRefreshToken, err error) {
+	err = a.db.Get(&oauthRefreshToken, querySelectRefreshToken+" WHERE refresh_token = ?", refreshToken)
+	if err == sql.ErrNoRows {
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidRefreshToken)
+	}
+
+	return
+}
+
+// rotateRefreshToken revokes a refresh token and stores its replacement, or
+// fails with errRefreshTokenReused when the token is revoked already.
+func (a *TokenStore) rotateRefreshToken(previous OauthRefreshToken, next OauthRefreshToken) error {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(queryRevokeRefreshToken, time.Now(), previous.RefreshToken)
+	if err != nil {
+		return err
+	}
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return errRefreshTokenReused
+	}
+
+	stmt, err := tx.PrepareNamed(queryInsertRefreshToken)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(next)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// revokeRefreshTokenFamily revokes the refresh tokens of a family, deleting
+// the access tokens issued along them and denying their JWTs.
+func (a *TokenStore) revokeRefreshTokenFamily(familyID string) error {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(queryRevokeRefreshTokenFamily, time.Now(), familyID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(queryDeleteFamilyAccessTokens, familyID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(queryInsertRevokedFamily, familyID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// revokeJWT denies a JWT access token until it expires.
+func (a *TokenStore) revokeJWT(accessToken OauthAccessToken) error {
+	_, err := a.db.Exec(queryInsertRevokedToken, accessToken.TokenID, accessToken.Expires)
+	return err
+}
+
+// isJWTRevoked reports whether a JWT access token, or the refresh token
+// family it was issued along, is revoked, or its client is disabled.
+func (a *TokenStore) isJWTRevoked(claims Claims) (revoked bool, err error) {
+	err = a.db.Get(&revoked, querySelectTokenRevoked, claims.ID, claims.SessionID, claims.ClientID)
+	return
+}
+
+func (a *TokenStore) deleteExpiredRevokedTokens(before time.Time) (int64, error) {
+	result, err := a.db.Exec(queryDeleteExpiredRevokedTokens, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (a *TokenStore) deleteExpiredRefreshTokens(before time.Time) (int64, error) {
+	result, err := a.db.Exec(queryDeleteExpiredRefreshTokens, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (a *TokenStore) createAuthorizationCode(code OauthAuthorizationCode) error {
+	stmt, err := a.db.PrepareNamed(queryInsertAuthorizationCode)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(code)
+	return err
+}
+
+// consumeAuthorizationCode resolves and deletes an authorization code issued
+// to a client, so that it is exchanged once. It fails with invalid_grant when
+// the code is unknown, issued to another client or consumed already, leaving
+// the codes of other clients alone.
+func (a *TokenStore) consumeAuthorizationCode(code string, clientID string) (oauthAuthorizationCode OauthAuthorizationCode, err error) {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.Get(&oauthAuthorizationCode, querySelectAuthorizationCode+" WHERE code = ? AND client_id = ? FOR UPDATE", code, clientID)
+	if err == sql.ErrNoRows {
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidCode)
+	}
+	if err != nil {
+		return
+	}
+
+	result, err := tx.Exec(queryDeleteAuthorizationCode, code, clientID)
+	if err != nil {
+		return
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if deleted == 0 {
+		err = NewError(ErrorCodeInvalidGrant, ErrorInvalidCode)
+		return
+	}
+
+	err = tx.Commit()
+	return
+}
+
+func (a *TokenStore) deleteExpiredAuthorizationCodes(before time.Time) (int64, error) {
+	result, err := a.db.Exec(queryDeleteExpiredAuthorizationCodes, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
 func (a *TokenStore) resolveAccessTokenByAccessToken(accessToken string) (oauthAccessToken OauthAccessToken, err error) {
 	err = a.db.Get(&oauthAccessToken, querySelectAccessToken+" WHERE access_token = ?", accessToken)
 	switch {
@@ -86,10 +393,10 @@ func (a *TokenStore) resolveAccessTokenByAccessToken(accessToken string) (oauthA
 	return
 }
 
-func (a *TokenStore) resolveAllClients(db *sqlx.DB) ([]OauthClient, error) {
-	var clients []OauthClient
+func (a *TokenStore) resolveAllClients() ([]OauthClient, error) {
+	clients := make([]OauthClient, 0)
 
-	err := a.db.Get(&clients, querySelectClients)
+	err := a.db.Select(&clients, querySelectClients+" ORDER BY client_id")
 	if err != nil {
 		return []OauthClient{}, err
 	}
@@ -97,6 +404,62 @@ func (a *TokenStore) resolveAllClients(db *sqlx.DB) ([]OauthClient, error) {
 	return clients, nil
 }
 
+// createClient stores a new client, failing with Conflict when its ID is
+// taken.
+func (a *TokenStore) createClient(client OauthClient) error {
+	stmt, err := a.db.PrepareNamed(queryInsertClient)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(client)
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlErrorDuplicateEntry {
+		return failure.Conflict("register", "client", "client ID is taken")
+	}
+
+	return err
+}
+
+func (a *TokenStore) updateClientSecret(client OauthClient) error {
+	_, err := a.db.Exec(queryUpdateClientSecret, client.ClientSecret, client.SecretRotated, client.ClientID)
+	return err
+}
+
+// disableClient disables a client, deleting its access tokens and revoking its
+// refresh tokens. It fails with Conflict when the client is disabled already.
+func (a *TokenStore) disableClient(client OauthClient) error {
+	tx, err := a.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(queryDisableClient, client.Disabled, client.ClientID)
+	if err != nil {
+		return err
+	}
+	disabled, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if disabled == 0 {
+		return failure.Conflict("disable", "client", "already disabled")
+	}
+
+	_, err = tx.Exec(queryDeleteClientAccessTokens, client.ClientID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(queryRevokeClientRefreshTokens, client.Disabled, client.ClientID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func (a *TokenStore) resolveClientByClientID(clientID string) (client OauthClient, err error) {
 	err = a.db.Get(&client, querySelectClients+" WHERE client_id = ?", clientID)
 	switch {
@@ -110,13 +473,13 @@ func (a *TokenStore) resolveClientByClientID(clientID string) (client OauthClien
 	return
 }
 
-func (a *TokenStore) resolveByTelephoneOrEmail(username string) (User, error) {
+func (a *TokenStore) resolveByUsernameOrEmail(username string) (User, error) {
 	var user User
 
-	err := a.db.Get(&user, querySelectUser+" WHERE telephone = ? OR  email = ?", username, username)
+	err := a.db.Get(&user, querySelectUser+" WHERE (username = ? OR email = ?) AND deletedAt IS NULL", username, username)
 	switch {
 	case err == sql.ErrNoRows:
-		return User{}, errors.New(ErrorClientNotFound)
+		return User{}, errors.New(ErrorUserNotFound)
 	case err != nil:
 		return User{}, err
 	}
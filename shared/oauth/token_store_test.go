@@ -0,0 +1,48 @@
+package oauth
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userColumns lists the columns of the user table, as created and altered by
+// the migrations.
+func userColumns(t *testing.T) map[string]bool {
+	columns := make(map[string]bool)
+
+	schema, err := ioutil.ReadFile("../../migrations/domain/init.sql")
+	require.NoError(t, err)
+	table := regexp.MustCompile(`(?s)CREATE TABLE user \((.*?)\);`).FindSubmatch(schema)
+	require.NotNil(t, table)
+	for _, line := range strings.Split(string(table[1]), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			columns[fields[0]] = true
+		}
+	}
+
+	migration, err := ioutil.ReadFile("../../migrations/domain/13-user-password.sql")
+	require.NoError(t, err)
+	for _, column := range regexp.MustCompile("ALTER TABLE `user`\\s+ADD COLUMN `(\\w+)`").FindAllSubmatch(migration, -1) {
+		columns[string(column[1])] = true
+	}
+
+	return columns
+}
+
+func TestQuerySelectUser(t *testing.T) {
+	columns := userColumns(t)
+
+	selected := strings.Fields(strings.NewReplacer(",", " ").Replace(
+		regexp.MustCompile(`(?s)SELECT(.*)FROM`).FindStringSubmatch(querySelectUser)[1]))
+	assert.ElementsMatch(t, []string{"userId", "username", "password", "userType"}, selected)
+
+	// the password grant resolves users by username or email
+	for _, column := range append(selected, "email", "deletedAt") {
+		assert.True(t, columns[column], "user has no column %s", column)
+	}
+}
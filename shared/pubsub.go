@@ -1,37 +1,56 @@
 package shared
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrUnknownTopic is returned when publishing to a topic without subscriber.
+	ErrUnknownTopic = errors.New("unknown topic")
+	// ErrPubSubStopped is returned when publishing after the PubSub stopped.
+	ErrPubSubStopped = errors.New("pubsub is stopped")
 )
 
 type message struct {
-	topic   string
+	runner  *TopicRunner
 	payload []byte
-}
-
-type Consumer struct {
-	message     chan message
-	messagePool chan chan message
-	runner      map[string]TopicRunner
+	job     *Job
 }
 
 type Process func(message []byte) error
 
+// ErrorHandler receives the messages whose retries are exhausted.
+type ErrorHandler func(topic string, payload []byte, err error)
+
 type consumerConfig struct {
 	MaxRetry           int
+	BaseDelayRetry     time.Duration
 	MaxDelayRetry      time.Duration
 	AsynchronousThread bool
 }
 
+// SetMaxRetry sets the number of attempts at processing a message.
 func SetMaxRetry(maxRetry int) func(*consumerConfig) {
 	return func(cc *consumerConfig) {
 		cc.MaxRetry = maxRetry
 	}
 }
 
-func SetMaxDelayRetry(maxDelay time.Duration) func(*consumerConfig) {
+// SetRetryBackoff sets the delay between attempts, which doubles after each
+// attempt from base up to max and is jittered so that failing messages do not
+// retry in lockstep.
+func SetRetryBackoff(base time.Duration, max time.Duration) func(*consumerConfig) {
 	return func(cc *consumerConfig) {
-		cc.MaxDelayRetry = maxDelay
+		cc.BaseDelayRetry = base
+		cc.MaxDelayRetry = max
 	}
 }
 
@@ -45,59 +64,58 @@ func SetAsynchronousThread(sync bool) func(*consumerConfig) {
 func defaultConsumerConfig() consumerConfig {
 	return consumerConfig{
 		MaxRetry:           0,
+		BaseDelayRetry:     0 * time.Second,
 		MaxDelayRetry:      0 * time.Second,
 		AsynchronousThread: false,
 	}
 }
 
-func consumer(messagePol chan chan message, subscribers map[string]TopicRunner) Consumer {
-	return Consumer{
-		message:     make(chan message),
-		messagePool: messagePol,
-		runner:      subscribers,
-	}
-}
-
-func (c Consumer) consume() {
-	go func() {
-		for {
-			// starts out empty
-			// send the response to dispatcher
-			c.messagePool <- c.message
-
-			// read the response
-			msg := <-c.message
-
-			runner := c.runner[msg.topic]
-			if runner.consumerConfig.AsynchronousThread {
-				go runner.backoff(func() error {
-					return runner.Process(msg.payload)
-				})
-				continue
-			}
-
-			// if enabled process concurrent will handle by max flight
-			runner.backoff(func() error {
-				return runner.Process(msg.payload)
-			})
-		}
-	}()
+// TopicStats counts the messages of a topic.
+type TopicStats struct {
+	// Processed is the number of messages processed successfully.
+	Processed uint64
+	// Failed is the number of messages whose retries are exhausted.
+	Failed uint64
+	// Retried is the number of retried attempts.
+	Retried uint64
 }
 
 type PubSub struct {
-	message     chan message
-	messagePool chan chan message
-	max         int
-	topics      map[string]TopicRunner
+	message      chan message
+	max          int
+	errorHandler ErrorHandler
+	store        JobStore
+	pollInterval time.Duration
+	lease        time.Duration
+	stopPolling  chan struct{}
+
+	mu      sync.RWMutex
+	topics  map[string]*TopicRunner
+	started bool
+	stopped bool
+
+	inFlight  sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+	done      chan struct{}
 }
 
 type pubsubConfig struct {
 	MessageBuffer int
+	ErrorHandler  ErrorHandler
+	JobStore      JobStore
+	PollInterval  time.Duration
+	JobLease      time.Duration
 }
 
 func defaultPubsubConfig() pubsubConfig {
 	return pubsubConfig{
 		MessageBuffer: 0,
+		ErrorHandler: func(topic string, payload []byte, err error) {
+			log.Error().Err(err).Str("topic", topic).Msg("failed processing message")
+		},
+		PollInterval: time.Second,
+		JobLease:     5 * time.Minute,
 	}
 }
 
@@ -107,12 +125,25 @@ func SetMessageBuffer(maxBuffer int) func(*pubsubConfig) {
 	}
 }
 
+// SetErrorHandler sets the handler of the messages whose retries are
+// exhausted. They are logged by default.
+func SetErrorHandler(handler ErrorHandler) func(*pubsubConfig) {
+	return func(pc *pubsubConfig) {
+		pc.ErrorHandler = handler
+	}
+}
+
 type TopicRunner struct {
 	Process        Process
+	topic          string
 	consumerConfig consumerConfig
+
+	processed uint64
+	failed    uint64
+	retried   uint64
 }
 
-func (r TopicRunner) backoff(exec func() error) error {
+func (r *TopicRunner) backoff(exec func() error) error {
 	var err error
 
 	if r.consumerConfig.MaxRetry == 0 {
@@ -127,65 +158,230 @@ func (r TopicRunner) backoff(exec func() error) error {
 		}
 
 		counter++
-		time.Sleep(r.consumerConfig.MaxDelayRetry)
+		if counter < r.consumerConfig.MaxRetry {
+			atomic.AddUint64(&r.retried, 1)
+			time.Sleep(r.delay(counter))
+		}
 	}
 
 	return err
 }
 
+// delay is the jittered exponential delay before the given retry.
+func (r *TopicRunner) delay(retry int) time.Duration {
+	delay, max := r.consumerConfig.BaseDelayRetry, r.consumerConfig.MaxDelayRetry
+	for i := 1; i < retry; i++ {
+		delay *= 2
+		if max > 0 && delay >= max {
+			delay = max
+			break
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// wait at least half of the delay
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (r *TopicRunner) run(payload []byte, errorHandler ErrorHandler) {
+	err := r.backoff(func() error {
+		return r.Process(payload)
+	})
+	if err != nil {
+		atomic.AddUint64(&r.failed, 1)
+		errorHandler(r.topic, payload, err)
+		return
+	}
+
+	atomic.AddUint64(&r.processed, 1)
+}
+
+func (r *TopicRunner) stats() TopicStats {
+	return TopicStats{
+		Processed: atomic.LoadUint64(&r.processed),
+		Failed:    atomic.LoadUint64(&r.failed),
+		Retried:   atomic.LoadUint64(&r.retried),
+	}
+}
+
 // max is a total process could be handle
-func New(maxFlight int, opts ...func(*pubsubConfig)) PubSub {
+func New(maxFlight int, opts ...func(*pubsubConfig)) *PubSub {
 	config := defaultPubsubConfig()
 	for _, opt := range opts {
 		opt(&config)
 	}
 
-	return PubSub{
-		message:     make(chan message, config.MessageBuffer),
-		messagePool: make(chan chan message),
-		max:         maxFlight,
-		topics:      make(map[string]TopicRunner),
+	return &PubSub{
+		message:      make(chan message, config.MessageBuffer),
+		max:          maxFlight,
+		errorHandler: config.ErrorHandler,
+		store:        config.JobStore,
+		pollInterval: config.PollInterval,
+		lease:        config.JobLease,
+		stopPolling:  make(chan struct{}),
+		topics:       make(map[string]*TopicRunner),
+		done:         make(chan struct{}),
 	}
 }
 
-func (p PubSub) Publish(topic string, payload []byte) {
+// Publish queues a message for the subscriber of topic. It blocks while the
+// message buffer is full.
+func (p *PubSub) Publish(topic string, payload []byte) error {
+	return p.PublishWithOptions(topic, payload)
+}
+
+// PublishWithOptions queues a message for the subscriber of topic, delivered
+// according to the options. Delayed and unique messages require a JobStore.
+func (p *PubSub) PublishWithOptions(topic string, payload []byte, opts ...func(*publishConfig)) error {
+	cfg := publishConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return ErrPubSubStopped
+	}
+
+	runner, ok := p.topics[topic]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTopic, topic)
+	}
+
+	if p.store != nil {
+		return p.enqueue(topic, payload, cfg)
+	}
+	if !cfg.RunAt.IsZero() || cfg.UniqueKey != "" {
+		return ErrJobStoreRequired
+	}
+
 	p.message <- message{
-		topic:   topic,
+		runner:  runner,
 		payload: payload,
 	}
+	return nil
 }
 
-func (p PubSub) SubscriberRegistry(topicListener string, pr Process, opts ...func(*consumerConfig)) {
+func (p *PubSub) SubscriberRegistry(topicListener string, pr Process, opts ...func(*consumerConfig)) {
 	cfg := defaultConsumerConfig()
 
 	for _, opt := range opts {
 		opt(&cfg)
 	}
 
-	p.topics[topicListener] = TopicRunner{
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.topics[topicListener] = &TopicRunner{
 		Process:        pr,
+		topic:          topicListener,
 		consumerConfig: cfg,
 	}
 }
 
-func (p PubSub) Start() {
-	for i := 0; i < p.max; i++ {
-		consumer := consumer(p.messagePool, p.topics)
-		consumer.consume()
+// Stats returns the counters of each topic.
+func (p *PubSub) Stats() map[string]TopicStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make(map[string]TopicStats, len(p.topics))
+	for topic, runner := range p.topics {
+		stats[topic] = runner.stats()
 	}
+	return stats
+}
 
-	go p.dispatch()
+// Start starts processing messages. The PubSub stops once ctx is done, like
+// it does on Stop.
+func (p *PubSub) Start(ctx context.Context) {
+	p.startOnce.Do(func() {
+		p.mu.Lock()
+		p.started = true
+		p.mu.Unlock()
+
+		var workers sync.WaitGroup
+		for i := 0; i < p.max; i++ {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				p.consume()
+			}()
+		}
+
+		if p.store != nil {
+			go p.poll()
+		}
+
+		go func() {
+			workers.Wait()
+			p.inFlight.Wait()
+			close(p.done)
+		}()
+
+		go func() {
+			select {
+			case <-ctx.Done():
+				p.close()
+			case <-p.done:
+			}
+		}()
+	})
+}
+
+// Stop stops accepting messages and waits until the queued and in-flight
+// messages are processed, retries included.
+func (p *PubSub) Stop() {
+	p.close()
+
+	p.mu.RLock()
+	started := p.started
+	p.mu.RUnlock()
+	if started {
+		<-p.done
+	}
+}
+
+func (p *PubSub) close() {
+	p.stopOnce.Do(func() {
+		// waits for blocked publishers to hand over their message
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.stopped = true
+		if p.store != nil {
+			// the poller owns the message channel
+			close(p.stopPolling)
+			return
+		}
+		close(p.message)
+	})
 }
 
-func (p PubSub) dispatch() {
-	for {
-		// waiting from p.Message from instantiate
-		msg := <-p.message
+func (p *PubSub) consume() {
+	for msg := range p.message {
+		if msg.runner.consumerConfig.AsynchronousThread {
+			p.inFlight.Add(1)
+			go func(msg message) {
+				defer p.inFlight.Done()
+				p.run(msg)
+			}(msg)
+			continue
+		}
 
-		// read the response from consume
-		response := <-p.messagePool
+		// if enabled process concurrent will handle by max flight
+		p.run(msg)
+	}
+}
 
-		// send value from function Publish to response / consumer
-		response <- msg
+func (p *PubSub) run(msg message) {
+	if msg.job != nil {
+		msg.runner.runJob(p.store, *msg.job, p.errorHandler)
+		return
 	}
+
+	msg.runner.run(msg.payload, p.errorHandler)
 }
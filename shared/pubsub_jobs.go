@@ -0,0 +1,208 @@
+package shared
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrDuplicateJob is returned when publishing a message whose unique key
+	// is taken by a job that is not done yet.
+	ErrDuplicateJob = errors.New("duplicate job")
+	// ErrJobStoreRequired is returned when publishing a delayed or unique
+	// message to a PubSub without JobStore.
+	ErrJobStoreRequired = errors.New("delayed and unique messages require a job store")
+)
+
+// Job is a message stored by a durable PubSub until it is processed.
+type Job struct {
+	ID        string    `db:"id"`
+	Topic     string    `db:"topic"`
+	Payload   []byte    `db:"payload"`
+	UniqueKey *string   `db:"unique_key"`
+	Attempts  int       `db:"attempts"`
+	RunAt     time.Time `db:"run_at"`
+}
+
+// JobStore stores the messages of a durable PubSub, so that they survive
+// restarts.
+type JobStore interface {
+	// Enqueue stores a job. It fails with ErrDuplicateJob when the unique key
+	// of the job is taken by another job of its topic that is not done yet.
+	Enqueue(job Job) error
+	// Claim leases up to limit due jobs of the topics, counting an attempt
+	// for each. Jobs whose lease expires are claimed again.
+	Claim(topics []string, limit int, lease time.Duration) ([]Job, error)
+	// Complete removes a processed job.
+	Complete(job Job) error
+	// Retry releases a failed job to be claimed again at runAt.
+	Retry(job Job, runAt time.Time, reason error) error
+	// Fail keeps a job whose retries are exhausted aside, freeing its unique
+	// key.
+	Fail(job Job, reason error) error
+}
+
+// SetJobStore makes the PubSub durable: messages are stored in store until
+// processed and claimed from it by the workers, so that they survive restarts.
+func SetJobStore(store JobStore) func(*pubsubConfig) {
+	return func(pc *pubsubConfig) {
+		pc.JobStore = store
+	}
+}
+
+// SetPollInterval sets how often a durable PubSub claims due jobs.
+func SetPollInterval(interval time.Duration) func(*pubsubConfig) {
+	return func(pc *pubsubConfig) {
+		pc.PollInterval = interval
+	}
+}
+
+// SetJobLease sets how long a claimed job may take before it is claimed
+// again, as its worker is presumed dead.
+func SetJobLease(lease time.Duration) func(*pubsubConfig) {
+	return func(pc *pubsubConfig) {
+		pc.JobLease = lease
+	}
+}
+
+type publishConfig struct {
+	RunAt     time.Time
+	UniqueKey string
+}
+
+// SetDeliverAt delivers a message at the given time.
+func SetDeliverAt(at time.Time) func(*publishConfig) {
+	return func(pc *publishConfig) {
+		pc.RunAt = at
+	}
+}
+
+// SetDeliverAfter delivers a message after the given delay.
+func SetDeliverAfter(delay time.Duration) func(*publishConfig) {
+	return func(pc *publishConfig) {
+		pc.RunAt = time.Now().Add(delay)
+	}
+}
+
+// SetUniqueKey rejects the message with ErrDuplicateJob while another message
+// of the topic with the same key is not done yet.
+func SetUniqueKey(key string) func(*publishConfig) {
+	return func(pc *publishConfig) {
+		pc.UniqueKey = key
+	}
+}
+
+func (p *PubSub) enqueue(topic string, payload []byte, cfg publishConfig) error {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	job := Job{
+		ID:      id.String(),
+		Topic:   topic,
+		Payload: payload,
+		RunAt:   cfg.RunAt,
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if cfg.UniqueKey != "" {
+		job.UniqueKey = &cfg.UniqueKey
+	}
+
+	return p.store.Enqueue(job)
+}
+
+// poll claims due jobs for the workers until the PubSub stops.
+func (p *PubSub) poll() {
+	defer close(p.message)
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// keep claiming while there is a backlog
+		for p.claim() {
+		}
+
+		select {
+		case <-p.stopPolling:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim hands a batch of due jobs to the workers, and reports whether the
+// batch was full.
+func (p *PubSub) claim() bool {
+	select {
+	case <-p.stopPolling:
+		return false
+	default:
+	}
+
+	p.mu.RLock()
+	runners := make(map[string]*TopicRunner, len(p.topics))
+	topics := make([]string, 0, len(p.topics))
+	for topic, runner := range p.topics {
+		runners[topic] = runner
+		topics = append(topics, topic)
+	}
+	p.mu.RUnlock()
+
+	if len(topics) == 0 {
+		return false
+	}
+
+	limit := p.max
+	if limit < 1 {
+		limit = 1
+	}
+	jobs, err := p.store.Claim(topics, limit, p.lease)
+	if err != nil {
+		log.Error().Err(err).Msg("failed claiming jobs")
+		return false
+	}
+
+	for i := range jobs {
+		p.message <- message{
+			runner:  runners[jobs[i].Topic],
+			payload: jobs[i].Payload,
+			job:     &jobs[i],
+		}
+	}
+
+	return len(jobs) == limit
+}
+
+func (r *TopicRunner) runJob(store JobStore, job Job, errorHandler ErrorHandler) {
+	err := r.Process(job.Payload)
+	if err == nil {
+		atomic.AddUint64(&r.processed, 1)
+		if storeErr := store.Complete(job); storeErr != nil {
+			// the job is processed again once its lease expires
+			log.Error().Err(storeErr).Str("topic", r.topic).Str("jobId", job.ID).Msg("failed completing job")
+		}
+		return
+	}
+
+	if job.Attempts < r.consumerConfig.MaxRetry {
+		atomic.AddUint64(&r.retried, 1)
+		if storeErr := store.Retry(job, time.Now().Add(r.delay(job.Attempts)), err); storeErr != nil {
+			log.Error().Err(storeErr).Str("topic", r.topic).Str("jobId", job.ID).Msg("failed retrying job")
+		}
+		return
+	}
+
+	atomic.AddUint64(&r.failed, 1)
+	if storeErr := store.Fail(job, err); storeErr != nil {
+		log.Error().Err(storeErr).Str("topic", r.topic).Str("jobId", job.ID).Msg("failed failing job")
+	}
+	errorHandler(r.topic, job.Payload, err)
+}
@@ -0,0 +1,146 @@
+package shared
+
+import (
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	mysqlErrorDuplicateEntry = 1062
+
+	queryInsertJob = `
+		INSERT INTO jobs (
+			id,
+			topic,
+			payload,
+			unique_key,
+			status,
+			attempts,
+			run_at
+		) VALUES (?, ?, ?, ?, 'pending', 0, ?)`
+
+	// running jobs whose lease expired belong to a dead worker
+	querySelectDueJobs = `
+		SELECT
+			id,
+			topic,
+			payload,
+			unique_key,
+			attempts,
+			run_at
+		FROM jobs
+		WHERE topic IN (?) AND (
+			(status = 'pending' AND run_at <= ?) OR
+			(status = 'running' AND locked_until < ?))
+		ORDER BY run_at
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED`
+
+	queryClaimJobs = `
+		UPDATE jobs
+		SET
+			status = 'running',
+			attempts = attempts + 1,
+			locked_until = ?
+		WHERE id IN (?)`
+
+	queryRetryJob = `
+		UPDATE jobs
+		SET
+			status = 'pending',
+			last_error = ?,
+			run_at = ?,
+			locked_until = NULL
+		WHERE id = ?`
+
+	queryFailJob = `
+		UPDATE jobs
+		SET
+			status = 'failed',
+			unique_key = NULL,
+			last_error = ?,
+			locked_until = NULL
+		WHERE id = ?`
+
+	queryDeleteJob = "DELETE FROM jobs WHERE id = ?"
+)
+
+// JobStoreMySQL is the MySQL-backed implementation of JobStore.
+type JobStoreMySQL struct {
+	db *infras.MySQLConn
+}
+
+// NewJobStoreMySQL creates a new JobStoreMySQL.
+func NewJobStoreMySQL(db *infras.MySQLConn) *JobStoreMySQL {
+	return &JobStoreMySQL{db: db}
+}
+
+// Enqueue stores a job, relying on the unique key of its topic to reject
+// duplicates.
+func (s *JobStoreMySQL) Enqueue(job Job) error {
+	_, err := s.db.Write.Exec(queryInsertJob, job.ID, job.Topic, job.Payload, job.UniqueKey, job.RunAt)
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == mysqlErrorDuplicateEntry {
+		return ErrDuplicateJob
+	}
+	return err
+}
+
+// Claim locks due jobs, skipping those locked by other workers, and leases
+// them.
+func (s *JobStoreMySQL) Claim(topics []string, limit int, lease time.Duration) (jobs []Job, err error) {
+	err = s.db.WithTransaction(func(tx *sqlx.Tx, e chan error) {
+		now := time.Now()
+		query, args, err := sqlx.In(querySelectDueJobs, topics, now, now, limit)
+		if err != nil {
+			e <- err
+			return
+		}
+
+		err = tx.Select(&jobs, query, args...)
+		if err != nil || len(jobs) == 0 {
+			e <- err
+			return
+		}
+
+		ids := make([]string, 0, len(jobs))
+		for i := range jobs {
+			ids = append(ids, jobs[i].ID)
+			jobs[i].Attempts++
+		}
+
+		query, args, err = sqlx.In(queryClaimJobs, now.Add(lease), ids)
+		if err != nil {
+			e <- err
+			return
+		}
+
+		_, err = tx.Exec(query, args...)
+		e <- err
+	})
+	if err != nil {
+		jobs = nil
+	}
+
+	return
+}
+
+// Complete deletes a processed job, freeing its unique key.
+func (s *JobStoreMySQL) Complete(job Job) error {
+	_, err := s.db.Write.Exec(queryDeleteJob, job.ID)
+	return err
+}
+
+// Retry releases a failed job to be claimed again at runAt.
+func (s *JobStoreMySQL) Retry(job Job, runAt time.Time, reason error) error {
+	_, err := s.db.Write.Exec(queryRetryJob, reason.Error(), runAt, job.ID)
+	return err
+}
+
+// Fail keeps a job whose retries are exhausted for inspection.
+func (s *JobStoreMySQL) Fail(job Job, reason error) error {
+	_, err := s.db.Write.Exec(queryFailJob, reason.Error(), job.ID)
+	return err
+}
@@ -0,0 +1,260 @@
+package shared_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeJob struct {
+	shared.Job
+	status      string
+	lockedUntil time.Time
+}
+
+// fakeJobStore is an in-memory JobStore following the semantics of the MySQL
+// one.
+type fakeJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*fakeJob
+}
+
+func newFakeJobStore() *fakeJobStore {
+	return &fakeJobStore{jobs: make(map[string]*fakeJob)}
+}
+
+func (s *fakeJobStore) Enqueue(job shared.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.jobs {
+		if job.UniqueKey != nil && existing.UniqueKey != nil && existing.Topic == job.Topic && *existing.UniqueKey == *job.UniqueKey {
+			return shared.ErrDuplicateJob
+		}
+	}
+	s.jobs[job.ID] = &fakeJob{Job: job, status: "pending"}
+	return nil
+}
+
+func (s *fakeJobStore) Claim(topics []string, limit int, lease time.Duration) ([]shared.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []*fakeJob
+	for _, job := range s.jobs {
+		for _, topic := range topics {
+			if job.Topic == topic &&
+				(job.status == "pending" && !job.RunAt.After(now) || job.status == "running" && job.lockedUntil.Before(now)) {
+				due = append(due, job)
+			}
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+
+	var jobs []shared.Job
+	for _, job := range due {
+		if len(jobs) == limit {
+			break
+		}
+		job.status = "running"
+		job.Attempts++
+		job.lockedUntil = now.Add(lease)
+		jobs = append(jobs, job.Job)
+	}
+	return jobs, nil
+}
+
+func (s *fakeJobStore) Complete(job shared.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.jobs, job.ID)
+	return nil
+}
+
+func (s *fakeJobStore) Retry(job shared.Job, runAt time.Time, reason error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID].status = "pending"
+	s.jobs[job.ID].RunAt = runAt
+	return nil
+}
+
+func (s *fakeJobStore) Fail(job shared.Job, reason error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID].status = "failed"
+	s.jobs[job.ID].UniqueKey = nil
+	return nil
+}
+
+func (s *fakeJobStore) statuses() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make(map[string]int)
+	for _, job := range s.jobs {
+		statuses[job.status]++
+	}
+	return statuses
+}
+
+// recorder collects processed payloads.
+type recorder struct {
+	mu       sync.Mutex
+	payloads []string
+	received chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{received: make(chan struct{}, 100)}
+}
+
+func (r *recorder) process(message []byte) error {
+	r.mu.Lock()
+	r.payloads = append(r.payloads, string(message))
+	r.mu.Unlock()
+	r.received <- struct{}{}
+	return nil
+}
+
+func (r *recorder) wait(t *testing.T, count int) {
+	for i := 0; i < count; i++ {
+		select {
+		case <-r.received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d of %d messages", i, count)
+		}
+	}
+}
+
+func newDurablePubSub(store shared.JobStore) *shared.PubSub {
+	return shared.New(2, shared.SetJobStore(store), shared.SetPollInterval(time.Millisecond), shared.SetJobLease(time.Minute))
+}
+
+func TestDurablePubSub(t *testing.T) {
+	t.Run("Publish", func(t *testing.T) {
+		store := newFakeJobStore()
+		received := newRecorder()
+		pubsub := newDurablePubSub(store)
+		pubsub.SubscriberRegistry("test", received.process)
+		pubsub.Start(context.Background())
+
+		require.NoError(t, pubsub.Publish("test", []byte("a")))
+		require.NoError(t, pubsub.Publish("test", []byte("b")))
+		received.wait(t, 2)
+		pubsub.Stop()
+
+		assert.ElementsMatch(t, []string{"a", "b"}, received.payloads)
+		assert.Empty(t, store.statuses())
+		assert.Equal(t, shared.TopicStats{Processed: 2}, pubsub.Stats()["test"])
+	})
+
+	t.Run("Unknown Topic", func(t *testing.T) {
+		pubsub := newDurablePubSub(newFakeJobStore())
+
+		err := pubsub.Publish("unknown", []byte("a"))
+		assert.True(t, errors.Is(err, shared.ErrUnknownTopic))
+	})
+
+	t.Run("Delayed Delivery", func(t *testing.T) {
+		received := newRecorder()
+		pubsub := newDurablePubSub(newFakeJobStore())
+		pubsub.SubscriberRegistry("test", received.process)
+		pubsub.Start(context.Background())
+		defer pubsub.Stop()
+
+		published := time.Now()
+		require.NoError(t, pubsub.PublishWithOptions("test", []byte("later"), shared.SetDeliverAfter(50*time.Millisecond)))
+		require.NoError(t, pubsub.PublishWithOptions("test", []byte("now"), shared.SetDeliverAt(published)))
+		received.wait(t, 2)
+
+		assert.Equal(t, []string{"now", "later"}, received.payloads)
+		assert.True(t, time.Since(published) >= 50*time.Millisecond)
+	})
+
+	t.Run("Unique Key", func(t *testing.T) {
+		store := newFakeJobStore()
+		pubsub := newDurablePubSub(store)
+		pubsub.SubscriberRegistry("test", func(message []byte) error { return nil })
+		pubsub.SubscriberRegistry("test-2", func(message []byte) error { return nil })
+
+		require.NoError(t, pubsub.PublishWithOptions("test", []byte("a"), shared.SetUniqueKey("product-1")))
+		assert.Equal(t, shared.ErrDuplicateJob, pubsub.PublishWithOptions("test", []byte("b"), shared.SetUniqueKey("product-1")))
+		assert.NoError(t, pubsub.PublishWithOptions("test-2", []byte("c"), shared.SetUniqueKey("product-1")))
+	})
+
+	t.Run("Retries In The Store", func(t *testing.T) {
+		store := newFakeJobStore()
+		var mu sync.Mutex
+		var failed []string
+		attempts := 0
+		pubsub := shared.New(1,
+			shared.SetJobStore(store),
+			shared.SetPollInterval(time.Millisecond),
+			shared.SetErrorHandler(func(topic string, payload []byte, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				failed = append(failed, string(payload))
+			}))
+		pubsub.SubscriberRegistry("test", func(message []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts++
+			return errors.New("error test retry")
+		}, shared.SetMaxRetry(3), shared.SetRetryBackoff(time.Millisecond, 2*time.Millisecond))
+		pubsub.Start(context.Background())
+
+		require.NoError(t, pubsub.PublishWithOptions("test", []byte("a"), shared.SetUniqueKey("a")))
+		require.Eventually(t, func() bool { return pubsub.Stats()["test"].Failed == 1 }, 5*time.Second, time.Millisecond)
+		pubsub.Stop()
+
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, []string{"a"}, failed)
+		assert.Equal(t, shared.TopicStats{Failed: 1, Retried: 2}, pubsub.Stats()["test"])
+		assert.Equal(t, map[string]int{"failed": 1}, store.statuses())
+		// the unique key is free again
+		key := "a"
+		assert.NoError(t, store.Enqueue(shared.Job{ID: "b", Topic: "test", UniqueKey: &key}))
+	})
+
+	t.Run("Reclaims Jobs Of Dead Workers", func(t *testing.T) {
+		store := newFakeJobStore()
+		crashed := newDurablePubSub(store)
+		crashed.SubscriberRegistry("test", func(message []byte) error { return nil })
+		require.NoError(t, crashed.Publish("test", []byte("a")))
+
+		// a worker claimed the job, then died without completing it
+		jobs, err := store.Claim([]string{"test"}, 1, time.Millisecond)
+		require.NoError(t, err)
+		require.Len(t, jobs, 1)
+
+		received := newRecorder()
+		restarted := newDurablePubSub(store)
+		restarted.SubscriberRegistry("test", received.process)
+		restarted.Start(context.Background())
+		received.wait(t, 1)
+		restarted.Stop()
+
+		assert.Equal(t, []string{"a"}, received.payloads)
+		assert.Empty(t, store.statuses())
+	})
+
+	t.Run("Delayed Messages Require A Job Store", func(t *testing.T) {
+		pubsub := shared.New(1)
+		pubsub.SubscriberRegistry("test", func(message []byte) error { return nil })
+
+		err := pubsub.PublishWithOptions("test", []byte("a"), shared.SetDeliverAfter(time.Second))
+		assert.Equal(t, shared.ErrJobStoreRequired, err)
+	})
+}
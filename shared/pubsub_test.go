@@ -1,7 +1,9 @@
 package shared_test
 
 import (
+	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,7 +20,7 @@ func TestPubSub(t *testing.T) {
 			actual = string(message)
 			return nil
 		})
-		pubsub.Start()
+		pubsub.Start(context.Background())
 		pubsub.Publish("test", []byte("Testing"))
 
 		time.Sleep(1 * time.Second)
@@ -33,7 +35,7 @@ func TestPubSub(t *testing.T) {
 			actual = string(message)
 			return nil
 		})
-		pubsub.Start()
+		pubsub.Start(context.Background())
 		pubsub.Publish("test", []byte("b"))
 
 		time.Sleep(1 * time.Second)
@@ -55,7 +57,7 @@ func TestPubSub(t *testing.T) {
 			return errors.New("error test retry")
 		}, shared.SetMaxRetry(2))
 
-		pubsub.Start()
+		pubsub.Start(context.Background())
 		pubsub.Publish("test", []byte("b"))
 		pubsub.Publish("test-2", []byte("b"))
 
@@ -71,7 +73,7 @@ func TestPubSub(t *testing.T) {
 			counter++
 			return nil
 		}, shared.SetAsynchronousThread(true))
-		pubsub.Start()
+		pubsub.Start(context.Background())
 
 		for i := 0; i < 1000; i++ {
 			pubsub.Publish("test", []byte("test"))
@@ -80,4 +82,70 @@ func TestPubSub(t *testing.T) {
 		time.Sleep(3 * time.Second)
 		assert.Equal(t, 1000, counter)
 	})
+
+	t.Run("Unknown Topic", func(t *testing.T) {
+		pubsub := shared.New(1)
+		pubsub.Start(context.Background())
+		defer pubsub.Stop()
+
+		err := pubsub.Publish("unknown", []byte("test"))
+		assert.True(t, errors.Is(err, shared.ErrUnknownTopic))
+	})
+
+	t.Run("Stop Drains Messages", func(t *testing.T) {
+		var mu sync.Mutex
+		processed := 0
+		pubsub := shared.New(2, shared.SetMessageBuffer(100))
+		pubsub.SubscriberRegistry("test", func(message []byte) error {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			processed++
+			mu.Unlock()
+			return nil
+		}, shared.SetAsynchronousThread(true))
+		pubsub.Start(context.Background())
+
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, pubsub.Publish("test", []byte("test")))
+		}
+		pubsub.Stop()
+
+		assert.Equal(t, 100, processed)
+		assert.Equal(t, shared.ErrPubSubStopped, pubsub.Publish("test", []byte("test")))
+	})
+
+	t.Run("Stop On Context", func(t *testing.T) {
+		pubsub := shared.New(1)
+		pubsub.SubscriberRegistry("test", func(message []byte) error { return nil })
+		ctx, cancel := context.WithCancel(context.Background())
+		pubsub.Start(ctx)
+
+		cancel()
+		pubsub.Stop()
+		assert.Equal(t, shared.ErrPubSubStopped, pubsub.Publish("test", []byte("test")))
+	})
+
+	t.Run("Exhausted Retries", func(t *testing.T) {
+		var failed []string
+		pubsub := shared.New(1, shared.SetErrorHandler(func(topic string, payload []byte, err error) {
+			failed = append(failed, topic+":"+string(payload)+":"+err.Error())
+		}))
+		pubsub.SubscriberRegistry("test", func(message []byte) error {
+			return errors.New("error test retry")
+		}, shared.SetMaxRetry(3), shared.SetRetryBackoff(time.Millisecond, 2*time.Millisecond))
+		pubsub.SubscriberRegistry("test-2", func(message []byte) error {
+			return nil
+		})
+		pubsub.Start(context.Background())
+
+		assert.NoError(t, pubsub.Publish("test", []byte("a")))
+		assert.NoError(t, pubsub.Publish("test-2", []byte("b")))
+		pubsub.Stop()
+
+		assert.Equal(t, []string{"test:a:error test retry"}, failed)
+		assert.Equal(t, map[string]shared.TopicStats{
+			"test":   {Failed: 1, Retried: 2},
+			"test-2": {Processed: 1},
+		}, pubsub.Stats())
+	})
 }
@@ -1,11 +1,13 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -13,6 +15,7 @@ import (
 	"github.com/evermos/boilerplate-go/docs"
 	"github.com/evermos/boilerplate-go/infras"
 	"github.com/evermos/boilerplate-go/shared/logger"
+	appMiddleware "github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 	"github.com/evermos/boilerplate-go/transport/http/router"
 	"github.com/go-chi/chi"
@@ -37,24 +40,38 @@ const (
 	ServerStateInCleanupPeriod
 )
 
+// ShutdownHook stops a background worker when the server shuts down. It must
+// return once the worker is drained, or as soon as ctx is done.
+type ShutdownHook func(ctx context.Context)
+
 // HTTP is the HTTP server.
 type HTTP struct {
-	Config *configs.Config
-	DB     *infras.MySQLConn
-	Router router.Router
-	State  ServerState
-	mux    *chi.Mux
+	Config        *configs.Config
+	DB            *infras.MySQLConn
+	Router        router.Router
+	RealIP        *appMiddleware.RealIP
+	State         ServerState
+	mux           *chi.Mux
+	shutdownHooks []ShutdownHook
 }
 
 // ProvideHTTP is the provider for HTTP.
-func ProvideHTTP(db *infras.MySQLConn, config *configs.Config, router router.Router) *HTTP {
+func ProvideHTTP(db *infras.MySQLConn, config *configs.Config, router router.Router, realIP *appMiddleware.RealIP) *HTTP {
 	return &HTTP{
 		DB:     db,
 		Config: config,
 		Router: router,
+		RealIP: realIP,
 	}
 }
 
+// OnShutdown registers a hook to be run as soon as the server receives
+// SIGTERM. Hooks run concurrently and may take up to the grace and cleanup
+// periods combined.
+func (h *HTTP) OnShutdown(hook ShutdownHook) {
+	h.shutdownHooks = append(h.shutdownHooks, hook)
+}
+
 // SetupAndServe sets up the server and gets it up and running.
 func (h *HTTP) SetupAndServe() {
 	h.mux = chi.NewRouter()
@@ -102,6 +119,12 @@ func (h *HTTP) respondToSigterm(done chan os.Signal) {
 	shutdownConfig := h.Config.Server.Shutdown
 
 	log.Info().Msg("Received SIGTERM.")
+
+	shutdownPeriod := time.Duration(shutdownConfig.GracePeriodSeconds+shutdownConfig.CleanupPeriodSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownPeriod)
+	defer cancel()
+	drained := h.runShutdownHooks(ctx)
+
 	log.Info().Int64("seconds", shutdownConfig.GracePeriodSeconds).Msg("Entering grace period.")
 	h.State = ServerStateInGracePeriod
 	time.Sleep(time.Duration(shutdownConfig.GracePeriodSeconds) * time.Second)
@@ -110,10 +133,36 @@ func (h *HTTP) respondToSigterm(done chan os.Signal) {
 	h.State = ServerStateInCleanupPeriod
 	time.Sleep(time.Duration(shutdownConfig.CleanupPeriodSeconds) * time.Second)
 
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		log.Warn().Msg("Background workers did not drain within the shutdown period.")
+	}
+
 	log.Info().Msg("Cleaning up completed. Shutting down now.")
 }
 
+func (h *HTTP) runShutdownHooks(ctx context.Context) <-chan struct{} {
+	var wg sync.WaitGroup
+	for _, hook := range h.shutdownHooks {
+		wg.Add(1)
+		go func(hook ShutdownHook) {
+			defer wg.Done()
+			hook(ctx)
+		}(hook)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	return drained
+}
+
 func (h *HTTP) setupMiddleware() {
+	// clients are logged and rate limited by their forwarded address
+	h.mux.Use(h.RealIP.Middleware)
 	h.mux.Use(middleware.Logger)
 	h.mux.Use(middleware.Recoverer)
 	h.mux.Use(h.serverStateMiddleware)
@@ -133,6 +182,7 @@ func (h *HTTP) logCORSConfigInfo() {
 		log.Info().Str(corsHeaderInfo, fmt.Sprintf("Access-Control-Allow-Headers: %s", strings.Join(corsConfig.AllowedHeaders, ", "))).Msg("")
 		log.Info().Str(corsHeaderInfo, fmt.Sprintf("Access-Control-Allow-Methods: %s", strings.Join(corsConfig.AllowedMethods, ", "))).Msg("")
 		log.Info().Str(corsHeaderInfo, fmt.Sprintf("Access-Control-Allow-Origin: %s", strings.Join(corsConfig.AllowedOrigins, ", "))).Msg("")
+		log.Info().Str(corsHeaderInfo, fmt.Sprintf("Access-Control-Expose-Headers: %s", strings.Join(corsConfig.ExposedHeaders, ", "))).Msg("")
 		log.Info().Str(corsHeaderInfo, fmt.Sprintf("Access-Control-Max-Age: %d", corsConfig.MaxAgeSeconds)).Msg("")
 	} else {
 		log.Info().Msg("CORS Headers are disabled.")
@@ -166,6 +216,7 @@ func (h *HTTP) setupCORS() {
 			AllowedHeaders:   corsConfig.AllowedHeaders,
 			AllowedMethods:   corsConfig.AllowedMethods,
 			AllowedOrigins:   corsConfig.AllowedOrigins,
+			ExposedHeaders:   corsConfig.ExposedHeaders,
 			MaxAge:           corsConfig.MaxAgeSeconds,
 		}))
 	}
@@ -1,45 +1,33 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
 	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/evermos/boilerplate-go/transport/http/response"
 )
 
 type Authentication struct {
-	db *infras.MySQLConn
+	db     *infras.MySQLConn
+	config oauth.Config
 }
 
 const (
 	HeaderAuthorization = "Authorization"
 )
 
-func ProvideAuthentication(db *infras.MySQLConn) *Authentication {
+func ProvideAuthentication(db *infras.MySQLConn, config oauth.Config) *Authentication {
 	return &Authentication{
-		db: db,
+		db:     db,
+		config: config,
 	}
 }
 
 func (a *Authentication) ClientCredential(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		accessToken := r.Header.Get(HeaderAuthorization)
-		token := oauth.New(a.db.Read, oauth.Config{})
-
-		parseToken, err := token.ParseWithAccessToken(accessToken)
-		if err != nil {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-
-		if !parseToken.VerifyExpireIn() {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
+	return a.Authorize(Access{})(next)
 }
 
 func (a *Authentication) ClientCredentialWithQueryParameter(next http.Handler) http.Handler {
@@ -49,43 +37,106 @@ func (a *Authentication) ClientCredentialWithQueryParameter(next http.Handler) h
 		tokenType := params.Get("token_type")
 		accessToken := tokenType + " " + token
 
-		auth := oauth.New(a.db.Read, oauth.Config{})
-		parseToken, err := auth.ParseWithAccessToken(accessToken)
-		if err != nil {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
+		parseToken, ok := a.authenticate(w, accessToken)
+		if !ok {
 			return
 		}
 
-		if !parseToken.VerifyExpireIn() {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
-			return
-		}
-
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, withPrincipal(r, parseToken))
 	})
 }
 
 func (a *Authentication) Password(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		accessToken := r.Header.Get(HeaderAuthorization)
-		token := oauth.New(a.db.Read, oauth.Config{})
+	return a.Authorize(Access{User: true})(next)
+}
 
-		parseToken, err := token.ParseWithAccessToken(accessToken)
-		if err != nil {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
-			return
-		}
+// Require authenticates the access token of a request like ClientCredential
+// does, and requires it to be granted all of the permissions.
+func (a *Authentication) Require(permissions ...string) func(http.Handler) http.Handler {
+	return a.Authorize(Access{Permissions: permissions})
+}
 
-		if !parseToken.VerifyExpireIn() {
-			response.WithMessage(w, http.StatusUnauthorized, err.Error())
-			return
-		}
+// Access is the authentication a route requires.
+type Access struct {
+	// Public routes are open to requests without an access token.
+	Public bool
+	// User requires the access token to be issued to a user, by the password
+	// grant, rather than to a client acting on its own.
+	User bool
+	// Permissions are the scopes the access token must be granted.
+	Permissions []string
+}
 
-		if !parseToken.VerifyUserLoggedIn() {
-			response.WithMessage(w, http.StatusUnauthorized, oauth.ErrorInvalidPassword)
-			return
-		}
+// Authorize requires requests to be granted access.
+func (a *Authentication) Authorize(access Access) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if access.Public {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			parseToken, ok := a.authenticate(w, r.Header.Get(HeaderAuthorization))
+			if !ok {
+				return
+			}
+
+			if access.User && !parseToken.VerifyUserLoggedIn() {
+				response.WithMessage(w, http.StatusUnauthorized, oauth.ErrorInvalidPassword)
+				return
+			}
+
+			for _, permission := range access.Permissions {
+				if !parseToken.HasScope(permission) {
+					response.WithError(w, failure.Forbidden(fmt.Sprintf("missing permission %s", permission)))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, withPrincipal(r, parseToken))
+		})
+	}
+}
 
-		next.ServeHTTP(w, r)
-	})
+// AuthorizeMethods requires reads, that is GET, HEAD and OPTIONS requests, to
+// be granted read access, and any other request write access.
+func (a *Authentication) AuthorizeMethods(read Access, write Access) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		readHandler := a.Authorize(read)(next)
+		writeHandler := a.Authorize(write)(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				readHandler.ServeHTTP(w, r)
+			default:
+				writeHandler.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// authenticate parses an access token, responding with 401 when it is invalid
+// or expired.
+func (a *Authentication) authenticate(w http.ResponseWriter, accessToken string) (oauth.OauthAccessToken, bool) {
+	token := oauth.New(a.db.Read, a.config)
+
+	parseToken, err := token.ParseWithAccessToken(accessToken)
+	if err != nil {
+		response.WithMessage(w, http.StatusUnauthorized, err.Error())
+		return parseToken, false
+	}
+
+	if !parseToken.VerifyExpireIn() {
+		response.WithMessage(w, http.StatusUnauthorized, oauth.ErrorInvalidToken)
+		return parseToken, false
+	}
+
+	return parseToken, true
+}
+
+// withPrincipal carries the principal authenticated by a token in the context
+// of a request.
+func withPrincipal(r *http.Request, token oauth.OauthAccessToken) *http.Request {
+	return r.WithContext(oauth.WithPrincipal(r.Context(), token.Principal()))
 }
@@ -0,0 +1,153 @@
+package middleware_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// revocationList revokes the JWTs, refresh token families and clients it
+// lists.
+type revocationList map[string]bool
+
+func (l revocationList) IsRevoked(claims oauth.Claims) (bool, error) {
+	return l[claims.ID] || l[claims.SessionID] || l[claims.ClientID], nil
+}
+
+// newJWTAuthentication authenticates JWT access tokens, which are verified
+// without the database and checked against revoked.
+func newJWTAuthentication(t *testing.T, revoked revocationList) (*middleware.Authentication, *oauth.KeySet) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keySet, err := oauth.NewKeySet("", key)
+	require.NoError(t, err)
+
+	config := oauth.Config{KeySet: keySet, RevocationList: revoked}
+	return middleware.ProvideAuthentication(&infras.MySQLConn{}, config), keySet
+}
+
+func TestAuthenticationRequire(t *testing.T) {
+	authentication, keySet := newJWTAuthentication(t, revocationList{})
+	handler := authentication.Require(oauth.PermissionCatalogWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(scope string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/product", nil)
+		if scope != "-" {
+			token, err := keySet.Sign(oauth.Claims{
+				ClientID: "client_web",
+				Scope:    scope,
+				Expires:  time.Now().Add(time.Minute).Unix(),
+			})
+			require.NoError(t, err)
+			req.Header.Set(middleware.HeaderAuthorization, "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("-"))
+	assert.Equal(t, http.StatusForbidden, serve(""))
+	assert.Equal(t, http.StatusForbidden, serve("catalog:read"))
+	assert.Equal(t, http.StatusNoContent, serve("catalog:read catalog:write"))
+}
+
+func TestAuthenticationAuthorizeMethods(t *testing.T) {
+	authentication, keySet := newJWTAuthentication(t, revocationList{})
+	handler := authentication.AuthorizeMethods(
+		middleware.Access{Public: true},
+		middleware.Access{User: true, Permissions: []string{oauth.PermissionCatalogWrite}},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(method string, subject string, scope string) int {
+		req := httptest.NewRequest(method, "/v1/product", nil)
+		if scope != "-" {
+			token, err := keySet.Sign(oauth.Claims{
+				Subject:  subject,
+				ClientID: "client_web",
+				Scope:    scope,
+				Expires:  time.Now().Add(time.Minute).Unix(),
+			})
+			require.NoError(t, err)
+			req.Header.Set(middleware.HeaderAuthorization, "Bearer "+token)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	userID := "0c4a2b1e-6a3f-4f0e-9a53-6f4a3c8e2d11"
+
+	t.Run("reads are public", func(t *testing.T) {
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodGet, "", "-"))
+	})
+
+	t.Run("writes require a user", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "", "-"))
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodPost, "", "catalog:write"))
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodPost, userID, "catalog:write"))
+	})
+
+	t.Run("writes require the permission", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(http.MethodPut, userID, "catalog:read"))
+		assert.Equal(t, http.StatusNoContent, serve(http.MethodDelete, userID, "catalog:read catalog:write"))
+	})
+}
+
+func TestAuthenticationRevokedJWT(t *testing.T) {
+	revoked := revocationList{}
+	authentication, keySet := newJWTAuthentication(t, revoked)
+	handler := authentication.Require(oauth.PermissionCatalogRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	token, err := keySet.Sign(oauth.Claims{
+		ClientID:  "client_web",
+		Scope:     "catalog:read",
+		Expires:   time.Now().Add(time.Minute).Unix(),
+		SessionID: "5b0d8c1e-2f4a-4c7e-8f1d-3a6b9e2c4d10",
+	})
+	require.NoError(t, err)
+	claims, err := keySet.Verify(token)
+	require.NoError(t, err)
+
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/product", nil)
+		req.Header.Set(middleware.HeaderAuthorization, "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve())
+
+	for name, id := range map[string]string{
+		"rejects a revoked token":              claims.ID,
+		"rejects a token of a revoked family":  claims.SessionID,
+		"rejects a token of a disabled client": claims.ClientID,
+	} {
+		t.Run(name, func(t *testing.T) {
+			revoked[id] = true
+			defer delete(revoked, id)
+
+			assert.Equal(t, http.StatusUnauthorized, serve())
+		})
+	}
+}
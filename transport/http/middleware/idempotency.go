@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/evermos/boilerplate-go/shared/failure"
+	"github.com/evermos/boilerplate-go/shared/logger"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/response"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotent-Replayed"
+
+	// IdempotencyStoreRedis selects the Redis-backed idempotency store.
+	IdempotencyStoreTypeRedis = "redis"
+	// IdempotencyStoreMySQL selects the MySQL-backed idempotency store.
+	IdempotencyStoreTypeMySQL = "mysql"
+
+	maxIdempotencyKeyLength = 255
+
+	defaultIdempotencyTTLSeconds   = 24 * 3600
+	defaultIdempotencyLeaseSeconds = 60
+)
+
+// IdempotencyRecord is the stored state of a request made with an
+// Idempotency-Key.
+type IdempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	StatusCode  int         `json:"statusCode"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// IdempotencyStore persists idempotency records for a limited window.
+type IdempotencyStore interface {
+	// Reserve atomically claims a key for an in-flight request, until its
+	// lease expires. When the key is already taken, the existing record is
+	// returned and reserved is false.
+	Reserve(key string, fingerprint string, lease time.Duration) (existing IdempotencyRecord, reserved bool, err error)
+	// Save stores the final response of a reserved key.
+	Save(key string, record IdempotencyRecord, ttl time.Duration) error
+	// Release frees a reserved key so that the request can be retried.
+	Release(key string) error
+}
+
+// Idempotency makes POST requests carrying an Idempotency-Key safe to retry.
+// Keys are scoped to the authenticated caller. A request in flight holds its
+// key for a short lease, so that a key is freed when its process dies.
+// Responses that must not be stored, marked Cache-Control: no-store, such as
+// those carrying secrets, are never replayed.
+type Idempotency struct {
+	store IdempotencyStore
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// ProvideIdempotency is the provider for Idempotency. The backing store is
+// selected through configuration.
+func ProvideIdempotency(config *configs.Config, db *infras.MySQLConn) *Idempotency {
+	idempotencyConfig := config.App.Idempotency
+	if idempotencyConfig.TTLSeconds <= 0 {
+		idempotencyConfig.TTLSeconds = defaultIdempotencyTTLSeconds
+	}
+	if idempotencyConfig.LeaseSeconds <= 0 {
+		idempotencyConfig.LeaseSeconds = defaultIdempotencyLeaseSeconds
+	}
+
+	var store IdempotencyStore
+	switch idempotencyConfig.Store {
+	case IdempotencyStoreTypeRedis:
+		store = NewIdempotencyStoreRedis(infras.RedisNewClient(*config))
+	default:
+		store = NewIdempotencyStoreMySQL(db)
+	}
+
+	log.Info().
+		Str("store", idempotencyConfig.Store).
+		Int64("ttlSeconds", idempotencyConfig.TTLSeconds).
+		Int64("leaseSeconds", idempotencyConfig.LeaseSeconds).
+		Msg("Idempotency keys enabled.")
+
+	return NewIdempotency(store,
+		time.Duration(idempotencyConfig.TTLSeconds)*time.Second,
+		time.Duration(idempotencyConfig.LeaseSeconds)*time.Second)
+}
+
+// NewIdempotency creates an Idempotency middleware with the given store.
+// Responses are kept for ttl, and requests in flight hold their key for lease.
+func NewIdempotency(store IdempotencyStore, ttl time.Duration, lease time.Duration) *Idempotency {
+	return &Idempotency{
+		store: store,
+		ttl:   ttl,
+		lease: lease,
+	}
+}
+
+// Middleware replays the stored response of a POST request that was already
+// processed under the same Idempotency-Key by the same caller, and rejects
+// reuse of a key with a different request.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderIdempotencyKey)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			response.WithError(w, failure.BadRequestFromString("Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			response.WithError(w, failure.BadRequest(err))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		key = i.scopedKey(r, key)
+		fingerprint := i.fingerprint(r, body)
+		existing, reserved, err := i.store.Reserve(key, fingerprint, i.lease)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			response.WithError(w, failure.InternalError(err))
+			return
+		}
+
+		if !reserved {
+			i.respondWithExisting(w, existing, fingerprint)
+			return
+		}
+
+		recorder := newResponseRecorder(w)
+		defer func() {
+			if rec := recover(); rec != nil {
+				i.release(key)
+				panic(rec)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+
+		// Server errors are not final, let the client retry with the same key.
+		// Responses that must not be stored free their key as well.
+		if recorder.statusCode >= http.StatusInternalServerError || isNoStore(recorder.Header()) {
+			i.release(key)
+			return
+		}
+
+		err = i.store.Save(key, IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			StatusCode:  recorder.statusCode,
+			Header:      recorder.Header().Clone(),
+			Body:        recorder.body.Bytes(),
+		}, i.ttl)
+		if err != nil {
+			logger.ErrorWithStack(err)
+		}
+	})
+}
+
+func (i *Idempotency) respondWithExisting(w http.ResponseWriter, existing IdempotencyRecord, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		response.WithError(w, failure.Conflict("idempotency", "request", "Idempotency-Key was already used with a different request"))
+		return
+	}
+
+	if !existing.Completed {
+		response.WithError(w, failure.Conflict("idempotency", "request", "a request with this Idempotency-Key is still being processed"))
+		return
+	}
+
+	for name, values := range existing.Header {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+	w.Header().Set(HeaderIdempotencyReplayed, "true")
+	w.WriteHeader(existing.StatusCode)
+	_, err := w.Write(existing.Body)
+	if err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+func (i *Idempotency) release(key string) {
+	if err := i.store.Release(key); err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+// scopedKey scopes an Idempotency-Key to the client and the user of the
+// principal of a request, so that callers never share keys.
+func (i *Idempotency) scopedKey(r *http.Request, key string) string {
+	principal, _ := oauth.PrincipalFromContext(r.Context())
+
+	hash := sha256.New()
+	hash.Write([]byte(principal.ClientID))
+	hash.Write([]byte{0})
+	hash.Write([]byte(principal.UserID.UUID.String()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(key))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// fingerprint identifies a request by its method, path and body.
+func (i *Idempotency) fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// isNoStore reports whether a response must not be stored, RFC 7234 section
+// 5.2.2.3.
+func isNoStore(header http.Header) bool {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/evermos/boilerplate-go/infras"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlErrorDuplicateEntry = 1062
+
+	queryInsertIdempotencyKey = `
+		INSERT INTO idempotency_keys (
+			idempotency_key,
+			fingerprint,
+			completed,
+			expires
+		) VALUES (?, ?, FALSE, ?)`
+
+	querySelectIdempotencyKey = `
+		SELECT
+			fingerprint,
+			completed,
+			status_code,
+			response_header,
+			response_body
+		FROM idempotency_keys
+		WHERE idempotency_key = ?`
+
+	queryUpdateIdempotencyKey = `
+		UPDATE idempotency_keys
+		SET
+			completed = TRUE,
+			status_code = ?,
+			response_header = ?,
+			response_body = ?,
+			expires = ?
+		WHERE idempotency_key = ?`
+
+	queryDeleteIdempotencyKey        = "DELETE FROM idempotency_keys WHERE idempotency_key = ?"
+	queryDeleteExpiredIdempotencyKey = "DELETE FROM idempotency_keys WHERE idempotency_key = ? AND expires < ?"
+)
+
+// IdempotencyStoreMySQL is the MySQL-backed implementation of IdempotencyStore.
+type IdempotencyStoreMySQL struct {
+	db *infras.MySQLConn
+}
+
+// NewIdempotencyStoreMySQL creates a new IdempotencyStoreMySQL.
+func NewIdempotencyStoreMySQL(db *infras.MySQLConn) *IdempotencyStoreMySQL {
+	return &IdempotencyStoreMySQL{db: db}
+}
+
+// Reserve claims a key by inserting it, relying on the primary key to reject
+// concurrent reservations.
+func (s *IdempotencyStoreMySQL) Reserve(key string, fingerprint string, lease time.Duration) (existing IdempotencyRecord, reserved bool, err error) {
+	now := time.Now()
+	_, err = s.db.Write.Exec(queryDeleteExpiredIdempotencyKey, key, now)
+	if err != nil {
+		return
+	}
+
+	_, err = s.db.Write.Exec(queryInsertIdempotencyKey, key, fingerprint, now.Add(lease))
+	if err == nil {
+		return existing, true, nil
+	}
+
+	if mysqlErr, ok := err.(*mysql.MySQLError); !ok || mysqlErr.Number != mysqlErrorDuplicateEntry {
+		return
+	}
+
+	// read from the primary so that a fresh reservation is visible
+	var row struct {
+		Fingerprint    string `db:"fingerprint"`
+		Completed      bool   `db:"completed"`
+		StatusCode     *int   `db:"status_code"`
+		ResponseHeader []byte `db:"response_header"`
+		ResponseBody   []byte `db:"response_body"`
+	}
+	err = s.db.Write.Get(&row, querySelectIdempotencyKey, key)
+	if err != nil {
+		return
+	}
+
+	existing = IdempotencyRecord{
+		Fingerprint: row.Fingerprint,
+		Completed:   row.Completed,
+		Body:        row.ResponseBody,
+	}
+	if row.StatusCode != nil {
+		existing.StatusCode = *row.StatusCode
+	}
+	if len(row.ResponseHeader) > 0 {
+		err = json.Unmarshal(row.ResponseHeader, &existing.Header)
+	}
+
+	return
+}
+
+// Save stores the final response of a reserved key.
+func (s *IdempotencyStoreMySQL) Save(key string, record IdempotencyRecord, ttl time.Duration) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Write.Exec(queryUpdateIdempotencyKey, record.StatusCode, header, record.Body, time.Now().Add(ttl), key)
+	return err
+}
+
+// Release frees a reserved key.
+func (s *IdempotencyStoreMySQL) Release(key string) error {
+	_, err := s.db.Write.Exec(queryDeleteIdempotencyKey, key)
+	return err
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	idempotencyRedisKeyPrefix = "idempotency:"
+
+	// maxIdempotencyReserveAttempts bounds the attempts of a reservation
+	// racing keys that expire in between.
+	maxIdempotencyReserveAttempts = 3
+)
+
+// errIdempotencyReserveContended is returned when a key keeps expiring
+// between the attempts of a reservation.
+var errIdempotencyReserveContended = errors.New("idempotency key reservation contended")
+
+// IdempotencyStoreRedis is the Redis-backed implementation of IdempotencyStore.
+type IdempotencyStoreRedis struct {
+	client *redis.Client
+}
+
+// NewIdempotencyStoreRedis creates a new IdempotencyStoreRedis.
+func NewIdempotencyStoreRedis(client *redis.Client) *IdempotencyStoreRedis {
+	return &IdempotencyStoreRedis{client: client}
+}
+
+// Reserve claims a key using SETNX so that only one request wins the race.
+// A key expiring between SETNX and GET is claimed again, a few times at most.
+func (s *IdempotencyStoreRedis) Reserve(key string, fingerprint string, lease time.Duration) (existing IdempotencyRecord, reserved bool, err error) {
+	value, err := json.Marshal(IdempotencyRecord{Fingerprint: fingerprint})
+	if err != nil {
+		return
+	}
+
+	for attempt := 0; attempt < maxIdempotencyReserveAttempts; attempt++ {
+		reserved, err = s.client.SetNX(idempotencyRedisKeyPrefix+key, value, lease).Result()
+		if err != nil || reserved {
+			return
+		}
+
+		var stored []byte
+		stored, err = s.client.Get(idempotencyRedisKeyPrefix + key).Bytes()
+		if err == redis.Nil {
+			// the key expired in between, try again
+			continue
+		}
+		if err != nil {
+			return
+		}
+
+		err = json.Unmarshal(stored, &existing)
+		return
+	}
+
+	return existing, false, errIdempotencyReserveContended
+}
+
+// Save stores the final response of a reserved key.
+func (s *IdempotencyStoreRedis) Save(key string, record IdempotencyRecord, ttl time.Duration) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(idempotencyRedisKeyPrefix+key, value, ttl).Err()
+}
+
+// Release frees a reserved key.
+func (s *IdempotencyStoreRedis) Release(key string) error {
+	return s.client.Del(idempotencyRedisKeyPrefix + key).Err()
+}
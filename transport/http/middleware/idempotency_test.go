@@ -0,0 +1,194 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/evermos/boilerplate-go/shared/nuuid"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]middleware.IdempotencyRecord
+	expires map[string]time.Time
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{
+		records: make(map[string]middleware.IdempotencyRecord),
+		expires: make(map[string]time.Time),
+	}
+}
+
+func (s *memoryIdempotencyStore) Reserve(key string, fingerprint string, lease time.Duration) (middleware.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok && time.Now().Before(s.expires[key]) {
+		return existing, false, nil
+	}
+	s.records[key] = middleware.IdempotencyRecord{Fingerprint: fingerprint}
+	s.expires[key] = time.Now().Add(lease)
+	return middleware.IdempotencyRecord{}, true, nil
+}
+
+func (s *memoryIdempotencyStore) Save(key string, record middleware.IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+	s.expires[key] = time.Now().Add(ttl)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	delete(s.expires, key)
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	newHandler := func(calls *int, status int) http.Handler {
+		store := newMemoryIdempotencyStore()
+		idempotency := middleware.NewIdempotency(store, time.Minute, time.Minute)
+		return idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"data":"created"}`))
+		}))
+	}
+
+	post := func(handler http.Handler, key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/product", strings.NewReader(body))
+		req.Header.Set(middleware.HeaderIdempotencyKey, key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("replays the stored response", func(t *testing.T) {
+		calls := 0
+		handler := newHandler(&calls, http.StatusCreated)
+
+		first := post(handler, "key-1", `{"productName":"a"}`)
+		second := post(handler, "key-1", `{"productName":"a"}`)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get(middleware.HeaderIdempotencyReplayed))
+	})
+
+	t.Run("rejects a different body with the same key", func(t *testing.T) {
+		calls := 0
+		handler := newHandler(&calls, http.StatusCreated)
+
+		post(handler, "key-1", `{"productName":"a"}`)
+		second := post(handler, "key-1", `{"productName":"b"}`)
+
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, http.StatusConflict, second.Code)
+	})
+
+	t.Run("allows retrying after a server error", func(t *testing.T) {
+		calls := 0
+		handler := newHandler(&calls, http.StatusInternalServerError)
+
+		post(handler, "key-1", `{"productName":"a"}`)
+		post(handler, "key-1", `{"productName":"a"}`)
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("never replays a response that must not be stored", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		calls := 0
+		handler := middleware.NewIdempotency(store, time.Minute, time.Minute).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Cache-Control", "private, no-store")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"data":{"clientSecret":"secret-%d"}}`, calls)
+		}))
+
+		first := post(handler, "key-1", `{"clientId":"a"}`)
+		second := post(handler, "key-1", `{"clientId":"a"}`)
+
+		assert.Equal(t, 2, calls)
+		assert.Contains(t, first.Body.String(), "secret-1")
+		assert.NotContains(t, second.Body.String(), "secret-1")
+		assert.Empty(t, second.Header().Get(middleware.HeaderIdempotencyReplayed))
+		assert.Empty(t, store.records)
+	})
+
+	t.Run("ignores requests without a key", func(t *testing.T) {
+		calls := 0
+		handler := newHandler(&calls, http.StatusCreated)
+
+		post(handler, "", `{"productName":"a"}`)
+		post(handler, "", `{"productName":"a"}`)
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("scopes keys to the caller", func(t *testing.T) {
+		calls := 0
+		handler := newHandler(&calls, http.StatusCreated)
+		postAs := func(principal oauth.Principal) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/v1/product", strings.NewReader(`{"productName":"a"}`))
+			req = req.WithContext(oauth.WithPrincipal(req.Context(), principal))
+			req.Header.Set(middleware.HeaderIdempotencyKey, "key-1")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+
+		postAs(oauth.Principal{ClientID: "client_web", UserID: nuuid.From(uuid.Must(uuid.NewV4()))})
+		other := postAs(oauth.Principal{ClientID: "client_web", UserID: nuuid.From(uuid.Must(uuid.NewV4()))})
+		client := postAs(oauth.Principal{ClientID: "client_app"})
+
+		assert.Equal(t, 3, calls)
+		assert.Empty(t, other.Header().Get(middleware.HeaderIdempotencyReplayed))
+		assert.Empty(t, client.Header().Get(middleware.HeaderIdempotencyReplayed))
+	})
+
+	t.Run("frees the key of a request in flight once its lease expires", func(t *testing.T) {
+		idempotency := middleware.NewIdempotency(newMemoryIdempotencyStore(), time.Minute, 10*time.Millisecond)
+		started, stuck := make(chan struct{}), make(chan struct{})
+		defer close(stuck)
+		handler := idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Stuck") != "" {
+				close(started)
+				<-stuck
+			}
+			w.WriteHeader(http.StatusCreated)
+		}))
+		post := func(stuck bool) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/v1/product", strings.NewReader(`{}`))
+			req.Header.Set(middleware.HeaderIdempotencyKey, "key-1")
+			if stuck {
+				req.Header.Set("X-Stuck", "true")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+
+		// a request whose process hangs holds its key
+		go post(true)
+		<-started
+		assert.Equal(t, http.StatusConflict, post(false).Code)
+
+		time.Sleep(20 * time.Millisecond)
+
+		assert.Equal(t, http.StatusCreated, post(false).Code)
+	})
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/evermos/boilerplate-go/configs"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	HeaderForwardedFor = "X-Forwarded-For"
+	HeaderRealIP       = "X-Real-IP"
+)
+
+// RealIP sets the remote address of requests to the address of their client,
+// which trusted proxies forward in the X-Forwarded-For or X-Real-IP headers.
+// The headers of other requests are ignored, so that clients cannot spoof
+// their address.
+type RealIP struct {
+	trustedProxies []*net.IPNet
+}
+
+// ProvideRealIP is the provider for RealIP, trusting the proxies of
+// SERVER.TRUSTED_PROXIES.
+func ProvideRealIP(config *configs.Config) *RealIP {
+	realIP, err := NewRealIP(config.Server.TrustedProxies)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed parsing trusted proxies")
+	}
+
+	if len(config.Server.TrustedProxies) > 0 {
+		log.Info().Strs("trustedProxies", config.Server.TrustedProxies).Msg("Forwarded client addresses enabled.")
+	}
+
+	return realIP
+}
+
+// NewRealIP creates a RealIP trusting the proxies of the given CIDRs or IP
+// addresses.
+func NewRealIP(trustedProxies []string) (*RealIP, error) {
+	realIP := &RealIP{}
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %s", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				bits = 8 * net.IPv4len
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %s: %w", proxy, err)
+		}
+		realIP.trustedProxies = append(realIP.trustedProxies, network)
+	}
+
+	return realIP, nil
+}
+
+// Middleware sets the remote address of requests from trusted proxies.
+func (m *RealIP) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		if m.trusted(net.ParseIP(host)) {
+			if ip := m.clientIP(r); ip != "" {
+				r.RemoteAddr = ip
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the address of the client of a request from a trusted
+// proxy. X-Forwarded-For is read from the right, as the addresses on the left
+// of the last untrusted one are set by the client.
+func (m *RealIP) clientIP(r *http.Request) string {
+	forwarded := strings.Split(strings.Join(r.Header.Values(HeaderForwardedFor), ","), ",")
+	client := ""
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+
+		client = ip.String()
+		if !m.trusted(ip) {
+			return client
+		}
+	}
+	if client != "" {
+		return client
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(HeaderRealIP))); ip != nil {
+		return ip.String()
+	}
+
+	return ""
+}
+
+func (m *RealIP) trusted(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range m.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
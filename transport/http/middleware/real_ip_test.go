@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealIP(t *testing.T) {
+	realIP, err := middleware.NewRealIP([]string{"10.0.0.0/8", "192.0.2.10"})
+	require.NoError(t, err)
+
+	remoteAddr := func(from string, header http.Header) string {
+		var got string
+		handler := realIP.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.RemoteAddr
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/oauth/token", nil)
+		req.RemoteAddr = from
+		for name, values := range header {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		return got
+	}
+
+	t.Run("takes the client forwarded by a trusted proxy", func(t *testing.T) {
+		header := http.Header{middleware.HeaderForwardedFor: {"198.51.100.7, 10.0.0.2"}}
+
+		assert.Equal(t, "198.51.100.7", remoteAddr("10.0.0.1:4000", header))
+		assert.Equal(t, "198.51.100.7", remoteAddr("192.0.2.10:4000", header))
+	})
+
+	t.Run("ignores addresses spoofed by the client", func(t *testing.T) {
+		header := http.Header{middleware.HeaderForwardedFor: {"203.0.113.1", "198.51.100.7"}}
+
+		assert.Equal(t, "198.51.100.7", remoteAddr("10.0.0.1:4000", header))
+	})
+
+	t.Run("falls back to X-Real-IP", func(t *testing.T) {
+		header := http.Header{middleware.HeaderRealIP: {"198.51.100.7"}}
+
+		assert.Equal(t, "198.51.100.7", remoteAddr("10.0.0.1:4000", header))
+	})
+
+	t.Run("ignores the headers of untrusted peers", func(t *testing.T) {
+		header := http.Header{
+			middleware.HeaderForwardedFor: {"203.0.113.1"},
+			middleware.HeaderRealIP:       {"203.0.113.1"},
+		}
+
+		assert.Equal(t, "198.51.100.7:4000", remoteAddr("198.51.100.7:4000", header))
+	})
+
+	t.Run("rejects invalid proxies", func(t *testing.T) {
+		_, err := middleware.NewRealIP([]string{"10.0.0.0/33"})
+		assert.Error(t, err)
+
+		_, err = middleware.NewRealIP([]string{"proxy"})
+		assert.Error(t, err)
+	})
+}
@@ -1,7 +1,12 @@
 package router
 
 import (
+	"expvar"
+
+	"github.com/evermos/boilerplate-go/configs"
 	"github.com/evermos/boilerplate-go/internal/handlers"
+	"github.com/evermos/boilerplate-go/shared/oauth"
+	"github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/go-chi/chi"
 )
 
@@ -13,28 +18,86 @@ type DomainHandlers struct {
 	ProductHandler   handlers.ProductHandler
 	VariantHandler   handlers.VariantHandler
 	WarehouseHandler handlers.WarehouseHandler
+
+	FailedEventHandler  handlers.FailedEventHandler
+	OAuthHandler        handlers.OAuthHandler
+	OAuthClientHandler  handlers.OAuthClientHandler
+	ScheduledJobHandler handlers.ScheduledJobHandler
+	SNSHandler          handlers.SNSHandler
 }
 
 // Router is the router struct containing handlers.
 type Router struct {
+	Config         *configs.Config
 	DomainHandlers DomainHandlers
+	Authentication *middleware.Authentication
+	Idempotency    *middleware.Idempotency
 }
 
 // ProvideRouter is the provider function for this router.
-func ProvideRouter(domainHandlers DomainHandlers) Router {
+func ProvideRouter(config *configs.Config, domainHandlers DomainHandlers, authentication *middleware.Authentication, idempotency *middleware.Idempotency) Router {
 	return Router{
+		Config:         config,
 		DomainHandlers: domainHandlers,
+		Authentication: authentication,
+		Idempotency:    idempotency,
 	}
 }
 
-// SetupRoutes sets up all routing for this server.
+// SetupRoutes sets up all routing for this server, along the access required
+// by the reads and writes of each group of routes.
 func (r *Router) SetupRoutes(mux *chi.Mux) {
+	r.DomainHandlers.OAuthHandler.Router(mux)
+
+	public := middleware.Access{Public: true}
+	catalogRead := middleware.Access{
+		Public:      r.Config.Server.PublicCatalogReads,
+		Permissions: []string{oauth.PermissionCatalogRead},
+	}
+	catalogWrite := middleware.Access{User: true, Permissions: []string{oauth.PermissionCatalogWrite}}
+	inventoryRead := middleware.Access{Permissions: []string{oauth.PermissionInventoryRead}}
+	inventoryAdjust := middleware.Access{User: true, Permissions: []string{oauth.PermissionInventoryAdjust}}
+	warehousesAdmin := middleware.Access{User: true, Permissions: []string{oauth.PermissionWarehousesAdmin}}
+	usersAdmin := middleware.Access{User: true, Permissions: []string{oauth.PermissionUsersAdmin}}
+	systemAdmin := middleware.Access{Permissions: []string{oauth.PermissionSystemAdmin}}
+
 	mux.Route("/v1", func(rc chi.Router) {
-		r.DomainHandlers.FooBarBazHandler.Router(rc)
-		r.DomainHandlers.UserHandler.Router(rc)
-		r.DomainHandlers.BrandHandler.Router(rc)
-		r.DomainHandlers.ProductHandler.Router(rc)
-		r.DomainHandlers.VariantHandler.Router(rc)
-		r.DomainHandlers.WarehouseHandler.Router(rc)
+		r.group(rc, middleware.Access{}, middleware.Access{User: true},
+			r.DomainHandlers.FooBarBazHandler.Router)
+		r.group(rc, catalogRead, catalogWrite,
+			r.DomainHandlers.BrandHandler.Router,
+			r.DomainHandlers.ProductHandler.Router,
+			r.DomainHandlers.VariantHandler.Router)
+		r.group(rc, inventoryRead, warehousesAdmin,
+			r.DomainHandlers.WarehouseHandler.Router)
+		r.group(rc, inventoryRead, inventoryAdjust,
+			r.DomainHandlers.WarehouseHandler.QuantityRouter)
+		r.group(rc, usersAdmin, usersAdmin,
+			r.DomainHandlers.UserHandler.Router)
+		r.group(rc, systemAdmin, systemAdmin,
+			r.DomainHandlers.FailedEventHandler.Router,
+			r.DomainHandlers.OAuthClientHandler.Router,
+			r.DomainHandlers.ScheduledJobHandler.Router)
+		// SNS messages are authenticated by their signature
+		r.group(rc, public, public,
+			r.DomainHandlers.SNSHandler.Router)
+	})
+
+	// metrics expose the command line and memory statistics of the process
+	r.group(mux, systemAdmin, systemAdmin, func(rc chi.Router) {
+		rc.Handle("/debug/vars", expvar.Handler())
+	})
+}
+
+// group sets up routes requiring read access for their reads and write access
+// for their writes. Requests are authorized before their idempotency key is
+// taken, so that a rejected request does not take it.
+func (r *Router) group(rc chi.Router, read middleware.Access, write middleware.Access, routers ...func(chi.Router)) {
+	rc.Group(func(rc chi.Router) {
+		rc.Use(r.Authentication.AuthorizeMethods(read, write))
+		rc.Use(r.Idempotency.Middleware)
+		for _, router := range routers {
+			router(rc)
+		}
 	})
 }
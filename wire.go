@@ -5,7 +5,16 @@ package main
 
 import (
 	"github.com/evermos/boilerplate-go/configs"
+	"github.com/evermos/boilerplate-go/event"
+	"github.com/evermos/boilerplate-go/event/consumer"
+	"github.com/evermos/boilerplate-go/event/deadletter"
+	fooBarBazEvent "github.com/evermos/boilerplate-go/event/domain/foobarbaz"
+	ordersEvent "github.com/evermos/boilerplate-go/event/domain/orders"
+	"github.com/evermos/boilerplate-go/event/model"
+	"github.com/evermos/boilerplate-go/event/outbox"
 	"github.com/evermos/boilerplate-go/event/producer"
+	"github.com/evermos/boilerplate-go/event/sns"
+	"github.com/evermos/boilerplate-go/event/transport"
 	"github.com/evermos/boilerplate-go/infras"
 	"github.com/evermos/boilerplate-go/internal/domain/brands"
 	"github.com/evermos/boilerplate-go/internal/domain/foobarbaz"
@@ -14,6 +23,9 @@ import (
 	"github.com/evermos/boilerplate-go/internal/domain/variants"
 	"github.com/evermos/boilerplate-go/internal/domain/warehouse"
 	"github.com/evermos/boilerplate-go/internal/handlers"
+	"github.com/evermos/boilerplate-go/internal/jobs"
+	"github.com/evermos/boilerplate-go/scheduler"
+	"github.com/evermos/boilerplate-go/shared/oauth"
 	"github.com/evermos/boilerplate-go/transport/http"
 	"github.com/evermos/boilerplate-go/transport/http/middleware"
 	"github.com/evermos/boilerplate-go/transport/http/router"
@@ -30,6 +42,39 @@ var persistences = wire.NewSet(
 	infras.ProvideMySQLConn,
 )
 
+// Wiring for the transactional outbox.
+var eventOutbox = wire.NewSet(
+	// Repository interface and implementation
+	outbox.ProvideRepositoryMySQL,
+	wire.Bind(new(outbox.Repository), new(*outbox.RepositoryMySQL)),
+)
+
+// Wiring for event payload schemas.
+var eventSchemas = wire.NewSet(
+	model.ProvideSchemaRegistry,
+)
+
+// Wiring for event producers.
+var producers = wire.NewSet(
+	// Producer interface and implementation of the configured transport,
+	// validating payloads before publishing
+	producer.ProvideValidatingProducer,
+	wire.Bind(new(producer.Producer), new(*producer.ValidatingProducer)),
+)
+
+// Wiring for dead-lettered events.
+var deadLetters = wire.NewSet(
+	// FailedEventService interface and implementation
+	deadletter.ProvideFailedEventServiceImpl,
+	wire.Bind(new(deadletter.FailedEventService), new(*deadletter.FailedEventServiceImpl)),
+	wire.Bind(new(consumer.DeadLetterHandler), new(*deadletter.FailedEventServiceImpl)),
+	// FailedEventRepository interface and implementation
+	deadletter.ProvideFailedEventRepositoryMySQL,
+	wire.Bind(new(deadletter.FailedEventRepository), new(*deadletter.FailedEventRepositoryMySQL)),
+	// Requeuer of the configured transport
+	transport.ProvideRequeuer,
+)
+
 // Wiring for domain FooBarBaz.
 var domainFooBarBaz = wire.NewSet(
 	// FooService interface and implementation
@@ -38,9 +83,6 @@ var domainFooBarBaz = wire.NewSet(
 	// FooRepository interface and implementation
 	foobarbaz.ProvideFooRepositoryMySQL,
 	wire.Bind(new(foobarbaz.FooRepository), new(*foobarbaz.FooRepositoryMySQL)),
-	// Producer interface and implementation
-	producer.NewSNSProducer,
-	wire.Bind(new(producer.Producer), new(*producer.SNSProducer)),
 )
 
 // Wiring for domainUser
@@ -100,38 +142,88 @@ var domains = wire.NewSet(
 )
 
 var authMiddleware = wire.NewSet(
+	oauth.ProvideConfig,
 	middleware.ProvideAuthentication,
 )
 
+var idempotencyMiddleware = wire.NewSet(
+	middleware.ProvideIdempotency,
+)
+
+var realIPMiddleware = wire.NewSet(
+	middleware.ProvideRealIP,
+)
+
+// Wiring for scheduled jobs.
+var scheduledJobs = wire.NewSet(
+	wire.Struct(new(scheduler.DomainJobs), "OAuthTokenCleanup"),
+	jobs.ProvideOAuthTokenCleanup,
+	// Locker interface of the configured store
+	scheduler.ProvideLocker,
+	// RunRepository interface and implementation
+	scheduler.ProvideRunRepositoryMySQL,
+	wire.Bind(new(scheduler.RunRepository), new(*scheduler.RunRepositoryMySQL)),
+	scheduler.ProvideScheduler,
+)
+
 // Wiring for HTTP routing.
 var routing = wire.NewSet(
-	wire.Struct(new(router.DomainHandlers), "FooBarBazHandler", "UserHandler", "BrandHandler", "ProductHandler", "VariantHandler", "WarehouseHandler"),
+	wire.Struct(new(router.DomainHandlers), "FooBarBazHandler", "UserHandler", "BrandHandler", "ProductHandler", "VariantHandler", "WarehouseHandler", "FailedEventHandler", "OAuthHandler", "OAuthClientHandler", "ScheduledJobHandler", "SNSHandler"),
 	handlers.ProvideFooBarBazHandler,
 	handlers.ProvideUserHandler,
 	handlers.ProvideBrandHandler,
 	handlers.ProvideProductHandler,
 	handlers.ProvideVariantHandler,
 	handlers.ProvideWarehouseHandler,
+	handlers.ProvideFailedEventHandler,
+	handlers.ProvideOAuthHandler,
+	handlers.ProvideOAuthClientHandler,
+	handlers.ProvideScheduledJobHandler,
+	handlers.ProvideSNSHandler,
 	router.ProvideRouter,
 )
 
+// Wiring for deduplicating consumed events.
+var eventDeduplication = wire.NewSet(
+	consumer.ProvideProcessedEventStore,
+	consumer.ProvideDeduplicator,
+)
+
+// Wiring for SNS HTTPS subscriptions.
+var snsSubscriptions = wire.NewSet(
+	sns.ProvideSubscriber,
+)
+
 // Wiring for all domains event consumer.
-//var evco = wire.NewSet(
-//	wire.Struct(new(event.Consumers), "FooBarBaz"),
-//	fooBarBazEvent.ProvideConsumerImpl,
-//)
+var evco = wire.NewSet(
+	wire.Struct(new(event.Consumers), "FooBarBaz", "Orders"),
+	fooBarBazEvent.ProvideConsumerImpl,
+	ordersEvent.ProvideConsumerImpl,
+)
 
-// Wiring for everything.
-func InitializeService() *http.HTTP {
+// Wiring for everything. The scheduler is shared with the admin endpoints.
+func InitializeService(scheduler *scheduler.Scheduler) *http.HTTP {
 	wire.Build(
 		// configurations
 		configurations,
 		// persistences
 		persistences,
+		// outbox
+		eventOutbox,
+		// producers
+		eventSchemas,
+		producers,
 		// middleware
 		authMiddleware,
+		idempotencyMiddleware,
+		realIPMiddleware,
 		// domains
 		domains,
+		deadLetters,
+		// event consumers for SNS HTTPS subscriptions
+		eventDeduplication,
+		evco,
+		snsSubscriptions,
 		// routing
 		routing,
 		// selected transport layer
@@ -139,17 +231,67 @@ func InitializeService() *http.HTTP {
 	return &http.HTTP{}
 }
 
+// Wiring for the scheduler.
+func InitializeScheduler() *scheduler.Scheduler {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// scheduled jobs
+		scheduledJobs)
+	return &scheduler.Scheduler{}
+}
+
+// Wiring for the outbox relay.
+func InitializeOutboxRelay() *outbox.Relay {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// outbox
+		eventOutbox,
+		// producers
+		eventSchemas,
+		producers,
+		// relay
+		outbox.ProvideRelay)
+	return &outbox.Relay{}
+}
+
+// Wiring for purging processed events.
+func InitializeProcessedEventPurger() *consumer.ProcessedEventPurger {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// purger
+		consumer.ProvideProcessedEventStore,
+		consumer.ProvideProcessedEventPurger)
+	return &consumer.ProcessedEventPurger{}
+}
+
 // Wiring the event needs.
-//func InitializeEvent() event.Consumers {
-//	wire.Build(
-//		// configurations
-//		configurations,
-//		// persistences
-//		persistences,
-//		// domains
-//		domains,
-//		// event consumer
-//		evco)
-//
-//	return event.Consumers{}
-//}
+func InitializeEvent() event.Consumers {
+	wire.Build(
+		// configurations
+		configurations,
+		// persistences
+		persistences,
+		// outbox
+		eventOutbox,
+		// schemas
+		eventSchemas,
+		// domains
+		domains,
+		// dead letters
+		deadLetters,
+		// deduplication
+		eventDeduplication,
+		// event consumer
+		evco)
+
+	return event.Consumers{}
+}